@@ -1,6 +1,8 @@
 package core
 
 import (
+	"context"
+
 	"github.com/madz-lab/go-ibft/messages"
 	"github.com/madz-lab/go-ibft/messages/proto"
 )
@@ -69,3 +71,42 @@ type Backend interface {
 	// specified block height.
 	Quorum(blockHeight uint64) uint64
 }
+
+// SealAggregator is an optional Backend extension that combines the
+// committed seals of a finalized proposal into a single aggregated seal.
+// If implemented, finalized proposals are inserted using InsertAggregatedBlock
+// instead of InsertBlock
+type SealAggregator interface {
+	// AggregateSeals combines the committed seals for the proposal hash at the
+	// specified height into one aggregated seal, with the signer bitmap set
+	AggregateSeals(
+		height uint64,
+		proposalHash []byte,
+		committedSeals []*messages.CommittedSeal,
+	) (*messages.AggregatedSeal, error)
+
+	// IsValidAggregatedSeal checks if the aggregated seal
+	// for the proposal hash at the specified height is valid
+	IsValidAggregatedSeal(height uint64, proposalHash []byte, seal *messages.AggregatedSeal) bool
+
+	// InsertAggregatedBlock inserts a proposal with the aggregated seal.
+	// If an error is returned, the sequence is aborted with the error
+	InsertAggregatedBlock(ctx context.Context, proposal []byte, seal *messages.AggregatedSeal) error
+}
+
+// BatchVerifier is an optional Backend extension that verifies the senders
+// of multiple messages at once, for example with batch signature verification.
+// If implemented, it's used instead of IsValidSender for certificates and
+// message bursts passed to AddMessages
+type BatchVerifier interface {
+	// AreValidSenders checks if the signatures are from the senders,
+	// and returns the result for each of the messages, in order
+	AreValidSenders(messages []*proto.Message) []bool
+}
+
+// ChainReader is an optional Backend extension that
+// provides the latest state of the local chain
+type ChainReader interface {
+	// LatestHeight returns the height of the latest inserted block
+	LatestHeight() uint64
+}
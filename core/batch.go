@@ -0,0 +1,40 @@
+package core
+
+import (
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// areValidSenders checks the senders of the messages, in a single batch if
+// the backend is a BatchVerifier, and returns the result for each message.
+// Batches that don't return a result for every message are rejected entirely
+func (i *IBFT) areValidSenders(messages []*proto.Message) []bool {
+	results := make([]bool, len(messages))
+
+	if len(messages) == 0 {
+		return results
+	}
+
+	verifier, ok := i.backend.(BatchVerifier)
+	if !ok {
+		for index, message := range messages {
+			results[index] = i.backend.IsValidSender(message)
+		}
+
+		return results
+	}
+
+	batch := verifier.AreValidSenders(messages)
+	if len(batch) != len(messages) {
+		i.log.Error(
+			"invalid batch verification result",
+			"messages", len(messages),
+			"results", len(batch),
+		)
+
+		return results
+	}
+
+	copy(results, batch)
+
+	return results
+}
@@ -0,0 +1,268 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder records the batches passed to the batch verifier, and only
+// accepts the senders that don't have the "invalid" prefix
+type batchRecorder struct {
+	batches [][]*proto.Message
+
+	sync.Mutex
+}
+
+func (b *batchRecorder) areValidSenders(messages []*proto.Message) []bool {
+	b.Lock()
+	defer b.Unlock()
+
+	b.batches = append(b.batches, messages)
+
+	results := make([]bool, len(messages))
+	for index, message := range messages {
+		results[index] = !strings.HasPrefix(string(message.From), "invalid")
+	}
+
+	return results
+}
+
+// buildBatchPC builds a prepared certificate for round 0 of height 1
+func buildBatchPC(proposer string, preparers ...string) *proto.PreparedCertificate {
+	view := &proto.View{Height: 1, Round: 0}
+
+	prepares := make([]*proto.Message, 0, len(preparers))
+	for _, from := range preparers {
+		prepares = append(prepares, buildBasicPrepareMessage([]byte("proposal hash"), []byte(from), view))
+	}
+
+	return &proto.PreparedCertificate{
+		ProposalMessage: buildBasicPreprepareMessage(
+			[]byte("proposal"),
+			[]byte("proposal hash"),
+			nil,
+			[]byte(proposer),
+			view,
+		),
+		PrepareMessages: prepares,
+	}
+}
+
+// newBatchTestIBFT creates an IBFT instance with a quorum of 3,
+// backed by the batch verifier that records the batches
+func newBatchTestIBFT(t *testing.T, recorder *batchRecorder, opts ...Option) *IBFT {
+	t.Helper()
+
+	backend := mockBatchVerifierBackend{
+		mockBackend: mockBackend{
+			isValidSenderFn: func(*proto.Message) bool {
+				t.Error("senders are verified one by one")
+
+				return false
+			},
+			isProposerFn: func([]byte, uint64, uint64) bool {
+				return true
+			},
+			quorumFn: func(uint64) uint64 {
+				return 3
+			},
+			isValidProposalHashFn: func([]byte, []byte) bool {
+				return true
+			},
+		},
+		areValidSendersFn: recorder.areValidSenders,
+	}
+
+	return newTestIBFT(t, mockLogger{}, backend, mockTransport{}, opts...)
+}
+
+// TestIBFT_AreValidSenders makes sure senders are verified in a single
+// batch by a BatchVerifier backend, and one by one otherwise
+func TestIBFT_AreValidSenders(t *testing.T) {
+	t.Parallel()
+
+	batch := []*proto.Message{
+		buildBasicPrepareMessage([]byte("hash"), []byte("node 0"), &proto.View{}),
+		buildBasicPrepareMessage([]byte("hash"), []byte("invalid node"), &proto.View{}),
+	}
+
+	t.Run("backend without batch verification", func(t *testing.T) {
+		t.Parallel()
+
+		backend := mockBackend{
+			isValidSenderFn: func(message *proto.Message) bool {
+				return string(message.From) == "node 0"
+			},
+		}
+
+		i := newTestIBFT(t, mockLogger{}, backend, mockTransport{})
+
+		assert.Equal(t, []bool{true, false}, i.areValidSenders(batch))
+	})
+
+	t.Run("batch verification", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &batchRecorder{}
+		i := newBatchTestIBFT(t, recorder)
+
+		assert.Equal(t, []bool{true, false}, i.areValidSenders(batch))
+		assert.Equal(t, [][]*proto.Message{batch}, recorder.batches)
+
+		// Make sure empty batches are not verified
+		assert.Empty(t, i.areValidSenders(nil))
+		assert.Len(t, recorder.batches, 1)
+	})
+
+	t.Run("incomplete batch result", func(t *testing.T) {
+		t.Parallel()
+
+		backend := mockBatchVerifierBackend{
+			areValidSendersFn: func([]*proto.Message) []bool {
+				return []bool{true}
+			},
+		}
+
+		i := newTestIBFT(t, mockLogger{}, backend, mockTransport{})
+
+		assert.Equal(t, []bool{false, false}, i.areValidSenders(batch))
+	})
+}
+
+// TestIBFT_ValidPCs makes sure the senders of all certificates
+// are verified in a single batch
+func TestIBFT_ValidPCs(t *testing.T) {
+	t.Parallel()
+
+	var (
+		recorder = &batchRecorder{}
+		i        = newBatchTestIBFT(t, recorder)
+
+		valid          = buildBatchPC("proposer", "node 1", "node 2")
+		invalidSender  = buildBatchPC("proposer", "node 1", "invalid node")
+		belowQuorum    = buildBatchPC("proposer", "node 1")
+		anotherValid   = buildBatchPC("proposer", "node 3", "node 4")
+		noCertificate  *proto.PreparedCertificate
+		certificates   = []*proto.PreparedCertificate{valid, invalidSender, belowQuorum, noCertificate, anotherValid}
+		expectedBatch  = make([]*proto.Message, 0)
+		expectedResult = []bool{true, false, false, true, true}
+	)
+
+	// Certificates that fail the other checks are not verified
+	for _, certificate := range []*proto.PreparedCertificate{valid, invalidSender, anotherValid} {
+		expectedBatch = append(expectedBatch, certificate.PrepareMessages...)
+	}
+
+	assert.Equal(t, expectedResult, i.validPCs(certificates, 1, 1))
+	assert.Equal(t, [][]*proto.Message{expectedBatch}, recorder.batches)
+}
+
+// TestIBFT_AddMessages makes sure bursts of messages are verified
+// in a single batch, and rejected messages are reported in order
+func TestIBFT_AddMessages(t *testing.T) {
+	t.Parallel()
+
+	var (
+		recorder = &batchRecorder{}
+		added    = make([]*proto.Message, 0)
+
+		store = mockMessages{
+			addMessageFn: func(message *proto.Message) {
+				added = append(added, message)
+			},
+		}
+
+		i = newBatchTestIBFT(t, recorder, WithMessages(store))
+
+		view = &proto.View{Height: 1, Round: 1}
+
+		valid        = buildBasicPrepareMessage([]byte("hash"), []byte("node 0"), view)
+		malformed    = buildBasicPrepareMessage(nil, []byte("node 1"), view)
+		invalid      = buildBasicPrepareMessage([]byte("hash"), []byte("invalid node"), view)
+		oldRound     = buildBasicPrepareMessage([]byte("hash"), []byte("node 2"), &proto.View{Height: 1, Round: 0})
+		anotherValid = buildBasicCommitMessage([]byte("hash"), []byte("seal"), []byte("node 3"), view)
+	)
+
+	i.state.setView(view)
+
+	errs := i.AddMessages([]*proto.Message{valid, malformed, invalid, oldRound, anotherValid})
+
+	require.Len(t, errs, 5)
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], ErrMalformedPayload)
+	assert.ErrorIs(t, errs[2], ErrInvalidSender)
+	assert.ErrorIs(t, errs[3], ErrOldRound)
+	assert.NoError(t, errs[4])
+
+	// Make sure only structurally valid messages are verified, in a single batch
+	assert.Equal(t, [][]*proto.Message{{valid, invalid, oldRound, anotherValid}}, recorder.batches)
+	assert.Equal(t, []*proto.Message{valid, anotherValid}, added)
+}
+
+// TestIBFT_HandleRoundChangeMessage_Batch makes sure the certificates of the
+// received ROUND_CHANGE messages are verified in a single batch
+func TestIBFT_HandleRoundChangeMessage_Batch(t *testing.T) {
+	t.Parallel()
+
+	var (
+		recorder = &batchRecorder{}
+		store    = messages.NewMessages()
+		i        = newBatchTestIBFT(t, recorder, WithMessages(store))
+
+		view         = &proto.View{Height: 1, Round: 1}
+		certificates = []*proto.PreparedCertificate{
+			buildBatchPC("proposer", "node 1", "node 2"),
+			buildBatchPC("proposer", "node 0", "invalid node"),
+			nil,
+			buildBatchPC("proposer", "node 0", "node 1"),
+		}
+		expectedBatch = make([]*proto.Message, 0)
+	)
+
+	defer store.Close()
+
+	i.state.setView(view)
+
+	for index, certificate := range certificates {
+		var proposal []byte
+		if certificate != nil {
+			proposal = []byte("proposal")
+		}
+
+		store.AddMessage(buildBasicRoundChangeMessage(
+			proposal,
+			certificate,
+			view,
+			[]byte(fmt.Sprintf("node %d", index)),
+		))
+	}
+
+	rcc := i.handleRoundChangeMessage(view, 3)
+	require.NotNil(t, rcc)
+
+	// Make sure the message with the invalid certificate is left out
+	assert.Len(t, rcc.RoundChangeMessages, 3)
+
+	for _, certificate := range certificates {
+		if certificate != nil {
+			expectedBatch = append(expectedBatch, certificate.PrepareMessages...)
+		}
+	}
+
+	// The message order in the store is not deterministic
+	require.Len(t, recorder.batches, 1)
+	assert.ElementsMatch(t, expectedBatch, recorder.batches[0])
+	// The memoized verdicts are reused, so the PCs are not checked again
+	rcc = i.handleRoundChangeMessage(view, 3)
+	require.NotNil(t, rcc)
+
+	assert.Len(t, rcc.RoundChangeMessages, 3)
+	assert.Len(t, recorder.batches, 1)
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// CachedMessages is an optional extension of the Messages store,
+// that memoizes the validation verdicts of the stored messages
+type CachedMessages interface {
+	// GetCachedValidMessages fetches the valid messages of a specific type for the view.
+	// Only the messages without a memoized verdict for the validation context
+	// are passed to isValid, which returns the verdict for each of them
+	GetCachedValidMessages(
+		view *proto.View,
+		messageType proto.MessageType,
+		context string,
+		isValid func(messages []*proto.Message) []bool,
+	) []*proto.Message
+}
+
+// getValidMessages fetches the valid messages of a specific type for the view.
+// The verdicts are memoized for the validation context if the store is CachedMessages,
+// otherwise the messages stored so far are validated in a single batch,
+// and the ones received in the meantime one by one
+func (i *IBFT) getValidMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+	context string,
+	isValid func(messages []*proto.Message) []bool,
+) []*proto.Message {
+	if cached, ok := i.messages.(CachedMessages); ok {
+		return cached.GetCachedValidMessages(view, messageType, context, isValid)
+	}
+
+	// Collect the messages stored so far, without pruning any
+	pending := make([]*proto.Message, 0)
+
+	i.messages.GetValidMessages(view, messageType, func(message *proto.Message) bool {
+		pending = append(pending, message)
+
+		return true
+	})
+
+	verdicts := make(map[*proto.Message]bool, len(pending))
+
+	for index, valid := range isValid(pending) {
+		if index < len(pending) {
+			verdicts[pending[index]] = valid
+		}
+	}
+
+	return i.messages.GetValidMessages(view, messageType, func(message *proto.Message) bool {
+		valid, checked := verdicts[message]
+		if !checked {
+			valid = isValidMessage(isValid, message)
+		}
+
+		return valid
+	})
+}
+
+// isValidMessage validates a single message with the batch validation function
+func isValidMessage(isValid func(messages []*proto.Message) []bool, message *proto.Message) bool {
+	verdicts := isValid([]*proto.Message{message})
+
+	return len(verdicts) == 1 && verdicts[0]
+}
+
+// validateEach adapts the validation of a single message
+// into a batch validation function
+func validateEach(isValid func(message *proto.Message) bool) func(messages []*proto.Message) []bool {
+	return func(messages []*proto.Message) []bool {
+		verdicts := make([]bool, len(messages))
+
+		for index, message := range messages {
+			verdicts[index] = isValid(message)
+		}
+
+		return verdicts
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIBFT_GetValidMessages makes sure the messages are validated in batches,
+// and the verdicts are memoized if the store supports it
+func TestIBFT_GetValidMessages(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view    = &proto.View{Height: 1, Round: 1}
+		invalid = []byte("invalid node")
+		stored  = []*proto.Message{
+			buildBasicPrepareMessage([]byte("hash"), []byte("node 0"), view),
+			buildBasicPrepareMessage([]byte("hash"), invalid, view),
+		}
+		received = buildBasicPrepareMessage([]byte("hash"), []byte("node 1"), view)
+	)
+
+	newValidator := func(batches *[][]*proto.Message) func([]*proto.Message) []bool {
+		return func(batch []*proto.Message) []bool {
+			*batches = append(*batches, batch)
+
+			verdicts := make([]bool, len(batch))
+			for index, message := range batch {
+				verdicts[index] = string(message.From) != string(invalid)
+			}
+
+			return verdicts
+		}
+	}
+
+	t.Run("store without the verdict cache", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			batches [][]*proto.Message
+			calls   int
+		)
+
+		// The store receives a new message in between the calls
+		store := mockMessages{
+			getValidMessagesFn: func(
+				_ *proto.View,
+				_ proto.MessageType,
+				isValid func(*proto.Message) bool,
+			) []*proto.Message {
+				calls++
+
+				available := stored
+				if calls > 1 {
+					available = append([]*proto.Message{received}, stored...)
+				}
+
+				valid := make([]*proto.Message, 0)
+
+				for _, message := range available {
+					if isValid(message) {
+						valid = append(valid, message)
+					}
+				}
+
+				return valid
+			},
+		}
+
+		i := newTestIBFT(t, mockLogger{}, mockBackend{}, mockTransport{}, WithMessages(store))
+
+		valid := i.getValidMessages(view, proto.MessageType_PREPARE, "", newValidator(&batches))
+		assert.Equal(t, []*proto.Message{received, stored[0]}, valid)
+
+		// The stored messages are validated in a single batch,
+		// and the one received in the meantime on its own
+		assert.Equal(t, [][]*proto.Message{stored, {received}}, batches)
+	})
+
+	t.Run("store with the verdict cache", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			batches [][]*proto.Message
+			store   = messages.NewMessages()
+		)
+
+		defer store.Close()
+
+		for _, message := range stored {
+			store.AddMessage(message)
+		}
+
+		i := newTestIBFT(t, mockLogger{}, mockBackend{}, mockTransport{}, WithMessages(store))
+
+		valid := i.getValidMessages(view, proto.MessageType_PREPARE, "", newValidator(&batches))
+		assert.Equal(t, []*proto.Message{stored[0]}, valid)
+
+		require.Len(t, batches, 1)
+		assert.ElementsMatch(t, stored, batches[0])
+
+		// The memoized verdicts are reused
+		valid = i.getValidMessages(view, proto.MessageType_PREPARE, "", newValidator(&batches))
+		assert.Equal(t, []*proto.Message{stored[0]}, valid)
+
+		assert.Len(t, batches, 1)
+	})
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock defines the time source of the IBFT state machine
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// NewTimer creates a new timer that fires after the specified duration
+	NewTimer(d time.Duration) Timer
+
+	// After returns a channel that receives the
+	// current time after the specified duration
+	After(d time.Duration) <-chan time.Time
+}
+
+// Timer defines a single timer created by a Clock
+type Timer interface {
+	// C returns the channel on which the timer fires
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false
+	// if the timer has already fired, or was already stopped
+	Stop() bool
+}
+
+// realClock is the Clock backed by the system time
+type realClock struct{}
+
+// Now returns the current system time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer creates a new system timer
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// After returns a channel that receives the system time after the duration
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// realTimer is the Timer backed by a system timer
+type realTimer struct {
+	*time.Timer
+}
+
+// C returns the channel on which the timer fires
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// ManualClock is a Clock that only moves forward when Advance is called.
+// It is meant for driving round changes and timeouts step by step in tests
+type ManualClock struct {
+	// now is the current time of the clock
+	now time.Time
+
+	// timers are the active timers, in no particular order
+	timers []*manualTimer
+
+	mux sync.Mutex
+}
+
+// NewManualClock creates a new manual clock set to the specified time
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{
+		now:    now,
+		timers: make([]*manualTimer, 0),
+	}
+}
+
+// Now returns the current time of the clock
+func (c *ManualClock) Now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return c.now
+}
+
+// NewTimer creates a new timer that fires once
+// the clock is advanced by the specified duration
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	timer := &manualTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		ch:       make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		// The timer is already expired
+		timer.ch <- c.now
+
+		return timer
+	}
+
+	c.timers = append(c.timers, timer)
+
+	return timer
+}
+
+// After returns a channel that receives the current time
+// once the clock is advanced by the specified duration
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// Advance moves the clock forward by the specified duration,
+// and fires all the timers that expire in the meantime, in deadline order
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.now = c.now.Add(d)
+
+	var (
+		expired = make([]*manualTimer, 0)
+		active  = make([]*manualTimer, 0, len(c.timers))
+	)
+
+	for _, timer := range c.timers {
+		if timer.deadline.After(c.now) {
+			active = append(active, timer)
+
+			continue
+		}
+
+		expired = append(expired, timer)
+	}
+
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].deadline.Before(expired[j].deadline)
+	})
+
+	for _, timer := range expired {
+		timer.ch <- c.now
+	}
+
+	c.timers = active
+}
+
+// Timers returns the number of active timers
+func (c *ManualClock) Timers() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return len(c.timers)
+}
+
+// stopTimer removes the timer from the active timers
+func (c *ManualClock) stopTimer(timer *manualTimer) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for index, active := range c.timers {
+		if active == timer {
+			c.timers = append(c.timers[:index], c.timers[index+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// manualTimer is a single timer of the ManualClock
+type manualTimer struct {
+	clock    *ManualClock
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// C returns the channel on which the timer fires
+func (t *manualTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop prevents the timer from firing
+func (t *manualTimer) Stop() bool {
+	return t.clock.stopTimer(t)
+}
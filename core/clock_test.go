@@ -0,0 +1,109 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestManualClock_Advance makes sure the manual clock
+// only fires timers once their deadline is reached
+func TestManualClock_Advance(t *testing.T) {
+	t.Parallel()
+
+	t.Run("timers fire in deadline order", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			start = time.Unix(0, 0)
+			clock = NewManualClock(start)
+
+			late  = clock.NewTimer(2 * time.Second)
+			early = clock.NewTimer(time.Second)
+		)
+
+		assert.Equal(t, 2, clock.Timers())
+
+		// Advance the clock past the first deadline
+		clock.Advance(time.Second)
+
+		assert.Equal(t, start.Add(time.Second), clock.Now())
+		assert.Equal(t, 1, clock.Timers())
+		assert.Len(t, early.C(), 1)
+		assert.Len(t, late.C(), 0)
+
+		// Advance the clock past the second deadline
+		clock.Advance(time.Second)
+
+		assert.Equal(t, 0, clock.Timers())
+		assert.Equal(t, start.Add(2*time.Second), <-late.C())
+	})
+
+	t.Run("expired timers fire immediately", func(t *testing.T) {
+		t.Parallel()
+
+		clock := NewManualClock(time.Unix(0, 0))
+
+		assert.Len(t, clock.After(0), 1)
+		assert.Len(t, clock.After(-time.Second), 1)
+		assert.Equal(t, 0, clock.Timers())
+	})
+
+	t.Run("after fires once advanced", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			clock = NewManualClock(time.Unix(0, 0))
+			ch    = clock.After(time.Minute)
+		)
+
+		clock.Advance(time.Minute - time.Nanosecond)
+		assert.Len(t, ch, 0)
+
+		clock.Advance(time.Nanosecond)
+		assert.Len(t, ch, 1)
+	})
+}
+
+// TestManualClock_Stop makes sure stopped
+// timers never fire
+func TestManualClock_Stop(t *testing.T) {
+	t.Parallel()
+
+	var (
+		clock = NewManualClock(time.Unix(0, 0))
+		timer = clock.NewTimer(time.Second)
+	)
+
+	assert.True(t, timer.Stop())
+	assert.False(t, timer.Stop())
+	assert.Equal(t, 0, clock.Timers())
+
+	clock.Advance(time.Hour)
+
+	assert.Len(t, timer.C(), 0)
+
+	// Make sure fired timers can't be stopped
+	fired := clock.NewTimer(time.Second)
+	clock.Advance(time.Second)
+
+	assert.False(t, fired.Stop())
+}
+
+// TestRealClock makes sure the real clock
+// is backed by the system time
+func TestRealClock(t *testing.T) {
+	t.Parallel()
+
+	clock := realClock{}
+
+	before := time.Now()
+	assert.False(t, clock.Now().Before(before))
+
+	timer := clock.NewTimer(time.Millisecond)
+	<-timer.C()
+	assert.False(t, timer.Stop())
+
+	<-clock.After(time.Millisecond)
+}
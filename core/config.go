@@ -0,0 +1,341 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// ErrInvalidConfig is returned when the IBFT configuration is invalid
+var ErrInvalidConfig = errors.New("invalid IBFT configuration")
+
+// Config is the configuration of a single IBFT instance
+type Config struct {
+	// Messages is the message storage layer.
+	// If not set, messages.NewMessages() is used
+	Messages Messages
+
+	// StateStore is the optional consensus state persistence layer.
+	// If set, the latest persisted state is restored on construction
+	StateStore StateStore
+
+	// SigningErrorHandler is the optional handler that is notified of
+	// outgoing messages refused by the signing guard
+	SigningErrorHandler func(message *proto.Message, err error)
+
+	// Observers are notified of consensus lifecycle events, in order
+	Observers []Observer
+
+	// Syncer is the optional hook that is notified when
+	// f+1 validators are seen at a higher height
+	Syncer Syncer
+
+	// VotingPowerProvider is the optional provider of validator voting power.
+	// If set, quorums are based on the accumulated voting power of the
+	// message senders. If not set, each validator has a voting power of 1,
+	// and quorums are determined by Backend.Quorum
+	VotingPowerProvider VotingPowerProvider
+
+	// Metrics is the sink for consensus metrics.
+	// If not set, metrics are discarded
+	Metrics Metrics
+
+	// Clock is the time source used for round timers and durations.
+	// If not set, the system time is used
+	Clock Clock
+
+	// RoundTimeoutPolicy is the policy that determines the timeout of each round.
+	// If not set, an ExponentialTimeout with BaseRoundTimeout
+	// and MaxRoundTimeout is used
+	RoundTimeoutPolicy RoundTimeoutPolicy
+
+	// BaseRoundTimeout is the timeout of round 0
+	// for the default round timeout policy
+	BaseRoundTimeout time.Duration
+
+	// MaxRoundTimeout is the timeout cap for the default round timeout policy.
+	// Zero means the timeout is only capped at the largest representable duration
+	MaxRoundTimeout time.Duration
+
+	// AdditionalRoundTimeout is the amount each round timeout is extended by
+	AdditionalRoundTimeout time.Duration
+
+	// MaxRounds is the number of rounds a sequence can go through before
+	// it is aborted with ErrMaxRoundsExceeded. Zero disables the limit
+	MaxRounds uint64
+
+	// MinBlockInterval is the minimum amount of time between the starts
+	// of consecutive sequences run by Run. Zero disables the wait
+	MinBlockInterval time.Duration
+
+	// MaxMessageSize is the size limit of incoming messages,
+	// in bytes. Zero disables the limit
+	MaxMessageSize int
+
+	// MaxHeightsAhead is the number of heights above the current one
+	// incoming messages are accepted for. Zero disables the limit
+	MaxHeightsAhead uint64
+
+	// MaxRoundsAhead is the number of rounds above the current one
+	// incoming messages are accepted for. Messages for future heights
+	// are counted from round 0. Zero disables the limit
+	MaxRoundsAhead uint64
+
+	// MaxRoundChangeRoundsAhead is the number of rounds above the current one
+	// ROUND_CHANGE messages for the current height are accepted for, if it's
+	// larger than MaxRoundsAhead, so a node that fell behind can still catch up
+	// through round skips. It has no effect if MaxRoundsAhead is zero
+	MaxRoundChangeRoundsAhead uint64
+
+	// RetransmitInterval is the initial interval between retransmissions of
+	// the latest outgoing message, while the state doesn't advance.
+	// The interval doubles with each retransmission. Zero disables retransmission
+	RetransmitInterval time.Duration
+
+	// MaxRetransmitInterval is the cap of the retransmission interval.
+	// Zero means the interval is only capped at the largest representable duration
+	MaxRetransmitInterval time.Duration
+}
+
+// DefaultConfig returns the default IBFT configuration
+func DefaultConfig() Config {
+	return Config{
+		BaseRoundTimeout: round0Timeout,
+		MaxMessageSize:   DefaultMaxMessageSize,
+		MaxHeightsAhead:  DefaultMaxHeightsAhead,
+		MaxRoundsAhead:   DefaultMaxRoundsAhead,
+
+		MaxRoundChangeRoundsAhead: DefaultMaxRoundChangeRoundsAhead,
+	}
+}
+
+// Validate makes sure the configuration is valid
+func (c *Config) Validate() error {
+	if c.RoundTimeoutPolicy == nil && c.BaseRoundTimeout <= 0 {
+		return fmt.Errorf(
+			"%w: base round timeout must be positive, got %s",
+			ErrInvalidConfig,
+			c.BaseRoundTimeout,
+		)
+	}
+
+	if c.MaxRoundTimeout < 0 {
+		return fmt.Errorf(
+			"%w: max round timeout must not be negative, got %s",
+			ErrInvalidConfig,
+			c.MaxRoundTimeout,
+		)
+	}
+
+	if c.MaxRoundTimeout > 0 && c.MaxRoundTimeout < c.BaseRoundTimeout {
+		return fmt.Errorf(
+			"%w: max round timeout %s is lower than the base round timeout %s",
+			ErrInvalidConfig,
+			c.MaxRoundTimeout,
+			c.BaseRoundTimeout,
+		)
+	}
+
+	if c.AdditionalRoundTimeout < 0 {
+		return fmt.Errorf(
+			"%w: additional round timeout must not be negative, got %s",
+			ErrInvalidConfig,
+			c.AdditionalRoundTimeout,
+		)
+	}
+
+	if c.MinBlockInterval < 0 {
+		return fmt.Errorf(
+			"%w: min block interval must not be negative, got %s",
+			ErrInvalidConfig,
+			c.MinBlockInterval,
+		)
+	}
+
+	if c.MaxMessageSize < 0 {
+		return fmt.Errorf(
+			"%w: max message size must not be negative, got %d",
+			ErrInvalidConfig,
+			c.MaxMessageSize,
+		)
+	}
+
+	if c.RetransmitInterval < 0 {
+		return fmt.Errorf(
+			"%w: retransmit interval must not be negative, got %s",
+			ErrInvalidConfig,
+			c.RetransmitInterval,
+		)
+	}
+
+	if c.MaxRetransmitInterval < 0 {
+		return fmt.Errorf(
+			"%w: max retransmit interval must not be negative, got %s",
+			ErrInvalidConfig,
+			c.MaxRetransmitInterval,
+		)
+	}
+
+	if c.MaxRetransmitInterval > 0 && c.MaxRetransmitInterval < c.RetransmitInterval {
+		return fmt.Errorf(
+			"%w: max retransmit interval %s is lower than the retransmit interval %s",
+			ErrInvalidConfig,
+			c.MaxRetransmitInterval,
+			c.RetransmitInterval,
+		)
+	}
+
+	return nil
+}
+
+// Option is a functional option that modifies the IBFT configuration
+type Option func(*Config)
+
+// WithConfig replaces the entire configuration
+func WithConfig(config Config) Option {
+	return func(c *Config) {
+		*c = config
+	}
+}
+
+// WithMessages sets a custom message storage layer
+func WithMessages(messages Messages) Option {
+	return func(c *Config) {
+		c.Messages = messages
+	}
+}
+
+// WithStateStore sets the consensus state persistence layer
+func WithStateStore(store StateStore) Option {
+	return func(c *Config) {
+		c.StateStore = store
+	}
+}
+
+// WithSigningErrorHandler sets the handler that is notified of
+// outgoing messages refused by the signing guard
+func WithSigningErrorHandler(handler func(message *proto.Message, err error)) Option {
+	return func(c *Config) {
+		c.SigningErrorHandler = handler
+	}
+}
+
+// WithObserver registers an observer of consensus lifecycle events.
+// It can be used multiple times to register multiple observers
+func WithObserver(observer Observer) Option {
+	return func(c *Config) {
+		c.Observers = append(c.Observers, observer)
+	}
+}
+
+// WithSyncer sets the hook that is notified when
+// f+1 validators are seen at a higher height
+func WithSyncer(syncer Syncer) Option {
+	return func(c *Config) {
+		c.Syncer = syncer
+	}
+}
+
+// WithVotingPowerProvider sets the provider of validator
+// voting power, for stake-weighted quorums
+func WithVotingPowerProvider(provider VotingPowerProvider) Option {
+	return func(c *Config) {
+		c.VotingPowerProvider = provider
+	}
+}
+
+// WithMetrics sets the sink for consensus metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Config) {
+		c.Metrics = metrics
+	}
+}
+
+// WithClock sets the time source used for round timers and durations
+func WithClock(clock Clock) Option {
+	return func(c *Config) {
+		c.Clock = clock
+	}
+}
+
+// WithBaseRoundTimeout sets the timeout of round 0 for the default round timeout policy
+func WithBaseRoundTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.BaseRoundTimeout = timeout
+	}
+}
+
+// WithMaxRoundTimeout sets the timeout cap for the default round timeout policy
+func WithMaxRoundTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.MaxRoundTimeout = timeout
+	}
+}
+
+// WithRoundTimeoutPolicy sets the policy that determines the timeout of each round
+func WithRoundTimeoutPolicy(policy RoundTimeoutPolicy) Option {
+	return func(c *Config) {
+		c.RoundTimeoutPolicy = policy
+	}
+}
+
+// WithAdditionalRoundTimeout sets the amount each round timeout is extended by
+func WithAdditionalRoundTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.AdditionalRoundTimeout = timeout
+	}
+}
+
+// WithMaxRounds sets the number of rounds a sequence can go through
+// before it is aborted. Zero disables the limit
+func WithMaxRounds(rounds uint64) Option {
+	return func(c *Config) {
+		c.MaxRounds = rounds
+	}
+}
+
+// WithMinBlockInterval sets the minimum amount of time
+// between the starts of consecutive sequences run by Run
+func WithMinBlockInterval(interval time.Duration) Option {
+	return func(c *Config) {
+		c.MinBlockInterval = interval
+	}
+}
+
+// WithMaxMessageSize sets the size limit of incoming
+// messages, in bytes. Zero disables the limit
+func WithMaxMessageSize(size int) Option {
+	return func(c *Config) {
+		c.MaxMessageSize = size
+	}
+}
+
+// WithMessageWindow sets the number of heights and rounds above the current
+// ones that incoming messages are accepted for. Zero disables the limit
+func WithMessageWindow(maxHeightsAhead, maxRoundsAhead uint64) Option {
+	return func(c *Config) {
+		c.MaxHeightsAhead = maxHeightsAhead
+		c.MaxRoundsAhead = maxRoundsAhead
+	}
+}
+
+// WithRoundChangeWindow sets the number of rounds above the current one
+// ROUND_CHANGE messages for the current height are accepted for,
+// if it's larger than the round window of WithMessageWindow
+func WithRoundChangeWindow(maxRoundsAhead uint64) Option {
+	return func(c *Config) {
+		c.MaxRoundChangeRoundsAhead = maxRoundsAhead
+	}
+}
+
+// WithRetransmission enables retransmission of the latest outgoing message
+// while the state doesn't advance. The interval between retransmissions
+// starts at the specified interval, and doubles up to the specified maximum
+func WithRetransmission(interval, maxInterval time.Duration) Option {
+	return func(c *Config) {
+		c.RetransmitInterval = interval
+		c.MaxRetransmitInterval = maxInterval
+	}
+}
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConfig_Validate makes sure invalid
+// configurations are rejected
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name        string
+		opts        []Option
+		expectedErr error
+	}{
+		{
+			"default configuration",
+			nil,
+			nil,
+		},
+		{
+			"zero base round timeout",
+			[]Option{WithBaseRoundTimeout(0)},
+			ErrInvalidConfig,
+		},
+		{
+			"negative base round timeout",
+			[]Option{WithBaseRoundTimeout(-time.Second)},
+			ErrInvalidConfig,
+		},
+		{
+			"negative additional round timeout",
+			[]Option{WithAdditionalRoundTimeout(-time.Second)},
+			ErrInvalidConfig,
+		},
+		{
+			"negative max round timeout",
+			[]Option{WithMaxRoundTimeout(-time.Second)},
+			ErrInvalidConfig,
+		},
+		{
+			"negative min block interval",
+			[]Option{WithMinBlockInterval(-time.Second)},
+			ErrInvalidConfig,
+		},
+		{
+			"negative max message size",
+			[]Option{WithMaxMessageSize(-1)},
+			ErrInvalidConfig,
+		},
+		{
+			"negative retransmit interval",
+			[]Option{WithRetransmission(-time.Second, 0)},
+			ErrInvalidConfig,
+		},
+		{
+			"negative max retransmit interval",
+			[]Option{WithRetransmission(time.Second, -time.Second)},
+			ErrInvalidConfig,
+		},
+		{
+			"max retransmit interval lower than the retransmit interval",
+			[]Option{WithRetransmission(time.Minute, time.Second)},
+			ErrInvalidConfig,
+		},
+		{
+			"retransmission without a cap",
+			[]Option{WithRetransmission(time.Second, 0)},
+			nil,
+		},
+		{
+			"max round timeout lower than the base round timeout",
+			[]Option{
+				WithBaseRoundTimeout(time.Minute),
+				WithMaxRoundTimeout(time.Second),
+			},
+			ErrInvalidConfig,
+		},
+		{
+			"custom round timeout policy without a base round timeout",
+			[]Option{
+				WithBaseRoundTimeout(0),
+				WithRoundTimeoutPolicy(ConstantTimeout(time.Second)),
+			},
+			nil,
+		},
+		{
+			"empty configuration",
+			[]Option{WithConfig(Config{})},
+			ErrInvalidConfig,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := DefaultConfig()
+			for _, opt := range testCase.opts {
+				opt(&config)
+			}
+
+			assert.ErrorIs(t, config.Validate(), testCase.expectedErr)
+		})
+	}
+}
+
+// TestNewIBFT_Options makes sure the passed in
+// options are applied to the IBFT instance
+func TestNewIBFT_Options(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults are applied", func(t *testing.T) {
+		t.Parallel()
+
+		i := newTestIBFT(t, mockLogger{}, mockBackend{}, mockTransport{})
+
+		assert.Equal(t, ExponentialTimeout{Base: round0Timeout}, i.timeoutPolicy)
+		assert.Equal(t, time.Duration(0), i.additionalTimeout)
+		assert.Equal(t, uint64(0), i.maxRounds)
+		assert.NotNil(t, i.messages)
+		assert.Nil(t, i.store)
+		assert.Equal(t, NoopObserver{}, i.observer)
+		assert.Equal(t, NoopMetrics{}, i.metrics)
+	})
+
+	t.Run("options are applied", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			messages = &mockMessages{}
+			store    = mockStateStore{}
+			handled  = false
+		)
+
+		i := newTestIBFT(
+			t,
+			mockLogger{},
+			mockBackend{},
+			mockTransport{},
+			WithBaseRoundTimeout(time.Second),
+			WithMaxRoundTimeout(time.Minute),
+			WithAdditionalRoundTimeout(2*time.Second),
+			WithMaxRounds(5),
+			WithMessages(messages),
+			WithStateStore(store),
+			WithSigningErrorHandler(func(_ *proto.Message, _ error) {
+				handled = true
+			}),
+		)
+
+		assert.Equal(t, ExponentialTimeout{Base: time.Second, Max: time.Minute}, i.timeoutPolicy)
+		assert.Equal(t, 2*time.Second, i.additionalTimeout)
+		assert.Equal(t, uint64(5), i.maxRounds)
+		assert.Equal(t, messages, i.messages)
+		assert.Equal(t, store, i.store)
+
+		i.signingErrorHandler(nil, nil)
+		assert.True(t, handled)
+	})
+
+	t.Run("missing dependencies are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewIBFT(nil, mockBackend{}, mockTransport{})
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+
+		_, err = NewIBFT(mockLogger{}, nil, mockTransport{})
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+
+		_, err = NewIBFT(mockLogger{}, mockBackend{}, nil)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	t.Run("invalid options are rejected", func(t *testing.T) {
+		t.Parallel()
+
+		i, err := NewIBFT(
+			mockLogger{},
+			mockBackend{},
+			mockTransport{},
+			WithBaseRoundTimeout(0),
+		)
+
+		assert.Nil(t, i)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	t.Run("state store errors are propagated", func(t *testing.T) {
+		t.Parallel()
+
+		loadErr := errors.New("corrupt state")
+
+		_, err := NewIBFT(
+			mockLogger{},
+			mockBackend{},
+			mockTransport{},
+			WithStateStore(mockStateStore{
+				loadStateFn: func() (*PersistedState, error) {
+					return nil, loadErr
+				},
+			}),
+		)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, loadErr)
+	})
+}
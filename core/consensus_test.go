@@ -10,6 +10,7 @@ import (
 	"github.com/madz-lab/go-ibft/messages"
 	"github.com/madz-lab/go-ibft/messages/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // generateNodeAddresses generates dummy node addresses
@@ -245,6 +246,7 @@ func TestConsensus_ValidFlow(t *testing.T) {
 
 	// Create the mock cluster
 	cluster := newMockCluster(
+		t,
 		numNodes,
 		backendCallbackMap,
 		nil,
@@ -267,6 +269,20 @@ func TestConsensus_ValidFlow(t *testing.T) {
 	for _, block := range insertedBlocks {
 		assert.True(t, bytes.Equal(block, proposal))
 	}
+
+	// Make sure the sequence results match the inserted blocks
+	for _, result := range cluster.results {
+		if !assert.NotNil(t, result) {
+			continue
+		}
+
+		assert.Equal(t, uint64(0), result.Height)
+		assert.Equal(t, uint64(0), result.Round)
+		assert.Equal(t, proposal, result.Proposal)
+		assert.Equal(t, proposalHash, result.ProposalHash)
+		assert.Len(t, result.CommittedSeals, int(numNodes))
+		assert.Empty(t, result.RoundChanges)
+	}
 }
 
 // TestConsensus_InvalidBlock tests the following scenario:
@@ -420,14 +436,16 @@ func TestConsensus_InvalidBlock(t *testing.T) {
 
 	// Create the mock cluster
 	cluster := newMockCluster(
+		t,
 		numNodes,
 		backendCallbackMap,
 		nil,
 		transportCallbackMap,
 	)
 
-	// Set the base timeout to be lower than usual
-	cluster.setBaseTimeout(2 * time.Second)
+	// Drive the round timers manually
+	clock := NewManualClock(time.Now())
+	cluster.setClock(clock)
 
 	// Set the multicast callback to relay the message
 	// to the entire cluster
@@ -438,6 +456,14 @@ func TestConsensus_InvalidBlock(t *testing.T) {
 	// Start the main run loops
 	cluster.runSequence(1)
 
+	// Wait until all nodes start their round 0 timers,
+	// and expire them at once
+	require.Eventually(t, func() bool {
+		return clock.Timers() == int(numNodes)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	clock.Advance(round0Timeout)
+
 	// Wait until the main run loops finish
 	cluster.awaitCompletion()
 
@@ -448,4 +474,17 @@ func TestConsensus_InvalidBlock(t *testing.T) {
 	for _, block := range insertedBlocks {
 		assert.True(t, bytes.Equal(block, proposals[1]))
 	}
+
+	// Make sure the sequence results note the round change
+	for _, result := range cluster.results {
+		if !assert.NotNil(t, result) {
+			continue
+		}
+
+		assert.Equal(t, uint64(1), result.Round)
+		assert.Equal(t, proposals[1], result.Proposal)
+		assert.NotEmpty(t, result.RoundChanges)
+		assert.Equal(t, uint64(1), result.RoundChanges[len(result.RoundChanges)-1].Round)
+		assert.Equal(t, round0Timeout, result.Duration)
+	}
 }
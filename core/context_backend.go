@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+
+	"github.com/madz-lab/go-ibft/messages"
+)
+
+// ContextBackend is an optional Backend extension with context-aware,
+// error-returning variants of the block building and insertion methods.
+// If implemented, it is used instead of BuildProposal and InsertBlock
+type ContextBackend interface {
+	// BuildProposalContext builds a new block proposal for the height.
+	// The context is cancelled once the round is over. If an error is
+	// returned, the node doesn't propose in the current round
+	BuildProposalContext(ctx context.Context, height uint64) ([]byte, error)
+
+	// InsertBlockContext inserts a proposal with the specified committed seals.
+	// If an error is returned, the sequence is aborted with the error
+	InsertBlockContext(
+		ctx context.Context,
+		proposal []byte,
+		committedSeals []*messages.CommittedSeal,
+	) error
+}
+
+// backendAdapter adapts a Backend that doesn't implement the
+// ContextBackend extension. Its methods can't fail or be cancelled
+type backendAdapter struct {
+	backend Backend
+}
+
+// newContextBackend returns the ContextBackend
+// implementation of the backend, or adapts it
+func newContextBackend(backend Backend) ContextBackend {
+	if contextBackend, ok := backend.(ContextBackend); ok {
+		return contextBackend
+	}
+
+	return backendAdapter{backend: backend}
+}
+
+// BuildProposalContext builds a new block proposal using BuildProposal
+func (a backendAdapter) BuildProposalContext(_ context.Context, height uint64) ([]byte, error) {
+	return a.backend.BuildProposal(height), nil
+}
+
+// InsertBlockContext inserts the proposal using InsertBlock
+func (a backendAdapter) InsertBlockContext(
+	_ context.Context,
+	proposal []byte,
+	committedSeals []*messages.CommittedSeal,
+) error {
+	a.backend.InsertBlock(proposal, committedSeals)
+
+	return nil
+}
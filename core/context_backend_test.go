@@ -0,0 +1,233 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockContextBackend is the mock backend
+// that also implements the ContextBackend extension
+type mockContextBackend struct {
+	mockBackend
+
+	buildProposalContextFn func(context.Context, uint64) ([]byte, error)
+	insertBlockContextFn   func(context.Context, []byte, []*messages.CommittedSeal) error
+}
+
+func (m mockContextBackend) BuildProposalContext(ctx context.Context, height uint64) ([]byte, error) {
+	return m.buildProposalContextFn(ctx, height)
+}
+
+func (m mockContextBackend) InsertBlockContext(
+	ctx context.Context,
+	proposal []byte,
+	committedSeals []*messages.CommittedSeal,
+) error {
+	return m.insertBlockContextFn(ctx, proposal, committedSeals)
+}
+
+// TestNewContextBackend makes sure backends that don't
+// implement the ContextBackend extension are adapted
+func TestNewContextBackend(t *testing.T) {
+	t.Parallel()
+
+	t.Run("context backend is used directly", func(t *testing.T) {
+		t.Parallel()
+
+		backend := mockContextBackend{}
+
+		assert.Equal(t, backend, newContextBackend(backend))
+	})
+
+	t.Run("backend is adapted", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			inserted []byte
+			seals    = []*messages.CommittedSeal{{Signer: []byte("node 0")}}
+		)
+
+		backend := mockBackend{
+			buildProposalFn: func(height uint64) []byte {
+				return []byte{byte(height)}
+			},
+			insertBlockFn: func(proposal []byte, committedSeals []*messages.CommittedSeal) {
+				assert.Equal(t, seals, committedSeals)
+
+				inserted = proposal
+			},
+		}
+
+		adapted := newContextBackend(backend)
+		assert.IsType(t, backendAdapter{}, adapted)
+
+		proposal, err := adapted.BuildProposalContext(context.Background(), 5)
+		require.NoError(t, err)
+		assert.Equal(t, []byte{5}, proposal)
+
+		require.NoError(t, adapted.InsertBlockContext(context.Background(), proposal, seals))
+		assert.Equal(t, proposal, inserted)
+	})
+}
+
+// TestIBFT_BuildProposalContext makes sure the node skips
+// proposing in the round if the proposal can't be built
+func TestIBFT_BuildProposalContext(t *testing.T) {
+	t.Parallel()
+
+	t.Run("build error", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			errBuild = errors.New("build error")
+			clock    = NewManualClock(time.Now())
+			errCh    = make(chan error, 1)
+
+			sentLock sync.Mutex
+			sent     []proto.MessageType
+		)
+
+		backend := mockContextBackend{
+			mockBackend: newSingleNodeBackend(&mockChain{}),
+			buildProposalContextFn: func(_ context.Context, _ uint64) ([]byte, error) {
+				return nil, errBuild
+			},
+		}
+
+		backend.buildRoundChangeMessageFn = func(
+			_ []byte,
+			_ *proto.PreparedCertificate,
+			view *proto.View,
+		) *proto.Message {
+			return buildBasicRoundChangeMessage(nil, nil, view, []byte("node 0"))
+		}
+
+		transport := mockTransport{
+			multicastFn: func(message *proto.Message) {
+				sentLock.Lock()
+				defer sentLock.Unlock()
+
+				sent = append(sent, message.Type)
+			},
+		}
+
+		i := newTestIBFT(t, mockLogger{}, backend, transport, WithClock(clock), WithMaxRounds(1))
+
+		go func() {
+			_, err := i.RunSequence(context.Background(), 1)
+
+			errCh <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		clock.Advance(round0Timeout)
+
+		assert.ErrorIs(t, <-errCh, ErrMaxRoundsExceeded)
+
+		// Make sure only the round change went out
+		sentLock.Lock()
+		defer sentLock.Unlock()
+
+		assert.Equal(t, []proto.MessageType{proto.MessageType_ROUND_CHANGE}, sent)
+	})
+
+	t.Run("slow builder is cancelled with the round", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			clock     = NewManualClock(time.Now())
+			errCh     = make(chan error, 1)
+			started   = make(chan struct{})
+			cancelled = make(chan struct{})
+		)
+
+		backend := mockContextBackend{
+			mockBackend: newSingleNodeBackend(&mockChain{}),
+			buildProposalContextFn: func(ctx context.Context, _ uint64) ([]byte, error) {
+				close(started)
+				<-ctx.Done()
+				close(cancelled)
+
+				return nil, ctx.Err()
+			},
+		}
+
+		backend.buildRoundChangeMessageFn = func(
+			_ []byte,
+			_ *proto.PreparedCertificate,
+			view *proto.View,
+		) *proto.Message {
+			return buildBasicRoundChangeMessage(nil, nil, view, []byte("node 0"))
+		}
+
+		i := newTestIBFT(t, mockLogger{}, backend, mockTransport{}, WithClock(clock), WithMaxRounds(1))
+
+		go func() {
+			_, err := i.RunSequence(context.Background(), 1)
+
+			errCh <- err
+		}()
+
+		<-started
+
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		clock.Advance(round0Timeout)
+
+		assert.ErrorIs(t, <-errCh, ErrMaxRoundsExceeded)
+
+		select {
+		case <-cancelled:
+		default:
+			t.Fatal("builder not cancelled")
+		}
+	})
+}
+
+// TestIBFT_InsertBlockContext makes sure the sequence
+// is aborted if the proposal can't be inserted
+func TestIBFT_InsertBlockContext(t *testing.T) {
+	t.Parallel()
+
+	var (
+		errInsert = errors.New("insert error")
+		observer  = &recordingObserver{}
+	)
+
+	backend := mockContextBackend{
+		mockBackend: newSingleNodeBackend(&mockChain{}),
+		buildProposalContextFn: func(_ context.Context, height uint64) ([]byte, error) {
+			return []byte{byte(height)}, nil
+		},
+		insertBlockContextFn: func(_ context.Context, proposal []byte, seals []*messages.CommittedSeal) error {
+			assert.Equal(t, []byte{1}, proposal)
+			assert.Len(t, seals, 1)
+
+			return errInsert
+		},
+	}
+
+	node := newSingleNodeIBFT(t, backend, WithObserver(observer))
+
+	result, err := node.RunSequence(context.Background(), 1)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, errInsert)
+	assert.NotContains(t, observer.getEvents(), "finalized 1/0")
+
+	// Make sure the driver stops with the error as well
+	assert.ErrorIs(t, node.Run(context.Background(), 1, nil), errInsert)
+}
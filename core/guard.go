@@ -0,0 +1,155 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+var (
+	// ErrConflictingMessage is returned when an outgoing message conflicts
+	// with a message of the same type that was already signed for the view
+	ErrConflictingMessage = errors.New("conflicting message for an already signed view")
+
+	// ErrStaleMessage is returned when an outgoing message is for a view
+	// lower than the last signed view for the message type
+	ErrStaleMessage = errors.New("message for a view lower than the last signed view")
+)
+
+// signedRecord is the last signed view and hash
+// for a single message type
+type signedRecord struct {
+	view *proto.View
+	hash []byte
+}
+
+// signingGuard keeps track of the last signed view and hash
+// for each message type, and refuses outgoing messages that
+// conflict with them. Since every outgoing message is persisted
+// by the StateStore, the guard is restored from the recorded messages
+type signingGuard struct {
+	// records maps the message type -> last signed record
+	records map[proto.MessageType]signedRecord
+
+	// minHeight is the lowest height messages can be signed for
+	minHeight uint64
+
+	sync.Mutex
+}
+
+// newSigningGuard creates a new signing guard instance
+func newSigningGuard() *signingGuard {
+	return &signingGuard{
+		records: make(map[proto.MessageType]signedRecord),
+	}
+}
+
+// check makes sure the outgoing message does not conflict
+// with the already signed messages, and records it
+func (g *signingGuard) check(message *proto.Message) error {
+	if message == nil || message.View == nil {
+		// Nothing was signed for a view
+		return nil
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	var (
+		view = message.View
+		hash = extractSignedHash(message)
+	)
+
+	if view.Height < g.minHeight {
+		return fmt.Errorf(
+			"%w, %s for height %d, minimum height %d",
+			ErrStaleMessage,
+			message.Type.String(),
+			view.Height,
+			g.minHeight,
+		)
+	}
+
+	if record, exists := g.records[message.Type]; exists {
+		switch {
+		case isLowerView(view, record.view):
+			return fmt.Errorf(
+				"%w, %s for view (%d, %d), last signed (%d, %d)",
+				ErrStaleMessage,
+				message.Type.String(),
+				view.Height,
+				view.Round,
+				record.view.Height,
+				record.view.Round,
+			)
+		case isSameView(view, record.view) && !bytes.Equal(hash, record.hash):
+			return fmt.Errorf(
+				"%w, %s for view (%d, %d)",
+				ErrConflictingMessage,
+				message.Type.String(),
+				view.Height,
+				view.Round,
+			)
+		}
+	}
+
+	g.records[message.Type] = signedRecord{
+		view: &proto.View{
+			Height: view.Height,
+			Round:  view.Round,
+		},
+		hash: hash,
+	}
+
+	return nil
+}
+
+// restore restores the guard from the messages signed for the specified height
+func (g *signingGuard) restore(height uint64, signed []*proto.Message) {
+	g.Lock()
+	g.minHeight = height
+	g.Unlock()
+
+	for _, message := range signed {
+		//nolint:errcheck // Recorded messages are restored as-is
+		_ = g.check(message)
+	}
+}
+
+// extractSignedHash extracts the proposal hash the message signs off on
+func extractSignedHash(message *proto.Message) []byte {
+	switch message.Type {
+	case proto.MessageType_PREPREPARE:
+		return message.GetPreprepareData().GetProposalHash()
+	case proto.MessageType_PREPARE:
+		return message.GetPrepareData().GetProposalHash()
+	case proto.MessageType_COMMIT:
+		return message.GetCommitData().GetProposalHash()
+	case proto.MessageType_ROUND_CHANGE:
+		return message.
+			GetRoundChangeData().
+			GetLatestPreparedCertificate().
+			GetProposalMessage().
+			GetPreprepareData().
+			GetProposalHash()
+	}
+
+	return nil
+}
+
+// isSameView checks if the views are equal
+func isSameView(a, b *proto.View) bool {
+	return a.Height == b.Height && a.Round == b.Round
+}
+
+// isLowerView checks if view a is lower than view b
+func isLowerView(a, b *proto.View) bool {
+	if a.Height != b.Height {
+		return a.Height < b.Height
+	}
+
+	return a.Round < b.Round
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSigningGuard_Check makes sure conflicting
+// outgoing messages are refused
+func TestSigningGuard_Check(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sender = []byte("node")
+		hashA  = []byte("hash A")
+		hashB  = []byte("hash B")
+	)
+
+	view := func(height, round uint64) *proto.View {
+		return &proto.View{
+			Height: height,
+			Round:  round,
+		}
+	}
+
+	testTable := []struct {
+		name        string
+		signed      []*proto.Message
+		message     *proto.Message
+		expectedErr error
+	}{
+		{
+			"first message for the type",
+			nil,
+			buildBasicPrepareMessage(hashA, sender, view(1, 0)),
+			nil,
+		},
+		{
+			"same message for the same view",
+			[]*proto.Message{
+				buildBasicPrepareMessage(hashA, sender, view(1, 0)),
+			},
+			buildBasicPrepareMessage(hashA, sender, view(1, 0)),
+			nil,
+		},
+		{
+			"different hash for the same view",
+			[]*proto.Message{
+				buildBasicPrepareMessage(hashA, sender, view(1, 0)),
+			},
+			buildBasicPrepareMessage(hashB, sender, view(1, 0)),
+			ErrConflictingMessage,
+		},
+		{
+			"different COMMIT hash for the same view",
+			[]*proto.Message{
+				buildBasicCommitMessage(hashA, nil, sender, view(1, 2)),
+			},
+			buildBasicCommitMessage(hashB, nil, sender, view(1, 2)),
+			ErrConflictingMessage,
+		},
+		{
+			"different proposal for the same view",
+			[]*proto.Message{
+				buildBasicPreprepareMessage(nil, hashA, nil, sender, view(1, 0)),
+			},
+			buildBasicPreprepareMessage(nil, hashB, nil, sender, view(1, 0)),
+			ErrConflictingMessage,
+		},
+		{
+			"different prepared certificate for the same round",
+			[]*proto.Message{
+				buildBasicRoundChangeMessage(nil, nil, view(1, 1), sender),
+			},
+			buildBasicRoundChangeMessage(
+				nil,
+				&proto.PreparedCertificate{
+					ProposalMessage: buildBasicPreprepareMessage(nil, hashA, nil, sender, view(1, 0)),
+				},
+				view(1, 1),
+				sender,
+			),
+			ErrConflictingMessage,
+		},
+		{
+			"different hash for a higher round",
+			[]*proto.Message{
+				buildBasicPrepareMessage(hashA, sender, view(1, 0)),
+			},
+			buildBasicPrepareMessage(hashB, sender, view(1, 1)),
+			nil,
+		},
+		{
+			"different message type for the same view",
+			[]*proto.Message{
+				buildBasicPrepareMessage(hashA, sender, view(1, 0)),
+			},
+			buildBasicCommitMessage(hashB, nil, sender, view(1, 0)),
+			nil,
+		},
+		{
+			"lower round than the last signed",
+			[]*proto.Message{
+				buildBasicPrepareMessage(hashA, sender, view(1, 3)),
+			},
+			buildBasicPrepareMessage(hashA, sender, view(1, 2)),
+			ErrStaleMessage,
+		},
+		{
+			"lower height than the last signed",
+			[]*proto.Message{
+				buildBasicPrepareMessage(hashA, sender, view(2, 0)),
+			},
+			buildBasicPrepareMessage(hashA, sender, view(1, 5)),
+			ErrStaleMessage,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			guard := newSigningGuard()
+
+			for _, message := range testCase.signed {
+				assert.NoError(t, guard.check(message))
+			}
+
+			assert.ErrorIs(t, guard.check(testCase.message), testCase.expectedErr)
+		})
+	}
+}
+
+// TestSigningGuard_Restore makes sure the guard
+// is restored from the recorded messages
+func TestSigningGuard_Restore(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sender = []byte("node")
+		view   = &proto.View{Height: 5, Round: 1}
+	)
+
+	guard := newSigningGuard()
+	guard.restore(
+		view.Height,
+		[]*proto.Message{
+			buildBasicCommitMessage([]byte("hash A"), nil, sender, view),
+		},
+	)
+
+	// Make sure the recorded message is guarded
+	assert.ErrorIs(
+		t,
+		guard.check(buildBasicCommitMessage([]byte("hash B"), nil, sender, view)),
+		ErrConflictingMessage,
+	)
+
+	// Make sure messages for lower heights are refused
+	assert.ErrorIs(
+		t,
+		guard.check(buildBasicPrepareMessage([]byte("hash A"), sender, &proto.View{Height: 4})),
+		ErrStaleMessage,
+	)
+}
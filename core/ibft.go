@@ -4,7 +4,8 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"math"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -30,13 +31,32 @@ type Messages interface {
 		isValid func(*proto.Message) bool,
 	) []*proto.Message
 	GetMostRoundChangeMessages(minRound, height uint64) []*proto.Message
+	GetRoundChangeMessages(minRound, height uint64) []*proto.Message
+	NumMessages(view *proto.View, messageType proto.MessageType) int
 
 	// Messages subscription handlers //
 	Subscribe(details messages.SubscriptionDetails) *messages.Subscription
 	Unsubscribe(id messages.SubscriptionID)
 }
 
+// RoundAwareMessages is an optional extension of the Messages store,
+// that is notified of the current round, so it can evict the messages
+// for rounds far ahead of it at the current height
+type RoundAwareMessages interface {
+	// SetRound notes the current round, at the height
+	// the messages were last pruned by
+	SetRound(round uint64)
+}
+
 var (
+	// ErrSequenceCancelled is returned when the sequence is
+	// stopped before the height is finalized
+	ErrSequenceCancelled = errors.New("sequence cancelled")
+
+	// ErrMaxRoundsExceeded is returned when the sequence
+	// reaches the configured round limit without finalizing
+	ErrMaxRoundsExceeded = errors.New("maximum number of rounds exceeded")
+
 	errTimeoutExpired = errors.New("round timeout expired")
 
 	round0Timeout = 10 * time.Second
@@ -57,6 +77,10 @@ type IBFT struct {
 	// Backend implementation
 	backend Backend
 
+	// contextBackend is the context-aware variant of the backend,
+	// either implemented by the backend itself, or adapted
+	contextBackend ContextBackend
+
 	// transport is the reference to the
 	// Transport implementation
 	transport Transport
@@ -65,6 +89,10 @@ type IBFT struct {
 	// consensus finalization upon a certain sequence
 	roundDone chan struct{}
 
+	// sequenceFailed is the channel used for signalizing
+	// that the finalized proposal could not be inserted
+	sequenceFailed chan error
+
 	// roundExpired is the channel used for signalizing
 	// round changing events
 	roundExpired chan struct{}
@@ -79,32 +107,148 @@ type IBFT struct {
 	// one is present
 	roundCertificate chan uint64
 
+	// roundSkip is the channel used for signalizing when
+	// f+1 validators sent ROUND_CHANGE messages for higher rounds
+	roundSkip chan uint64
+
 	//	User configured additional timeout for each round of consensus
 	additionalTimeout time.Duration
 
-	// baseRoundTimeout is the base round timeout for each round of consensus
-	baseRoundTimeout time.Duration
+	// timeoutPolicy determines the timeout of each round of consensus
+	timeoutPolicy RoundTimeoutPolicy
+
+	// clock is the time source for round timers and durations
+	clock Clock
+
+	// observer is notified of consensus lifecycle events
+	observer Observer
+
+	// metrics is the sink for consensus metrics
+	metrics Metrics
+
+	// syncer is notified when f+1 validators are seen at a higher height
+	syncer Syncer
+
+	// futureSenders tracks the senders of messages for future heights
+	futureSenders *futureSenders
+
+	// votingPowerProvider is the optional provider of
+	// validator voting power, for stake-weighted quorums
+	votingPowerProvider VotingPowerProvider
+
+	// syncTrigger carries the heights the syncer should catch up to
+	syncTrigger chan uint64
+
+	// retransmit keeps track of the latest outgoing message
+	retransmit *retransmitter
+
+	// retransmitInterval is the initial interval between
+	// retransmissions of the latest outgoing message. Zero disables retransmission
+	retransmitInterval time.Duration
+
+	// maxRetransmitInterval is the retransmission backoff cap
+	maxRetransmitInterval time.Duration
+
+	// maxMessageSize is the size limit of incoming messages,
+	// in bytes. Zero means there is no limit
+	maxMessageSize int
+
+	// maxHeightsAhead is the number of heights above the current
+	// one messages are accepted for. Zero means there is no limit
+	maxHeightsAhead uint64
+
+	// maxRoundsAhead is the number of rounds above the current
+	// one messages are accepted for. Zero means there is no limit
+	maxRoundsAhead uint64
+
+	// maxRoundChangeRoundsAhead is the number of rounds above the current
+	// one ROUND_CHANGE messages for the current height are accepted for
+	maxRoundChangeRoundsAhead uint64
+
+	// minBlockInterval is the minimum amount of time
+	// between the starts of consecutive sequences
+	minBlockInterval time.Duration
+
+	// maxRounds is the number of rounds a sequence can go through
+	// before it is aborted. Zero means there is no limit
+	maxRounds uint64
+
+	// store is the optional consensus state persistence layer
+	store StateStore
+
+	// restored is the persisted state loaded from the store,
+	// waiting to be picked up by the matching sequence
+	restored *PersistedState
+
+	// guard prevents conflicting outgoing messages
+	guard *signingGuard
+
+	// signingErrorHandler is notified of outgoing
+	// messages refused by the signing guard
+	signingErrorHandler func(message *proto.Message, err error)
 
 	// wg is a simple barrier used for synchronizing
 	// state modification routines
 	wg sync.WaitGroup
 }
 
-// NewIBFT creates a new instance of the IBFT consensus protocol
+// NewIBFT creates a new instance of the IBFT consensus protocol.
+// The default configuration can be modified using the passed in options
 func NewIBFT(
 	log Logger,
 	backend Backend,
 	transport Transport,
-) *IBFT {
-	return &IBFT{
+	opts ...Option,
+) (*IBFT, error) {
+	switch {
+	case log == nil:
+		return nil, fmt.Errorf("%w: logger is not set", ErrInvalidConfig)
+	case backend == nil:
+		return nil, fmt.Errorf("%w: backend is not set", ErrInvalidConfig)
+	case transport == nil:
+		return nil, fmt.Errorf("%w: transport is not set", ErrInvalidConfig)
+	}
+
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	if config.Messages == nil {
+		config.Messages = messages.NewMessages()
+	}
+
+	if config.Metrics == nil {
+		config.Metrics = NoopMetrics{}
+	}
+
+	if config.Clock == nil {
+		config.Clock = realClock{}
+	}
+
+	if config.RoundTimeoutPolicy == nil {
+		config.RoundTimeoutPolicy = ExponentialTimeout{
+			Base: config.BaseRoundTimeout,
+			Max:  config.MaxRoundTimeout,
+		}
+	}
+
+	i := &IBFT{
 		log:              log,
 		backend:          backend,
 		transport:        transport,
-		messages:         messages.NewMessages(),
+		messages:         config.Messages,
+		contextBackend:   newContextBackend(backend),
 		roundDone:        make(chan struct{}),
+		sequenceFailed:   make(chan error),
 		roundExpired:     make(chan struct{}),
 		newProposal:      make(chan newProposalEvent),
 		roundCertificate: make(chan uint64),
+		roundSkip:        make(chan uint64),
 		state: &state{
 			view: &proto.View{
 				Height: 0,
@@ -112,31 +256,53 @@ func NewIBFT(
 			},
 			seals:        make([]*messages.CommittedSeal, 0),
 			roundStarted: false,
-			name:         newRound,
+			name:         StateNewRound,
 		},
-		baseRoundTimeout: round0Timeout,
+		timeoutPolicy:             config.RoundTimeoutPolicy,
+		clock:                     config.Clock,
+		observer:                  newObserver(config.Observers),
+		metrics:                   config.Metrics,
+		syncer:                    config.Syncer,
+		votingPowerProvider:       config.VotingPowerProvider,
+		futureSenders:             newFutureSenders(),
+		syncTrigger:               make(chan uint64, 1),
+		retransmit:                newRetransmitter(),
+		retransmitInterval:        config.RetransmitInterval,
+		maxRetransmitInterval:     config.MaxRetransmitInterval,
+		additionalTimeout:         config.AdditionalRoundTimeout,
+		maxRounds:                 config.MaxRounds,
+		minBlockInterval:          config.MinBlockInterval,
+		maxMessageSize:            config.MaxMessageSize,
+		maxHeightsAhead:           config.MaxHeightsAhead,
+		maxRoundsAhead:            config.MaxRoundsAhead,
+		maxRoundChangeRoundsAhead: config.MaxRoundChangeRoundsAhead,
+		store:                     config.StateStore,
+		guard:                     newSigningGuard(),
+		signingErrorHandler:       config.SigningErrorHandler,
 	}
+
+	if err := i.restoreState(); err != nil {
+		return nil, err
+	}
+
+	return i, nil
 }
 
-// startRoundTimer starts the exponential round timer, based on the
-// passed in round number
+// startRoundTimer starts the round timer, based on the
+// passed in round number and the round timeout policy
 func (i *IBFT) startRoundTimer(ctx context.Context, round uint64) {
 	defer i.wg.Done()
 
-	var (
-		duration     = int(i.baseRoundTimeout)
-		roundFactor  = int(math.Pow(float64(2), float64(round)))
-		roundTimeout = time.Duration(duration * roundFactor)
-	)
+	roundTimeout := addTimeouts(i.timeoutPolicy.Timeout(round), i.additionalTimeout)
 
 	//	Create a new timer instance
-	timer := time.NewTimer(roundTimeout + i.additionalTimeout)
+	timer := i.clock.NewTimer(roundTimeout)
 
 	select {
 	case <-ctx.Done():
 		// Stop signal received, stop the timer
 		timer.Stop()
-	case <-timer.C:
+	case <-timer.C():
 		// Timer expired, alert the round change channel to move
 		// to the next round
 		i.signalRoundExpired(ctx)
@@ -162,6 +328,15 @@ func (i *IBFT) signalRoundDone(ctx context.Context) {
 	}
 }
 
+// signalSequenceFailed notifies the sequence routine (RunSequence) that
+// the sequence can't be completed because of the specified error
+func (i *IBFT) signalSequenceFailed(ctx context.Context, err error) {
+	select {
+	case i.sequenceFailed <- err:
+	case <-ctx.Done():
+	}
+}
+
 // signalNewRCC notifies the sequence routine (RunSequence) that
 // a valid Round Change Certificate for a higher round appeared
 func (i *IBFT) signalNewRCC(ctx context.Context, round uint64) {
@@ -171,6 +346,15 @@ func (i *IBFT) signalNewRCC(ctx context.Context, round uint64) {
 	}
 }
 
+// signalRoundSkip notifies the sequence routine (RunSequence) that
+// f+1 validators sent ROUND_CHANGE messages for a higher round
+func (i *IBFT) signalRoundSkip(ctx context.Context, round uint64) {
+	select {
+	case i.roundSkip <- round:
+	case <-ctx.Done():
+	}
+}
+
 type newProposalEvent struct {
 	proposalMessage *proto.Message
 	round           uint64
@@ -242,7 +426,7 @@ func (i *IBFT) watchForRoundChangeCertificates(ctx context.Context) {
 		view   = i.state.getView()
 		height = view.Height
 		round  = view.Round
-		quorum = i.backend.Quorum(height)
+		quorum = i.quorum(height)
 
 		sub = i.messages.Subscribe(messages.SubscriptionDetails{
 			MessageType: proto.MessageType_ROUND_CHANGE,
@@ -269,33 +453,166 @@ func (i *IBFT) watchForRoundChangeCertificates(ctx context.Context) {
 				},
 				quorum,
 			)
-			if rcc == nil {
-				continue
+			if rcc != nil {
+				//	we received a valid RCC for a higher round
+				i.signalNewRCC(ctx, round)
+
+				return
 			}
 
-			//	we received a valid RCC for a higher round
-			i.signalNewRCC(ctx, round)
+			// Check if f+1 validators moved on to a higher round
+			if skipRound := i.getRoundSkip(height, view.Round+1); skipRound > view.Round {
+				i.signalRoundSkip(ctx, skipRound)
 
-			return
+				return
+			}
 		}
 	}
 }
 
-// RunSequence runs the IBFT sequence for the specified height
-func (i *IBFT) RunSequence(ctx context.Context, h uint64) {
+// getRoundSkip returns the round to skip to, once validators with at least f+1
+// voting power sent ROUND_CHANGE messages for rounds from the specified minimum round.
+// As in the IBFT spec, it's the smallest round within that f+1 set, where the set is
+// made of the validators at the highest rounds, each at its highest round.
+// It returns 0 if there is no such set
+func (i *IBFT) getRoundSkip(height, minRound uint64) uint64 {
+	threshold := i.weakQuorum(height)
+
+	// Find the highest round each sender is at
+	senderRounds := make(map[string]uint64)
+
+	for _, msg := range i.messages.GetRoundChangeMessages(minRound, height) {
+		if msg.GetView() == nil {
+			continue
+		}
+
+		if round := msg.View.Round; round >= senderRounds[string(msg.From)] {
+			senderRounds[string(msg.From)] = round
+		}
+	}
+
+	senders := make([]string, 0, len(senderRounds))
+	for from := range senderRounds {
+		senders = append(senders, from)
+	}
+
+	sort.Slice(senders, func(a, b int) bool {
+		return senderRounds[senders[a]] > senderRounds[senders[b]]
+	})
+
+	// Accumulate the voting power from the highest round down,
+	// until the f+1 threshold is reached
+	var power uint64
+
+	for _, from := range senders {
+		power += i.votingPower(height, []byte(from))
+
+		if power >= threshold {
+			return senderRounds[from]
+		}
+	}
+
+	return 0
+}
+
+// RunSequence runs the IBFT sequence for the specified height.
+// It returns the sequence result once the height is finalized, or an error
+// if the sequence was cancelled or could not be finalized
+func (i *IBFT) RunSequence(ctx context.Context, h uint64) (*SequenceResult, error) {
+	var (
+		start        = i.clock.Now()
+		roundChanges = make([]RoundChange, 0)
+	)
+
 	// Set the starting state data
-	i.state.clear(h)
+	if !i.restoreSequence(h) {
+		i.state.clear(h)
+	}
+
 	i.messages.PruneByHeight(h)
+	i.futureSenders.prune(h)
+
+	if err := i.persistState(); err != nil {
+		return nil, err
+	}
+
+	i.state.setStateStarted(start)
+
+	// Start the workers that run alongside the entire sequence
+	var (
+		synced    = make(chan struct{})
+		workersWg sync.WaitGroup
+
+		workersCtx, cancelWorkers = context.WithCancel(ctx)
+	)
+
+	defer func() {
+		cancelWorkers()
+		workersWg.Wait()
+	}()
+
+	// Let the syncer catch up in parallel with the sequence
+	if i.syncer != nil {
+		workersWg.Add(1)
+
+		go func() {
+			defer workersWg.Done()
+
+			i.watchForSync(workersCtx, h, synced)
+		}()
+	}
+
+	// Retransmit outgoing messages while the sequence is stuck
+	if i.retransmitInterval > 0 {
+		workersWg.Add(1)
+
+		go func() {
+			defer workersWg.Done()
+
+			i.retransmitMessages(workersCtx)
+		}()
+	}
 
 	i.log.Info("sequence started", "height", h)
 	defer i.log.Info("sequence done", "height", h)
 
+	i.observer.OnSequenceStart(h)
+
+	// roundChange notes the round change, and notifies the observer
+	roundChange := func(round uint64, reason RoundChangeReason) {
+		roundChanges = append(roundChanges, RoundChange{
+			Round:  round,
+			Reason: reason,
+		})
+
+		i.observer.OnRoundChange(&proto.View{Height: h, Round: round}, reason)
+		i.metrics.IncRoundChange(reason)
+	}
+
 	for {
 		view := i.state.getView()
 
+		if i.maxRounds > 0 && view.Round >= i.maxRounds {
+			i.log.Error("maximum number of rounds exceeded", "round", view.Round)
+
+			return nil, ErrMaxRoundsExceeded
+		}
+
 		i.log.Info("round started", "round", view.Round)
+		i.observer.OnRoundStart(copyView(view))
+		i.metrics.SetView(copyView(view))
+
+		if roundAware, ok := i.messages.(RoundAwareMessages); ok {
+			roundAware.SetRound(view.Round)
+		}
+
+		var (
+			currentRound = view.Round
+			roundStart   = i.clock.Now()
+		)
+
+		i.state.setRoundStartedAt(roundStart)
 
-		currentRound := view.Round
 		ctxRound, cancelRound := context.WithCancel(ctx)
 
 		i.wg.Add(4)
@@ -315,6 +632,8 @@ func (i *IBFT) RunSequence(ctx context.Context, h uint64) {
 		teardown := func() {
 			cancelRound()
 			i.wg.Wait()
+
+			i.metrics.ObserveRoundDuration(i.clock.Now().Sub(roundStart))
 		}
 
 		select {
@@ -322,20 +641,47 @@ func (i *IBFT) RunSequence(ctx context.Context, h uint64) {
 			teardown()
 			i.log.Info("received future proposal", "round", ev.round)
 
-			i.moveToNewRound(ev.round)
-			i.acceptProposal(ev.proposalMessage)
+			if err := i.moveToNewRound(ev.round); err != nil {
+				return nil, err
+			}
+
+			roundChange(ev.round, RoundChangeFutureProposal)
+
+			if err := i.acceptProposal(ev.proposalMessage); err != nil {
+				return nil, err
+			}
+
 			i.state.setRoundStarted(true)
 		case round := <-i.roundCertificate:
 			teardown()
 			i.log.Info("received future RCC", "round", round)
 
-			i.moveToNewRound(round)
+			if err := i.moveToNewRound(round); err != nil {
+				return nil, err
+			}
+
+			roundChange(round, RoundChangeFutureRCC)
+		case round := <-i.roundSkip:
+			teardown()
+			i.log.Info("received future round changes", "round", round)
+
+			if err := i.moveToNewRound(round); err != nil {
+				return nil, err
+			}
+
+			roundChange(round, RoundChangeFutureRoundChanges)
+
+			i.sendRoundChangeMessage(h, round)
 		case <-i.roundExpired:
 			teardown()
 			i.log.Info("round timeout expired", "round", currentRound)
 
 			newRound := currentRound + 1
-			i.moveToNewRound(newRound)
+			if err := i.moveToNewRound(newRound); err != nil {
+				return nil, err
+			}
+
+			roundChange(newRound, RoundChangeTimeout)
 
 			i.sendRoundChangeMessage(h, newRound)
 		case <-i.roundDone:
@@ -343,12 +689,38 @@ func (i *IBFT) RunSequence(ctx context.Context, h uint64) {
 			// Stop all running worker threads
 			teardown()
 
-			return
+			result := &SequenceResult{
+				Height:         h,
+				Round:          i.state.getRound(),
+				Proposal:       i.state.getProposal(),
+				ProposalHash:   i.state.getProposalHash(),
+				CommittedSeals: i.state.getCommittedSeals(),
+				AggregatedSeal: i.state.getAggregatedSeal(),
+				RoundChanges:   roundChanges,
+				Duration:       i.clock.Now().Sub(start),
+			}
+
+			i.observeStateDuration(StateFin)
+			i.metrics.ObserveSequence(result)
+			i.observer.OnFinalized(result)
+
+			return result, nil
+		case err := <-i.sequenceFailed:
+			// The finalized proposal could not be inserted,
+			// or the state could not be persisted
+			teardown()
+
+			return nil, err
+		case <-synced:
+			teardown()
+			i.log.Info("height finalized through sync", "height", h)
+
+			return nil, ErrSequenceSkipped
 		case <-ctx.Done():
 			teardown()
 			i.log.Debug("sequence cancelled")
 
-			return
+			return nil, ErrSequenceCancelled
 		}
 	}
 }
@@ -358,25 +730,40 @@ func (i *IBFT) startRound(ctx context.Context) {
 	// Register this worker thread with the barrier
 	defer i.wg.Done()
 
-	i.state.newRound()
-
 	var (
-		id   = i.backend.ID()
-		view = i.state.getView()
+		started = i.state.newRound()
+		id      = i.backend.ID()
+		view    = i.state.getView()
 	)
 
 	// Check if any block needs to be proposed
 	if i.backend.IsProposer(id, view.Height, view.Round) {
 		i.log.Info("we are the proposer")
 
-		proposalMessage := i.buildProposal(ctx, view)
+		if !started {
+			// The proposal for the round was already accepted (before a restart),
+			// so it's sent out again instead of building a conflicting one
+			i.resendProposal(id)
+			i.runStates(ctx)
+
+			return
+		}
+
+		proposalMessage, err := i.buildProposal(ctx, view)
 		if proposalMessage == nil {
-			i.log.Error("unable to build proposal")
+			// Skip proposing in this round
+			i.log.Error("unable to build proposal", "err", err)
+
+			return
+		}
+
+		if err := i.acceptProposal(proposalMessage); err != nil {
+			// The proposal can't be sent out without being persisted
+			i.signalSequenceFailed(ctx, err)
 
 			return
 		}
 
-		i.acceptProposal(proposalMessage)
 		i.log.Debug("block proposal accepted")
 
 		i.sendPreprepareMessage(proposalMessage)
@@ -387,6 +774,19 @@ func (i *IBFT) startRound(ctx context.Context) {
 	i.runStates(ctx)
 }
 
+// resendProposal multicasts the accepted proposal for the round again,
+// if it was sent by this node
+func (i *IBFT) resendProposal(id []byte) {
+	proposalMessage := i.state.getProposalMessage()
+	if proposalMessage == nil || !bytes.Equal(proposalMessage.From, id) {
+		return
+	}
+
+	i.sendPreprepareMessage(proposalMessage)
+
+	i.log.Debug("pre-prepare message multicasted again")
+}
+
 // waitForRCC waits for valid RCC for the specified height and round
 func (i *IBFT) waitForRCC(
 	ctx context.Context,
@@ -394,7 +794,7 @@ func (i *IBFT) waitForRCC(
 	round uint64,
 ) *proto.RoundChangeCertificate {
 	var (
-		quorum = i.backend.Quorum(height)
+		quorum = i.quorum(height)
 		view   = &proto.View{
 			Height: height,
 			Round:  round,
@@ -404,7 +804,8 @@ func (i *IBFT) waitForRCC(
 			messages.SubscriptionDetails{
 				MessageType:    proto.MessageType_ROUND_CHANGE,
 				View:           view,
-				MinNumMessages: int(quorum),
+				MinVotingPower: quorum,
+				VotingPower:    i.votingPowerFn(height),
 			},
 		)
 	)
@@ -434,29 +835,41 @@ func (i *IBFT) handleRoundChangeMessage(view *proto.View, quorum uint64) *proto.
 		round  = view.Round
 	)
 
-	isValidFn := func(msg *proto.Message) bool {
-		proposal := messages.ExtractLastPreparedProposedBlock(msg)
-		certificate := messages.ExtractLatestPC(msg)
+	// The PCs are checked in a single batch
+	isValidFn := func(msgs []*proto.Message) []bool {
+		certificates := make([]*proto.PreparedCertificate, len(msgs))
+		for index, msg := range msgs {
+			certificates[index] = messages.ExtractLatestPC(msg)
+		}
+
+		results := i.validPCs(certificates, round, height)
 
-		// Check if the prepared certificate is valid
-		if !i.validPC(certificate, round, height) {
-			return false
+		for index, msg := range msgs {
+			// Make sure the certificate matches the proposal
+			results[index] = results[index] && i.proposalMatchesCertificate(
+				messages.ExtractLastPreparedProposedBlock(msg),
+				certificates[index],
+			)
 		}
 
-		// Make sure the certificate matches the proposal
-		return i.proposalMatchesCertificate(proposal, certificate)
+		return results
 	}
 
-	msgs := i.messages.GetValidMessages(
+	// The validity of round change messages
+	// depends only on their view
+	msgs := i.getValidMessages(
 		view,
 		proto.MessageType_ROUND_CHANGE,
+		"",
 		isValidFn,
 	)
 
-	if len(msgs) < int(quorum) {
+	if i.accumulatedVotingPower(height, msgs) < quorum {
 		return nil
 	}
 
+	i.observer.OnQuorumReached(copyView(view), proto.MessageType_ROUND_CHANGE)
+
 	return &proto.RoundChangeCertificate{
 		RoundChangeMessages: msgs,
 	}
@@ -503,18 +916,24 @@ func (i *IBFT) proposalMatchesCertificate(
 
 // runStates is the main loop which performs state transitions
 func (i *IBFT) runStates(ctx context.Context) {
-	var timeout error
+	var err error
 
 	for {
 		switch i.state.getStateName() {
-		case newRound:
-			timeout = i.runNewRound(ctx)
-		case prepare:
-			timeout = i.runPrepare(ctx)
-		case commit:
-			timeout = i.runCommit(ctx)
-		case fin:
-			i.runFin()
+		case StateNewRound:
+			err = i.runNewRound(ctx)
+		case StatePrepare:
+			err = i.runPrepare(ctx)
+		case StateCommit:
+			err = i.runCommit(ctx)
+		case StateFin:
+			if err = i.runFin(ctx); err != nil {
+				// The sequence can't be completed
+				i.signalSequenceFailed(ctx, err)
+
+				return
+			}
+
 			//	Block inserted without any errors,
 			// sequence is complete
 			i.signalRoundDone(ctx)
@@ -522,10 +941,17 @@ func (i *IBFT) runStates(ctx context.Context) {
 			return
 		}
 
-		if timeout != nil {
+		if errors.Is(err, errTimeoutExpired) {
 			// Timeout received
 			return
 		}
+
+		if err != nil {
+			// The sequence can't be completed
+			i.signalSequenceFailed(ctx, err)
+
+			return
+		}
 	}
 }
 
@@ -566,7 +992,9 @@ func (i *IBFT) runNewRound(ctx context.Context) error {
 			}
 
 			// Accept the proposal since it's valid
-			i.acceptProposal(proposalMessage)
+			if err := i.acceptProposal(proposalMessage); err != nil {
+				return err
+			}
 
 			// Multicast the PREPARE message
 			i.sendPrepareMessage(view)
@@ -574,7 +1002,7 @@ func (i *IBFT) runNewRound(ctx context.Context) error {
 			i.log.Debug("prepare message multicasted")
 
 			// Move to the prepare state
-			i.state.changeState(prepare)
+			i.changeState(StatePrepare)
 
 			return nil
 		}
@@ -653,7 +1081,7 @@ func (i *IBFT) validateProposal(msg *proto.Message, view *proto.View) bool {
 	}
 
 	// Make sure there are Quorum RCC
-	if len(certificate.RoundChangeMessages) < int(i.backend.Quorum(height)) {
+	if i.accumulatedVotingPower(height, certificate.RoundChangeMessages) < i.quorum(height) {
 		return false
 	}
 
@@ -677,20 +1105,33 @@ func (i *IBFT) validateProposal(msg *proto.Message, view *proto.View) bool {
 		round uint64
 	}
 
-	roundsAndPreparedBlockHashes := make([]roundHashTuple, 0)
+	var (
+		roundsAndPreparedBlockHashes = make([]roundHashTuple, 0)
+
+		certifiedMessages = make([]*proto.Message, 0, len(rcc.RoundChangeMessages))
+		certificates      = make([]*proto.PreparedCertificate, 0, len(rcc.RoundChangeMessages))
+	)
 
 	for _, rcMessage := range rcc.RoundChangeMessages {
-		certificate := messages.ExtractLatestPC(rcMessage)
-
-		// Check if there is a certificate, and if it's a valid PC
-		if certificate != nil && i.validPC(certificate, msg.View.Round, height) {
-			hash := messages.ExtractProposalHash(certificate.ProposalMessage)
+		// Check if there is a certificate
+		if certificate := messages.ExtractLatestPC(rcMessage); certificate != nil {
+			certifiedMessages = append(certifiedMessages, rcMessage)
+			certificates = append(certificates, certificate)
+		}
+	}
 
-			roundsAndPreparedBlockHashes = append(roundsAndPreparedBlockHashes, roundHashTuple{
-				round: rcMessage.View.Round,
-				hash:  hash,
-			})
+	// Check if the PCs are valid, in a single batch
+	for index, valid := range i.validPCs(certificates, msg.View.Round, height) {
+		if !valid {
+			continue
 		}
+
+		hash := messages.ExtractProposalHash(certificates[index].ProposalMessage)
+
+		roundsAndPreparedBlockHashes = append(roundsAndPreparedBlockHashes, roundHashTuple{
+			round: certifiedMessages[index].View.Round,
+			hash:  hash,
+		})
 	}
 
 	if len(roundsAndPreparedBlockHashes) == 0 {
@@ -725,10 +1166,12 @@ func (i *IBFT) handlePrePrepare(view *proto.View) *proto.Message {
 		return i.validateProposal(message, view)
 	}
 
-	msgs := i.messages.GetValidMessages(
+	// The validity of the proposal depends only on its view
+	msgs := i.getValidMessages(
 		view,
 		proto.MessageType_PREPREPARE,
-		isValidPrePrepare,
+		"",
+		validateEach(isValidPrePrepare),
 	)
 
 	if len(msgs) < 1 {
@@ -748,14 +1191,16 @@ func (i *IBFT) runPrepare(ctx context.Context) error {
 		view = i.state.getView()
 
 		// Grab quorum information
-		quorum = i.backend.Quorum(view.Height)
+		quorum = i.quorum(view.Height)
 
-		// Subscribe to PREPARE messages
+		// Subscribe to PREPARE messages, the proposer
+		// counts towards the quorum as well
 		sub = i.messages.Subscribe(
 			messages.SubscriptionDetails{
 				MessageType:    proto.MessageType_PREPARE,
 				View:           view,
-				MinNumMessages: int(quorum) - 1,
+				MinVotingPower: saturatingSub(quorum, i.proposerVotingPower(view.Height)),
+				VotingPower:    i.votingPowerFn(view.Height),
 			},
 		)
 	)
@@ -770,7 +1215,12 @@ func (i *IBFT) runPrepare(ctx context.Context) error {
 			// Stop signal received, exit
 			return errTimeoutExpired
 		case <-sub.SubCh:
-			if !i.handlePrepare(view, quorum) {
+			prepared, err := i.handlePrepare(view, quorum)
+			if err != nil {
+				return err
+			}
+
+			if !prepared {
 				//	quorum of valid prepare messages not received, retry
 				continue
 			}
@@ -781,8 +1231,9 @@ func (i *IBFT) runPrepare(ctx context.Context) error {
 }
 
 // handlePrepare parses available prepare messages and performs
-// a transition to COMMIT state, if quorum was reached
-func (i *IBFT) handlePrepare(view *proto.View, quorum uint64) bool {
+// a transition to COMMIT state, if quorum was reached.
+// It returns an error if the prepared lock could not be persisted
+func (i *IBFT) handlePrepare(view *proto.View, quorum uint64) (bool, error) {
 	isValidPrepare := func(message *proto.Message) bool {
 		// Verify that the proposal hash is valid
 		return i.backend.IsValidProposalHash(
@@ -791,31 +1242,49 @@ func (i *IBFT) handlePrepare(view *proto.View, quorum uint64) bool {
 		)
 	}
 
-	prepareMessages := i.messages.GetValidMessages(
+	// The validity of prepare messages depends on the accepted proposal
+	prepareMessages := i.getValidMessages(
 		view,
 		proto.MessageType_PREPARE,
-		isValidPrepare,
+		string(i.state.getProposalHash()),
+		validateEach(isValidPrepare),
 	)
 
-	if len(prepareMessages) < int(quorum)-1 {
+	// The proposer counts towards the quorum through the proposal,
+	// so its own PREPARE is left out of the quorum and the certificate
+	proposalMessage := i.state.getProposalMessage()
+	prepareMessages = excludeSender(prepareMessages, proposalMessage.GetFrom())
+
+	voters := append([]*proto.Message{proposalMessage}, prepareMessages...)
+
+	if i.accumulatedVotingPower(view.Height, voters) < quorum {
 		//	quorum not reached, keep polling
-		return false
+		return false, nil
 	}
 
-	// Multicast the COMMIT message
-	i.sendCommitMessage(view)
+	i.observer.OnQuorumReached(copyView(view), proto.MessageType_PREPARE)
 
-	i.log.Debug("commit message multicasted")
-
-	i.state.finalizePrepare(
+	previous := i.state.finalizePrepare(
 		&proto.PreparedCertificate{
-			ProposalMessage: i.state.getProposalMessage(),
+			ProposalMessage: proposalMessage,
 			PrepareMessages: prepareMessages,
 		},
 		i.state.getProposal(),
 	)
+	i.notifyStateChange(previous, StateCommit)
 
-	return true
+	// The prepared lock needs to be persisted
+	// before the COMMIT message goes out
+	if err := i.persistState(); err != nil {
+		return false, err
+	}
+
+	// Multicast the COMMIT message
+	i.sendCommitMessage(view)
+
+	i.log.Debug("commit message multicasted")
+
+	return true, nil
 }
 
 // runCommit runs the Commit IBFT state
@@ -828,14 +1297,15 @@ func (i *IBFT) runCommit(ctx context.Context) error {
 		view = i.state.getView()
 
 		// Grab quorum information
-		quorum = i.backend.Quorum(view.Height)
+		quorum = i.quorum(view.Height)
 
 		// Subscribe to COMMIT messages
 		sub = i.messages.Subscribe(
 			messages.SubscriptionDetails{
 				MessageType:    proto.MessageType_COMMIT,
 				View:           view,
-				MinNumMessages: int(quorum),
+				MinVotingPower: quorum,
+				VotingPower:    i.votingPowerFn(view.Height),
 			},
 		)
 	)
@@ -877,41 +1347,84 @@ func (i *IBFT) handleCommit(view *proto.View, quorum uint64) bool {
 		return i.backend.IsValidCommittedSeal(proposalHash, committedSeal)
 	}
 
-	commitMessages := i.messages.GetValidMessages(view, proto.MessageType_COMMIT, isValidCommit)
-	if len(commitMessages) < int(quorum) {
+	// The validity of commit messages depends on the accepted proposal
+	commitMessages := i.getValidMessages(
+		view,
+		proto.MessageType_COMMIT,
+		string(i.state.getProposalHash()),
+		validateEach(isValidCommit),
+	)
+
+	if i.accumulatedVotingPower(view.Height, commitMessages) < quorum {
 		//	quorum not reached, keep polling
 		return false
 	}
 
+	committedSeals := messages.ExtractCommittedSeals(commitMessages)
+
+	// Aggregate the committed seals, if supported
+	if aggregator, ok := i.backend.(SealAggregator); ok {
+		aggregatedSeal, err := i.aggregateSeals(aggregator, view.Height, committedSeals)
+		if err != nil {
+			i.log.Error("unable to aggregate committed seals", "err", err)
+
+			return false
+		}
+
+		i.state.setAggregatedSeal(aggregatedSeal)
+	}
+
+	i.observer.OnQuorumReached(copyView(view), proto.MessageType_COMMIT)
+
 	// Set the committed seals
-	i.state.setCommittedSeals(
-		messages.ExtractCommittedSeals(commitMessages),
-	)
+	i.state.setCommittedSeals(committedSeals)
 
 	//	Move to the fin state
-	i.state.changeState(fin)
+	i.changeState(StateFin)
 
 	return true
 }
 
 // runFin runs the fin state (block insertion)
-func (i *IBFT) runFin() {
+func (i *IBFT) runFin(ctx context.Context) error {
 	i.log.Debug("enter: fin state")
 	defer i.log.Debug("exit: fin state")
 
 	// Insert the block to the node's underlying
 	// blockchain layer
-	i.backend.InsertBlock(
-		i.state.getProposal(),
-		i.state.getCommittedSeals(),
-	)
+	if err := i.insertBlock(ctx); err != nil {
+		i.log.Error("unable to insert block", "err", err)
+
+		return fmt.Errorf("unable to insert block for height %d: %w", i.state.getHeight(), err)
+	}
 
 	// Remove stale messages
 	i.messages.PruneByHeight(i.state.getHeight())
+
+	return nil
+}
+
+// insertBlock inserts the finalized proposal, with the
+// aggregated seal if the backend aggregates seals
+func (i *IBFT) insertBlock(ctx context.Context) error {
+	if aggregator, ok := i.backend.(SealAggregator); ok {
+		return aggregator.InsertAggregatedBlock(
+			ctx,
+			i.state.getProposal(),
+			i.state.getAggregatedSeal(),
+		)
+	}
+
+	return i.contextBackend.InsertBlockContext(
+		ctx,
+		i.state.getProposal(),
+		i.state.getCommittedSeals(),
+	)
 }
 
-// moveToNewRound moves the state to the new round
-func (i *IBFT) moveToNewRound(round uint64) {
+// moveToNewRound moves the state to the new round.
+// It returns an error if the new round could not be persisted
+func (i *IBFT) moveToNewRound(round uint64) error {
 	i.state.setView(&proto.View{
 		Height: i.state.getHeight(),
 		Round:  round,
@@ -919,17 +1432,25 @@ func (i *IBFT) moveToNewRound(round uint64) {
 
 	i.state.setRoundStarted(false)
 	i.state.setProposalMessage(nil)
-	i.state.changeState(newRound)
+
+	// The time spent in the previous round's state is
+	// observed even if it was also the new round state
+	i.notifyStateChange(i.state.changeState(StateNewRound), StateNewRound)
+
+	return i.persistState()
 }
 
-func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Message {
+func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) (*proto.Message, error) {
 	var (
 		height = view.Height
 		round  = view.Round
 	)
 
 	if round == 0 {
-		proposal := i.backend.BuildProposal(height)
+		proposal, err := i.contextBackend.BuildProposalContext(ctx, height)
+		if err != nil {
+			return nil, err
+		}
 
 		return i.backend.BuildPrePrepareMessage(
 			proposal,
@@ -938,14 +1459,14 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 				Height: height,
 				Round:  round,
 			},
-		)
+		), nil
 	}
 
 	//	round > 0 -> needs RCC
 	rcc := i.waitForRCC(ctx, height, round)
 	if rcc == nil {
 		// Timeout occurred
-		return nil
+		return nil, errTimeoutExpired
 	}
 
 	//	check the messages for any previous proposal (if they have any, it's the same proposal)
@@ -964,7 +1485,10 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 
 	if previousProposal == nil {
 		//	build new proposal
-		proposal := i.backend.BuildProposal(height)
+		proposal, err := i.contextBackend.BuildProposalContext(ctx, height)
+		if err != nil {
+			return nil, err
+		}
 
 		return i.backend.BuildPrePrepareMessage(
 			proposal,
@@ -973,7 +1497,7 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 				Height: height,
 				Round:  round,
 			},
-		)
+		), nil
 	}
 
 	return i.backend.BuildPrePrepareMessage(
@@ -983,49 +1507,200 @@ func (i *IBFT) buildProposal(ctx context.Context, view *proto.View) *proto.Messa
 			Height: height,
 			Round:  round,
 		},
-	)
+	), nil
 }
 
-// acceptProposal accepts the proposal and moves the state
-func (i *IBFT) acceptProposal(proposalMessage *proto.Message) {
+// acceptProposal accepts the proposal and moves the state.
+// It returns an error if the accepted proposal could not be persisted
+func (i *IBFT) acceptProposal(proposalMessage *proto.Message) error {
 	//	accept newly proposed block and move to PREPARE state
 	i.state.setProposalMessage(proposalMessage)
-	i.state.changeState(prepare)
+	i.observer.OnProposalAccepted(
+		i.state.getView(),
+		proposalMessage.GetPreprepareData().GetProposalHash(),
+	)
+
+	i.changeState(StatePrepare)
+
+	return i.persistState()
 }
 
-// AddMessage adds a new message to the IBFT message system
-func (i *IBFT) AddMessage(message *proto.Message) {
-	// Make sure the message is present
-	if message == nil {
+// changeState moves the state machine to the specified state
+func (i *IBFT) changeState(name StateType) {
+	if from := i.state.changeState(name); from != name {
+		i.notifyStateChange(from, name)
+	}
+}
+
+// notifyStateChange observes the time spent in the previous
+// state, and notifies the observer of the state transition
+func (i *IBFT) notifyStateChange(from, to StateType) {
+	i.observeStateDuration(from)
+
+	if from == to {
 		return
 	}
 
+	i.observer.OnStateChange(i.state.getView(), from, to)
+}
+
+// observeStateDuration observes the time spent in the state that is being left
+func (i *IBFT) observeStateDuration(name StateType) {
+	now := i.clock.Now()
+
+	if started := i.state.setStateStarted(now); !started.IsZero() {
+		i.metrics.ObserveStateDuration(name, now.Sub(started))
+	}
+}
+
+// AddMessage adds a new message to the IBFT message system.
+// Messages that are rejected are not added, and the returned error
+// describes the reason (ErrMalformedPayload, ErrMessageTooLarge, ErrInvalidSender,
+// ErrStaleHeight, ErrOldRound, ErrFutureHeight or ErrFutureRound)
+func (i *IBFT) AddMessage(message *proto.Message) error {
+	// Make sure the message is structurally valid
+	if err := ValidateMessage(message, i.maxMessageSize); err != nil {
+		i.metrics.IncDroppedMessage(dropReason(err))
+
+		return err
+	}
+
 	// Check if the message should even be considered
-	if i.isAcceptableMessage(message) {
-		i.messages.AddMessage(message)
+	return i.admitMessage(message, i.checkAcceptable(message))
+}
+
+// AddMessages adds a burst of new messages to the IBFT message system, and returns
+// the rejection reason for each of them, in order (nil for the added ones).
+// If the backend is a BatchVerifier, the senders are checked in a single batch
+func (i *IBFT) AddMessages(messages []*proto.Message) []error {
+	var (
+		errs = make([]error, len(messages))
+
+		// valid are the structurally valid messages,
+		// and indexes are their positions in the burst
+		valid   = make([]*proto.Message, 0, len(messages))
+		indexes = make([]int, 0, len(messages))
+	)
+
+	for index, message := range messages {
+		// Make sure the message is structurally valid
+		if err := ValidateMessage(message, i.maxMessageSize); err != nil {
+			i.metrics.IncDroppedMessage(dropReason(err))
+
+			errs[index] = err
+
+			continue
+		}
+
+		valid = append(valid, message)
+		indexes = append(indexes, index)
 	}
+
+	for position, validSender := range i.areValidSenders(valid) {
+		message := valid[position]
+
+		err := ErrInvalidSender
+		if validSender {
+			err = i.checkView(message)
+		}
+
+		errs[indexes[position]] = i.admitMessage(message, err)
+	}
+
+	return errs
 }
 
-// isAcceptableMessage checks if the message can even be accepted
-func (i *IBFT) isAcceptableMessage(message *proto.Message) bool {
+// admitMessage adds the structurally valid message to the message system,
+// unless it was rejected for the passed in reason, which is returned
+func (i *IBFT) admitMessage(message *proto.Message, rejection error) error {
+	if rejection != nil {
+		if errors.Is(rejection, ErrFutureHeight) {
+			// Messages beyond the window aren't stored,
+			// but they still show the node is falling behind
+			i.trackFutureMessage(message)
+		}
+
+		i.metrics.IncDroppedMessage(dropReason(rejection))
+
+		return rejection
+	}
+
+	i.messages.AddMessage(message)
+	i.trackFutureMessage(message)
+
+	return nil
+}
+
+// checkAcceptable checks if the message can even be accepted
+// for the current view, and returns the reason if it can't
+func (i *IBFT) checkAcceptable(message *proto.Message) error {
 	//	Make sure the message sender is ok
 	if !i.backend.IsValidSender(message) {
-		return false
+		return ErrInvalidSender
 	}
 
+	return i.checkView(message)
+}
+
+// checkView checks if the message view can be accepted
+// for the current view, and returns the reason if it can't
+func (i *IBFT) checkView(message *proto.Message) error {
 	// Invalid messages are discarded
 	if message.View == nil {
-		return false
+		return fmt.Errorf("%w: view is not set", ErrMalformedPayload)
 	}
 
 	// Make sure the message is in accordance with
 	// the current state height, or greater
-	if i.state.getHeight() > message.View.Height {
-		return false
+	view := i.state.getView()
+	if view.Height > message.View.Height {
+		return fmt.Errorf("%w: height %d, current height %d", ErrStaleHeight, message.View.Height, view.Height)
+	}
+
+	// Messages for future heights are accepted from any round within the window,
+	// since the sequence for the height starts from round 0
+	if message.View.Height > view.Height {
+		if i.maxHeightsAhead > 0 && message.View.Height-view.Height > i.maxHeightsAhead {
+			return fmt.Errorf(
+				"%w: height %d, current height %d",
+				ErrFutureHeight,
+				message.View.Height,
+				view.Height,
+			)
+		}
+
+		return checkRoundWindow(message.View.Round, 0, i.maxRoundsAhead)
 	}
 
 	// Make sure the message round is >= the current state round
-	return message.View.Round >= i.state.getRound()
+	if message.View.Round < view.Round {
+		return fmt.Errorf("%w: round %d, current round %d", ErrOldRound, message.View.Round, view.Round)
+	}
+
+	return checkRoundWindow(message.View.Round, view.Round, i.roundWindow(message))
+}
+
+// roundWindow returns the number of rounds above the current one the message
+// for the current height is accepted for. ROUND_CHANGE messages get the wider
+// window, since they are how a node that fell behind catches up (round skip)
+func (i *IBFT) roundWindow(message *proto.Message) uint64 {
+	if message.Type == proto.MessageType_ROUND_CHANGE &&
+		i.maxRoundsAhead > 0 &&
+		i.maxRoundChangeRoundsAhead > i.maxRoundsAhead {
+		return i.maxRoundChangeRoundsAhead
+	}
+
+	return i.maxRoundsAhead
+}
+
+// checkRoundWindow makes sure the message round is within
+// the window of rounds ahead of the current one. Zero means there is no limit
+func checkRoundWindow(round, currentRound, window uint64) error {
+	if window > 0 && round-currentRound > window {
+		return fmt.Errorf("%w: round %d, current round %d", ErrFutureRound, round, currentRound)
+	}
+
+	return nil
 }
 
 // ExtendRoundTimeout extends each round's timer by the specified amount.
@@ -1033,17 +1708,163 @@ func (i *IBFT) ExtendRoundTimeout(amount time.Duration) {
 	i.additionalTimeout = amount
 }
 
+// restoreState restores the latest persisted state, if a state store is set.
+// The restored state is picked up by the next RunSequence call for the same height
+func (i *IBFT) restoreState() error {
+	if i.store == nil {
+		return nil
+	}
+
+	persisted, err := i.store.LoadState()
+	if errors.Is(err, ErrStateNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to load persisted state, %w", err)
+	}
+
+	i.restored = persisted
+	i.state.restore(persisted)
+	i.guard.restore(persisted.View.Height, persisted.Messages)
+
+	return nil
+}
+
+// restoreSequence restores the persisted state for the specified height, if any.
+// The outgoing messages for the height are added back to the message storage
+func (i *IBFT) restoreSequence(height uint64) bool {
+	restored := i.restored
+	i.restored = nil
+
+	if restored == nil || restored.View.Height != height {
+		return false
+	}
+
+	i.state.restore(restored)
+
+	for _, message := range restored.Messages {
+		i.messages.AddMessage(message)
+	}
+
+	i.log.Info(
+		"persisted state restored",
+		"height", height,
+		"round", restored.View.Round,
+		"state", restored.Name.String(),
+	)
+
+	return true
+}
+
+// persistState persists the current state, if a state store is set.
+// Nothing that depends on the state may be sent out if it fails
+func (i *IBFT) persistState() error {
+	if i.store == nil {
+		return nil
+	}
+
+	if err := i.store.SaveState(i.state.snapshot()); err != nil {
+		return fmt.Errorf("unable to persist state, %w", err)
+	}
+
+	return nil
+}
+
+// multicast checks the outgoing message against the signing guard,
+// persists it, if a state store is set, and multicasts it.
+// Messages that are refused or can't be persisted are not sent out.
+// The sent out message is noted for retransmission
+func (i *IBFT) multicast(message *proto.Message) {
+	if err := i.guard.check(message); err != nil {
+		i.log.Error("outgoing message refused", "err", err)
+
+		if i.signingErrorHandler != nil {
+			i.signingErrorHandler(message, err)
+		}
+
+		return
+	}
+
+	if i.store != nil {
+		if err := i.store.SaveMessage(message); err != nil {
+			i.log.Error("unable to persist outgoing message", "err", err)
+
+			return
+		}
+	}
+
+	i.transport.Multicast(message)
+	i.retransmit.setMessage(message)
+}
+
 // validPC verifies that  the prepared certificate is valid
 func (i *IBFT) validPC(
 	certificate *proto.PreparedCertificate,
 	rLimit,
 	height uint64,
 ) bool {
-	if certificate == nil {
-		// PCs that are not set are valid by default
-		return true
+	return i.validPCs([]*proto.PreparedCertificate{certificate}, rLimit, height)[0]
+}
+
+// validPCs verifies the prepared certificates, and returns the result for each of
+// them, in order. The senders of the PREPARE messages of all certificates are
+// checked in a single batch, once the rest of the certificate checks pass
+func (i *IBFT) validPCs(
+	certificates []*proto.PreparedCertificate,
+	rLimit,
+	height uint64,
+) []bool {
+	var (
+		results = make([]bool, len(certificates))
+
+		// pending are the indexes of the certificates
+		// that only need their senders checked
+		pending  = make([]int, 0, len(certificates))
+		prepares = make([]*proto.Message, 0)
+	)
+
+	for index, certificate := range certificates {
+		if certificate == nil {
+			// PCs that are not set are valid by default
+			results[index] = true
+
+			continue
+		}
+
+		if !i.validPCContents(certificate, rLimit, height) {
+			continue
+		}
+
+		pending = append(pending, index)
+		prepares = append(prepares, certificate.PrepareMessages...)
+	}
+
+	validSenders := i.areValidSenders(prepares)
+
+	for _, index := range pending {
+		count := len(certificates[index].PrepareMessages)
+
+		results[index] = true
+
+		// Make sure the Prepare messages are validators, apart from the proposer
+		for _, valid := range validSenders[:count] {
+			results[index] = results[index] && valid
+		}
+
+		validSenders = validSenders[count:]
 	}
 
+	return results
+}
+
+// validPCContents verifies the prepared certificate,
+// apart from the senders of the PREPARE messages
+func (i *IBFT) validPCContents(
+	certificate *proto.PreparedCertificate,
+	rLimit,
+	height uint64,
+) bool {
 	// Make sure that either both the proposal message and the prepare messages are set together
 	if certificate.ProposalMessage == nil || certificate.PrepareMessages == nil {
 		return false
@@ -1055,7 +1876,7 @@ func (i *IBFT) validPC(
 	)
 
 	// Make sure there are at least Quorum (PP + P) messages
-	if len(allMessages) < int(i.backend.Quorum(i.state.getHeight())) {
+	if i.accumulatedVotingPower(height, allMessages) < i.quorum(height) {
 		return false
 	}
 
@@ -1094,29 +1915,18 @@ func (i *IBFT) validPC(
 	// Make sure the proposal message is sent by the proposer
 	// for the round
 	proposal := certificate.ProposalMessage
-	if !i.backend.IsProposer(proposal.From, proposal.View.Height, proposal.View.Round) {
-		return false
-	}
 
-	// Make sure the Prepare messages are validators, apart from the proposer
-	for _, message := range certificate.PrepareMessages {
-		// Make sure the sender is part of the validator set
-		if !i.backend.IsValidSender(message) {
-			return false
-		}
-	}
-
-	return true
+	return i.backend.IsProposer(proposal.From, proposal.View.Height, proposal.View.Round)
 }
 
 // sendPreprepareMessage sends out the preprepare message
 func (i *IBFT) sendPreprepareMessage(message *proto.Message) {
-	i.transport.Multicast(message)
+	i.multicast(message)
 }
 
 // sendRoundChangeMessage sends out the round change message
 func (i *IBFT) sendRoundChangeMessage(height, newRound uint64) {
-	i.transport.Multicast(
+	i.multicast(
 		i.backend.BuildRoundChangeMessage(
 			i.state.getLatestPreparedProposedBlock(),
 			i.state.getLatestPC(),
@@ -1130,7 +1940,7 @@ func (i *IBFT) sendRoundChangeMessage(height, newRound uint64) {
 
 // sendPrepareMessage sends out the prepare message
 func (i *IBFT) sendPrepareMessage(view *proto.View) {
-	i.transport.Multicast(
+	i.multicast(
 		i.backend.BuildPrepareMessage(
 			i.state.getProposalHash(),
 			view,
@@ -1140,7 +1950,7 @@ func (i *IBFT) sendPrepareMessage(view *proto.View) {
 
 // sendCommitMessage sends out the commit message
 func (i *IBFT) sendCommitMessage(view *proto.View) {
-	i.transport.Multicast(
+	i.multicast(
 		i.backend.BuildCommitMessage(
 			i.state.getProposalHash(),
 			view,
@@ -3,6 +3,7 @@ package core
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -11,6 +12,7 @@ import (
 	"github.com/madz-lab/go-ibft/messages"
 	"github.com/madz-lab/go-ibft/messages/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func proposalMatches(proposal []byte, message *proto.Message) bool {
@@ -155,7 +157,7 @@ func generateFilledRCMessages(
 	proposalHash []byte,
 ) []*proto.Message {
 	// Generate random RC messages
-	roundChangeMessages := generateMessages(quorum, proto.MessageType_ROUND_CHANGE)
+	roundChangeMessages := generateMessagesWithUniqueSender(quorum, proto.MessageType_ROUND_CHANGE)
 	prepareMessages := generateMessages(quorum-1, proto.MessageType_PREPARE)
 
 	// Fill up the prepare message hashes
@@ -267,7 +269,7 @@ func TestRunNewRound_Proposer(t *testing.T) {
 				}
 			)
 
-			i := NewIBFT(log, backend, transport)
+			i := newTestIBFT(t, log, backend, transport)
 			i.messages = messages
 
 			i.wg.Add(1)
@@ -276,7 +278,7 @@ func TestRunNewRound_Proposer(t *testing.T) {
 			i.wg.Wait()
 
 			// Make sure the node is in prepare state
-			assert.Equal(t, prepare, i.state.name)
+			assert.Equal(t, StatePrepare, i.state.name)
 
 			// Make sure the accepted proposal is the one proposed to other nodes
 			assert.Equal(t, multicastedProposal, i.state.proposalMessage)
@@ -294,7 +296,7 @@ func TestRunNewRound_Proposer(t *testing.T) {
 			quorum := uint64(4)
 			ctx, cancelFn := context.WithCancel(context.Background())
 
-			roundChangeMessages := generateMessages(quorum, proto.MessageType_ROUND_CHANGE)
+			roundChangeMessages := generateMessagesWithUniqueSender(quorum, proto.MessageType_ROUND_CHANGE)
 			setRoundForMessages(roundChangeMessages, 1)
 
 			var (
@@ -376,7 +378,7 @@ func TestRunNewRound_Proposer(t *testing.T) {
 				}
 			)
 
-			i := NewIBFT(log, backend, transport)
+			i := newTestIBFT(t, log, backend, transport)
 			i.messages = messages
 			i.state.setView(&proto.View{
 				Height: 0,
@@ -391,7 +393,7 @@ func TestRunNewRound_Proposer(t *testing.T) {
 			i.wg.Wait()
 
 			// Make sure the node changed the state to prepare
-			assert.Equal(t, prepare, i.state.name)
+			assert.Equal(t, StatePrepare, i.state.name)
 
 			// Make sure the multicasted proposal is the accepted proposal
 			assert.Equal(t, multicastedPreprepare, i.state.proposalMessage)
@@ -415,7 +417,7 @@ func TestRunNewRound_Proposer(t *testing.T) {
 			quorum := uint64(4)
 			ctx, cancelFn := context.WithCancel(context.Background())
 
-			roundChangeMessages := generateMessages(quorum, proto.MessageType_ROUND_CHANGE)
+			roundChangeMessages := generateMessagesWithUniqueSender(quorum, proto.MessageType_ROUND_CHANGE)
 			prepareMessages := generateMessages(quorum-1, proto.MessageType_PREPARE)
 
 			for index, message := range prepareMessages {
@@ -533,7 +535,7 @@ func TestRunNewRound_Proposer(t *testing.T) {
 				}
 			)
 
-			i := NewIBFT(log, backend, transport)
+			i := newTestIBFT(t, log, backend, transport)
 			i.messages = messages
 			i.state.setView(&proto.View{
 				Height: 0,
@@ -548,7 +550,7 @@ func TestRunNewRound_Proposer(t *testing.T) {
 			i.wg.Wait()
 
 			// Make sure the node changed the state to prepare
-			assert.Equal(t, prepare, i.state.name)
+			assert.Equal(t, StatePrepare, i.state.name)
 
 			// Make sure the multicasted proposal is the accepted proposal
 			assert.Equal(t, multicastedPreprepare, i.state.proposalMessage)
@@ -643,7 +645,7 @@ func TestRunNewRound_Validator_Zero(t *testing.T) {
 		}
 	)
 
-	i := NewIBFT(log, backend, transport)
+	i := newTestIBFT(t, log, backend, transport)
 	i.messages = messages
 
 	// Make sure the notification is sent out
@@ -655,7 +657,7 @@ func TestRunNewRound_Validator_Zero(t *testing.T) {
 	i.wg.Wait()
 
 	// Make sure the node moves to prepare state
-	assert.Equal(t, prepare, i.state.name)
+	assert.Equal(t, StatePrepare, i.state.name)
 
 	// Make sure the accepted proposal is the one that was sent out
 	assert.Equal(t, proposal, i.state.getProposal())
@@ -802,7 +804,7 @@ func TestRunNewRound_Validator_NonZero(t *testing.T) {
 				}
 			)
 
-			i := NewIBFT(log, backend, transport)
+			i := newTestIBFT(t, log, backend, transport)
 			i.messages = messages
 			i.state.setView(&proto.View{
 				Height: 0,
@@ -818,7 +820,7 @@ func TestRunNewRound_Validator_NonZero(t *testing.T) {
 			i.wg.Wait()
 
 			// Make sure the node moves to prepare state
-			assert.Equal(t, prepare, i.state.name)
+			assert.Equal(t, StatePrepare, i.state.name)
 
 			// Make sure the accepted proposal is the one that was sent out
 			assert.Equal(t, proposal, i.state.getProposal())
@@ -905,8 +907,8 @@ func TestRunPrepare(t *testing.T) {
 				}
 			)
 
-			i := NewIBFT(log, backend, transport)
-			i.state.name = prepare
+			i := newTestIBFT(t, log, backend, transport)
+			i.state.name = StatePrepare
 			i.state.roundStarted = true
 			i.state.proposalMessage = &proto.Message{
 				Payload: &proto.Message_PreprepareData{
@@ -927,7 +929,7 @@ func TestRunPrepare(t *testing.T) {
 			i.wg.Wait()
 
 			// Make sure the node moves to the commit state
-			assert.Equal(t, commit, i.state.name)
+			assert.Equal(t, StateCommit, i.state.name)
 
 			// Make sure the proposal didn't change
 			assert.Equal(t, proposal, i.state.getProposal())
@@ -1011,7 +1013,7 @@ func TestRunCommit(t *testing.T) {
 				}
 			)
 
-			i := NewIBFT(log, backend, transport)
+			i := newTestIBFT(t, log, backend, transport)
 			i.messages = messages
 			i.state.proposalMessage = &proto.Message{
 				Payload: &proto.Message_PreprepareData{
@@ -1022,7 +1024,7 @@ func TestRunCommit(t *testing.T) {
 				},
 			}
 			i.state.roundStarted = true
-			i.state.name = commit
+			i.state.name = StateCommit
 
 			ctx, cancelFn := context.WithCancel(context.Background())
 
@@ -1051,7 +1053,7 @@ func TestRunCommit(t *testing.T) {
 			i.wg.Wait()
 
 			// Make sure the node changed the state to fin
-			assert.Equal(t, fin, i.state.name)
+			assert.Equal(t, StateFin, i.state.name)
 
 			// Make sure the inserted proposal was the one present
 			assert.Equal(t, insertedProposal, proposal)
@@ -1066,9 +1068,9 @@ func TestRunCommit(t *testing.T) {
 	)
 }
 
-// TestIBFT_IsAcceptableMessage makes sure invalid messages
+// TestIBFT_CheckAcceptable makes sure invalid messages
 // are properly handled
-func TestIBFT_IsAcceptableMessage(t *testing.T) {
+func TestIBFT_CheckAcceptable(t *testing.T) {
 	t.Parallel()
 
 	baseView := &proto.View{
@@ -1080,32 +1082,78 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 		view          *proto.View
 		currentView   *proto.View
 		name          string
+		expectedErr   error
 		invalidSender bool
-		acceptable    bool
 	}{
 		{
 			nil,
 			baseView,
 			"invalid sender",
+			ErrInvalidSender,
 			true,
-			false,
 		},
 		{
 			nil,
 			baseView,
 			"malformed message",
-			false,
+			ErrMalformedPayload,
 			false,
 		},
 		{
 			&proto.View{
-				Height: baseView.Height + 100,
+				Height: baseView.Height + DefaultMaxHeightsAhead,
 				Round:  baseView.Round,
 			},
 			baseView,
 			"higher height number",
+			nil,
+			false,
+		},
+		{
+			&proto.View{
+				Height: baseView.Height + DefaultMaxHeightsAhead + 1,
+				Round:  baseView.Round,
+			},
+			baseView,
+			"height number beyond the window",
+			ErrFutureHeight,
+			false,
+		},
+		{
+			&proto.View{
+				Height: baseView.Height + 1,
+				Round:  DefaultMaxRoundsAhead + 1,
+			},
+			&proto.View{
+				Height: baseView.Height,
+				Round:  baseView.Round + 5,
+			},
+			"higher height number, round number beyond the window",
+			ErrFutureRound,
+			false,
+		},
+		{
+			&proto.View{
+				Height: baseView.Height,
+				Round:  baseView.Round + 5 + DefaultMaxRoundsAhead,
+			},
+			&proto.View{
+				Height: baseView.Height,
+				Round:  baseView.Round + 5,
+			},
+			"higher round number, within the window",
+			nil,
+			false,
+		},
+		{
+			&proto.View{
+				Height: baseView.Height,
+				Round:  baseView.Round + DefaultMaxRoundsAhead + 1,
+			},
+			baseView,
+			"round number beyond the window",
+			ErrFutureRound,
 			false,
-			true,
 		},
 		{
 			&proto.View{
@@ -1114,8 +1162,8 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 			},
 			baseView,
 			"higher round number",
+			nil,
 			false,
-			true,
 		},
 		{
 			baseView,
@@ -1124,7 +1172,33 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 				Round:  baseView.Round,
 			},
 			"lower height number",
+			ErrStaleHeight,
 			false,
+		},
+		{
+			&proto.View{
+				Height: baseView.Height + 1,
+				Round:  baseView.Round,
+			},
+			&proto.View{
+				Height: baseView.Height,
+				Round:  baseView.Round + 5,
+			},
+			"higher height number, lower round number",
+			nil,
+			false,
+		},
+		{
+			&proto.View{
+				Height: baseView.Height,
+				Round:  baseView.Round,
+			},
+			&proto.View{
+				Height: baseView.Height,
+				Round:  baseView.Round + 5,
+			},
+			"same height number, lower round number",
+			ErrOldRound,
 			false,
 		},
 	}
@@ -1143,14 +1217,22 @@ func TestIBFT_IsAcceptableMessage(t *testing.T) {
 					},
 				}
 			)
-			i := NewIBFT(log, backend, transport)
+			i := newTestIBFT(t, log, backend, transport)
 			i.state.view = testCase.currentView
 
 			message := &proto.Message{
 				View: testCase.view,
 			}
 
-			assert.Equal(t, testCase.acceptable, i.isAcceptableMessage(message))
+			err := i.checkAcceptable(message)
+
+			if testCase.expectedErr == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
 		})
 	}
 }
@@ -1171,7 +1253,7 @@ func TestIBFT_StartRoundTimer(t *testing.T) {
 			backend   = mockBackend{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		ctx, cancelFn := context.WithCancel(context.Background())
 
@@ -1200,18 +1282,16 @@ func TestIBFT_StartRoundTimer(t *testing.T) {
 			backend   = mockBackend{}
 		)
 
-		i := NewIBFT(log, backend, transport)
-		i.baseRoundTimeout = 0 * time.Second
+		clock := NewManualClock(time.Now())
+
+		i := newTestIBFT(t, log, backend, transport, WithClock(clock))
 
 		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
 
 		wg.Add(1)
 		go func() {
-			defer func() {
-				wg.Done()
-
-				cancelFn()
-			}()
+			defer wg.Done()
 
 			select {
 			case <-i.roundExpired:
@@ -1221,13 +1301,55 @@ func TestIBFT_StartRoundTimer(t *testing.T) {
 		}()
 
 		i.wg.Add(1)
-		i.startRoundTimer(ctx, 0)
+		go i.startRoundTimer(ctx, 0)
+
+		// Wait for the round timer to start
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Make sure the round timer doesn't expire early
+		clock.Advance(round0Timeout - time.Nanosecond)
+		assert.Equal(t, 1, clock.Timers())
+
+		clock.Advance(time.Nanosecond)
 
 		wg.Wait()
 
 		// Make sure the round timer expired properly
 		assert.True(t, expired)
 	})
+
+	t.Run("round timer is stopped due to a quit signal", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			log       = mockLogger{}
+			transport = mockTransport{}
+			backend   = mockBackend{}
+		)
+
+		clock := NewManualClock(time.Now())
+
+		i := newTestIBFT(t, log, backend, transport, WithClock(clock))
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+
+		i.wg.Add(1)
+		go i.startRoundTimer(ctx, 0)
+
+		// Wait for the round timer to start
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cancelFn()
+		i.wg.Wait()
+
+		// Make sure the round timer was stopped
+		assert.Equal(t, 0, clock.Timers())
+		assert.Len(t, i.roundExpired, 0)
+	})
 }
 
 // TestIBFT_MoveToNewRound makes sure the state is modified
@@ -1246,9 +1368,9 @@ func TestIBFT_MoveToNewRound(t *testing.T) {
 			backend   = mockBackend{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
-		i.moveToNewRound(expectedNewRound)
+		require.NoError(t, i.moveToNewRound(expectedNewRound))
 
 		// Make sure the view has changed
 		assert.Equal(t, expectedNewRound, i.state.getRound())
@@ -1257,7 +1379,7 @@ func TestIBFT_MoveToNewRound(t *testing.T) {
 		assert.Nil(t, i.state.getProposal())
 
 		// Make sure the state is correct
-		assert.Equal(t, newRound, i.state.name)
+		assert.Equal(t, StateNewRound, i.state.name)
 	})
 }
 
@@ -1274,7 +1396,7 @@ func TestIBFT_FutureProposal(t *testing.T) {
 
 	generateEmptyRCMessages := func(count uint64) []*proto.Message {
 		// Generate random RC messages
-		roundChangeMessages := generateMessages(count, proto.MessageType_ROUND_CHANGE)
+		roundChangeMessages := generateMessagesWithUniqueSender(count, proto.MessageType_ROUND_CHANGE)
 
 		// Fill up their certificates
 		for _, message := range roundChangeMessages {
@@ -1381,7 +1503,7 @@ func TestIBFT_FutureProposal(t *testing.T) {
 				}
 			)
 
-			i := NewIBFT(log, backend, transport)
+			i := newTestIBFT(t, log, backend, transport)
 			i.messages = mMessages
 
 			wg.Add(1)
@@ -1433,7 +1555,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			backend   = mockBackend{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		assert.True(t, i.validPC(certificate, 0, 0))
 	})
@@ -1447,7 +1569,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			backend   = mockBackend{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		certificate := &proto.PreparedCertificate{
 			ProposalMessage: nil,
@@ -1479,7 +1601,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		certificate := &proto.PreparedCertificate{
 			ProposalMessage: &proto.Message{},
@@ -1504,7 +1626,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		certificate := &proto.PreparedCertificate{
 			ProposalMessage: &proto.Message{
@@ -1531,7 +1653,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		certificate := &proto.PreparedCertificate{
 			ProposalMessage: &proto.Message{
@@ -1562,7 +1684,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		certificate := &proto.PreparedCertificate{
 			ProposalMessage: &proto.Message{
@@ -1591,7 +1713,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		proposal := generateMessagesWithSender(1, proto.MessageType_PREPREPARE, sender)[0]
 
@@ -1625,7 +1747,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		proposal := generateMessagesWithSender(1, proto.MessageType_PREPREPARE, sender)[0]
 
@@ -1667,7 +1789,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		proposal := generateMessagesWithSender(1, proto.MessageType_PREPREPARE, sender)[0]
 
@@ -1712,7 +1834,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		proposal := generateMessagesWithSender(1, proto.MessageType_PREPREPARE, sender)[0]
 
@@ -1758,7 +1880,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		proposal := generateMessagesWithSender(1, proto.MessageType_PREPREPARE, sender)[0]
 
@@ -1803,7 +1925,7 @@ func TestIBFT_ValidPC(t *testing.T) {
 			}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		proposal := generateMessagesWithSender(1, proto.MessageType_PREPREPARE, sender)[0]
 
@@ -1823,6 +1945,60 @@ func TestIBFT_ValidPC(t *testing.T) {
 
 		assert.True(t, i.validPC(certificate, rLimit, 0))
 	})
+
+	t.Run("quorum of the certificate height", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			height       = uint64(5)
+			quorum       = uint64(4)
+			rLimit       = uint64(1)
+			sender       = []byte("unique node")
+			proposalHash = []byte("proposal hash")
+
+			log       = mockLogger{}
+			transport = mockTransport{}
+			backend   = mockBackend{
+				quorumFn: func(blockHeight uint64) uint64 {
+					// The validator set at the current height is larger
+					if blockHeight != height {
+						return 2 * quorum
+					}
+
+					return quorum
+				},
+				isProposerFn: func(proposer []byte, _ uint64, _ uint64) bool {
+					return bytes.Equal(proposer, sender)
+				},
+				isValidSenderFn: func(message *proto.Message) bool {
+					return true
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport)
+
+		proposal := generateMessagesWithSender(1, proto.MessageType_PREPREPARE, sender)[0]
+
+		certificate := &proto.PreparedCertificate{
+			ProposalMessage: proposal,
+			PrepareMessages: generateMessagesWithUniqueSender(quorum-1, proto.MessageType_PREPARE),
+		}
+
+		allMessages := append([]*proto.Message{certificate.ProposalMessage}, certificate.PrepareMessages...)
+		appendProposalHash(
+			allMessages,
+			proposalHash,
+		)
+
+		for _, message := range allMessages {
+			message.View.Height = height
+		}
+
+		setRoundForMessages(allMessages, rLimit-1)
+
+		assert.True(t, i.validPC(certificate, rLimit, height))
+	})
 }
 
 func TestIBFT_ValidateProposal(t *testing.T) {
@@ -1841,7 +2017,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			transport = mockTransport{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		baseView := &proto.View{
 			Height: 0,
@@ -1874,7 +2050,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			transport = mockTransport{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		baseView := &proto.View{
 			Height: 0,
@@ -1907,7 +2083,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			transport = mockTransport{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		baseView := &proto.View{
 			Height: 0,
@@ -1937,7 +2113,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			transport = mockTransport{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		baseView := &proto.View{
 			Height: 0,
@@ -1974,7 +2150,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			transport = mockTransport{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		baseView := &proto.View{
 			Height: 0,
@@ -1986,7 +2162,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			Payload: &proto.Message_PreprepareData{
 				PreprepareData: &proto.PrePrepareMessage{
 					Certificate: &proto.RoundChangeCertificate{
-						RoundChangeMessages: generateMessages(quorum-1, proto.MessageType_ROUND_CHANGE),
+						RoundChangeMessages: generateMessagesWithUniqueSender(quorum-1, proto.MessageType_ROUND_CHANGE),
 					},
 				},
 			},
@@ -2019,7 +2195,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			transport = mockTransport{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		baseView := &proto.View{
 			Height: 0,
@@ -2032,7 +2208,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			Payload: &proto.Message_PreprepareData{
 				PreprepareData: &proto.PrePrepareMessage{
 					Certificate: &proto.RoundChangeCertificate{
-						RoundChangeMessages: generateMessages(quorum, proto.MessageType_ROUND_CHANGE),
+						RoundChangeMessages: generateMessagesWithUniqueSender(quorum, proto.MessageType_ROUND_CHANGE),
 					},
 				},
 			},
@@ -2060,7 +2236,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			transport = mockTransport{}
 		)
 
-		i := NewIBFT(log, backend, transport)
+		i := newTestIBFT(t, log, backend, transport)
 
 		baseView := &proto.View{
 			Height: 0,
@@ -2072,7 +2248,7 @@ func TestIBFT_ValidateProposal(t *testing.T) {
 			Payload: &proto.Message_PreprepareData{
 				PreprepareData: &proto.PrePrepareMessage{
 					Certificate: &proto.RoundChangeCertificate{
-						RoundChangeMessages: generateMessages(quorum, proto.MessageType_ROUND_CHANGE),
+						RoundChangeMessages: generateMessagesWithUniqueSender(quorum, proto.MessageType_ROUND_CHANGE),
 					},
 				},
 			},
@@ -2131,7 +2307,7 @@ func TestIBFT_WatchForFutureRCC(t *testing.T) {
 		}
 	)
 
-	i := NewIBFT(log, backend, transport)
+	i := newTestIBFT(t, log, backend, transport)
 	i.messages = messages
 
 	ctx, cancelFn := context.WithCancel(context.Background())
@@ -2166,18 +2342,18 @@ func TestIBFT_WatchForFutureRCC(t *testing.T) {
 // TestState_String makes sure the string representation
 // of states is correct
 func TestState_String(t *testing.T) {
-	stringMap := map[stateType]string{
-		newRound: "new round",
-		prepare:  "prepare",
-		commit:   "commit",
-		fin:      "fin",
+	stringMap := map[StateType]string{
+		StateNewRound: "new round",
+		StatePrepare:  "prepare",
+		StateCommit:   "commit",
+		StateFin:      "fin",
 	}
 
-	stateTypes := []stateType{
-		newRound,
-		prepare,
-		commit,
-		fin,
+	stateTypes := []StateType{
+		StateNewRound,
+		StatePrepare,
+		StateCommit,
+		StateFin,
 	}
 
 	for _, stateT := range stateTypes {
@@ -2208,7 +2384,7 @@ func TestIBFT_RunSequence_NewProposal(t *testing.T) {
 		transport = mockTransport{}
 	)
 
-	i := NewIBFT(log, backend, transport)
+	i := newTestIBFT(t, log, backend, transport)
 	i.newProposal = make(chan newProposalEvent, 1)
 
 	ev := newProposalEvent{
@@ -2232,7 +2408,11 @@ func TestIBFT_RunSequence_NewProposal(t *testing.T) {
 		<-time.After(1 * time.Second)
 	}()
 
-	i.RunSequence(ctx, height)
+	result, err := i.RunSequence(ctx, height)
+
+	// Make sure the sequence was cancelled
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrSequenceCancelled)
 
 	// Make sure the correct proposal message was accepted
 	assert.Equal(t, ev.proposalMessage, i.state.proposalMessage)
@@ -2245,7 +2425,7 @@ func TestIBFT_RunSequence_NewProposal(t *testing.T) {
 	assert.True(t, i.state.roundStarted)
 
 	// Make sure the state is the prepare state
-	assert.Equal(t, prepare, i.state.name)
+	assert.Equal(t, StatePrepare, i.state.name)
 }
 
 // TestIBFT_RunSequence_FutureRCC verifies that the
@@ -2270,7 +2450,7 @@ func TestIBFT_RunSequence_FutureRCC(t *testing.T) {
 		transport = mockTransport{}
 	)
 
-	i := NewIBFT(log, backend, transport)
+	i := newTestIBFT(t, log, backend, transport)
 	i.roundCertificate = make(chan uint64, 1)
 
 	// Make sure the round event is waiting
@@ -2283,7 +2463,11 @@ func TestIBFT_RunSequence_FutureRCC(t *testing.T) {
 		<-time.After(1 * time.Second)
 	}()
 
-	i.RunSequence(ctx, height)
+	result, err := i.RunSequence(ctx, height)
+
+	// Make sure the sequence was cancelled
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrSequenceCancelled)
 
 	// Make sure the proposal message is not set
 	assert.Nil(t, i.state.proposalMessage)
@@ -2296,7 +2480,7 @@ func TestIBFT_RunSequence_FutureRCC(t *testing.T) {
 	assert.True(t, i.state.roundStarted)
 
 	// Make sure the state is the new round state
-	assert.Equal(t, newRound, i.state.name)
+	assert.Equal(t, StateNewRound, i.state.name)
 }
 
 // TestIBFT_ExtendRoundTimer makes sure the round timeout
@@ -2312,10 +2496,548 @@ func TestIBFT_ExtendRoundTimer(t *testing.T) {
 		transport = mockTransport{}
 	)
 
-	i := NewIBFT(log, backend, transport)
+	i := newTestIBFT(t, log, backend, transport)
 
 	i.ExtendRoundTimeout(additionalTimeout)
 
 	// Make sure the round timeout was extended
 	assert.Equal(t, additionalTimeout, i.additionalTimeout)
 }
+
+// TestIBFT_RunSequence_MaxRounds verifies that the
+// sequence is aborted once the round limit is reached
+func TestIBFT_RunSequence_MaxRounds(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height = uint64(1)
+
+		log       = mockLogger{}
+		backend   = mockBackend{}
+		transport = mockTransport{}
+	)
+
+	i := newTestIBFT(t, log, backend, transport, WithMaxRounds(1))
+	i.roundExpired = make(chan struct{}, 1)
+
+	// Make sure the round 0 timeout is waiting
+	i.roundExpired <- struct{}{}
+
+	result, err := i.RunSequence(context.Background(), height)
+
+	// Make sure the sequence was aborted after round 0
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrMaxRoundsExceeded)
+	assert.Equal(t, uint64(1), i.state.getRound())
+}
+
+// TestIBFT_RunSequence_RoundTimeouts makes sure round timeouts
+// move the sequence through rounds, driven by a manual clock
+func TestIBFT_RunSequence_RoundTimeouts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height = uint64(1)
+		clock  = NewManualClock(time.Now())
+
+		roundChanges = make(chan *proto.Message, 2)
+
+		log     = mockLogger{}
+		backend = mockBackend{
+			buildRoundChangeMessageFn: func(
+				_ []byte,
+				_ *proto.PreparedCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return buildBasicRoundChangeMessage(nil, nil, view, []byte("node"))
+			},
+		}
+		transport = mockTransport{
+			multicastFn: func(message *proto.Message) {
+				if message.Type == proto.MessageType_ROUND_CHANGE {
+					roundChanges <- message
+				}
+			},
+		}
+	)
+
+	i := newTestIBFT(
+		t,
+		log,
+		backend,
+		transport,
+		WithClock(clock),
+		WithMaxRounds(2),
+	)
+
+	var (
+		result *SequenceResult
+		err    error
+
+		done = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+
+		result, err = i.RunSequence(context.Background(), height)
+	}()
+
+	for round := uint64(0); round < 2; round++ {
+		// Wait for the round timer to start
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		clock.Advance(i.timeoutPolicy.Timeout(round))
+
+		// Make sure the node moved to the next round
+		select {
+		case message := <-roundChanges:
+			assert.Equal(t, round+1, message.View.Round)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("round change for round %d not sent", round+1)
+		}
+	}
+
+	<-done
+
+	// Make sure the sequence was aborted once the
+	// round limit was reached, without any real waiting
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrMaxRoundsExceeded)
+	assert.Equal(t, uint64(2), i.state.getRound())
+}
+
+// TestIBFT_StateStore makes sure the consensus state
+// is persisted and restored correctly
+func TestIBFT_StateStore(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height       = uint64(5)
+		round        = uint64(2)
+		proposal     = []byte("proposal")
+		proposalHash = []byte("proposal hash")
+
+		proposalMessage = buildBasicPreprepareMessage(
+			proposal,
+			proposalHash,
+			nil,
+			[]byte("proposer"),
+			&proto.View{Height: height, Round: round},
+		)
+		commitMessage = buildBasicCommitMessage(
+			proposalHash,
+			[]byte("seal"),
+			[]byte("node"),
+			&proto.View{Height: height, Round: round},
+		)
+		latestPC = &proto.PreparedCertificate{
+			ProposalMessage: proposalMessage,
+			PrepareMessages: generateMessagesWithUniqueSender(3, proto.MessageType_PREPARE),
+		}
+	)
+
+	newPersistedState := func() *PersistedState {
+		return &PersistedState{
+			View: &proto.View{
+				Height: height,
+				Round:  round,
+			},
+			ProposalMessage:             proposalMessage,
+			LatestPC:                    latestPC,
+			LatestPreparedProposedBlock: proposal,
+			Messages:                    []*proto.Message{commitMessage},
+			Name:                        StateCommit,
+		}
+	}
+
+	t.Run("state is restored for the persisted height", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			log       = mockLogger{}
+			transport = mockTransport{}
+			backend   = mockBackend{}
+			store     = mockStateStore{
+				loadStateFn: func() (*PersistedState, error) {
+					return newPersistedState(), nil
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport, WithStateStore(store))
+
+		// Make sure the state is restored right away
+		assert.Equal(t, round, i.state.getRound())
+		assert.Equal(t, latestPC, i.state.getLatestPC())
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		cancelFn()
+
+		_, err := i.RunSequence(ctx, height)
+		assert.ErrorIs(t, err, ErrSequenceCancelled)
+
+		// Make sure the sequence continued in the same view, with the same lock
+		assert.Equal(t, height, i.state.getHeight())
+		assert.Equal(t, round, i.state.getRound())
+		assert.Equal(t, latestPC, i.state.getLatestPC())
+		assert.Equal(t, proposal, i.state.getLatestPreparedProposedBlock())
+		assert.Equal(t, proposalMessage, i.state.getProposalMessage())
+
+		// Make sure the outgoing messages are added back
+		assert.Equal(
+			t,
+			[]*proto.Message{commitMessage},
+			i.messages.GetValidMessages(
+				commitMessage.View,
+				proto.MessageType_COMMIT,
+				func(_ *proto.Message) bool {
+					return true
+				},
+			),
+		)
+	})
+
+	t.Run("restored proposer sends the original proposal again", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			multicasted = make(chan *proto.Message, 1)
+
+			log       = mockLogger{}
+			transport = mockTransport{
+				multicastFn: func(message *proto.Message) {
+					select {
+					case multicasted <- message:
+					default:
+					}
+				},
+			}
+			backend = mockBackend{
+				idFn: func() []byte {
+					return []byte("proposer")
+				},
+				isProposerFn: func(from []byte, _, _ uint64) bool {
+					return bytes.Equal(from, []byte("proposer"))
+				},
+				buildPrePrepareMessageFn: func(
+					_ []byte,
+					_ *proto.RoundChangeCertificate,
+					view *proto.View,
+				) *proto.Message {
+					return buildBasicPreprepareMessage(
+						[]byte("new proposal"),
+						[]byte("new proposal hash"),
+						nil,
+						[]byte("proposer"),
+						view,
+					)
+				},
+			}
+			store = mockStateStore{
+				loadStateFn: func() (*PersistedState, error) {
+					persisted := newPersistedState()
+					persisted.Name = StatePrepare
+					persisted.Messages = []*proto.Message{proposalMessage}
+
+					return persisted, nil
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport, WithStateStore(store))
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+
+		go func() {
+			_, _ = i.RunSequence(ctx, height)
+		}()
+
+		select {
+		case message := <-multicasted:
+			// Make sure the original proposal is sent, and kept as the accepted one
+			assert.Equal(t, proposalMessage, message)
+		case <-time.After(5 * time.Second):
+			t.Fatal("original proposal not sent again")
+		}
+
+		cancelFn()
+		i.wg.Wait()
+
+		assert.Equal(t, proposalMessage, i.state.getProposalMessage())
+	})
+
+	t.Run("state is not restored for a different height", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			log       = mockLogger{}
+			transport = mockTransport{}
+			backend   = mockBackend{}
+			store     = mockStateStore{
+				loadStateFn: func() (*PersistedState, error) {
+					return newPersistedState(), nil
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport, WithStateStore(store))
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		cancelFn()
+
+		_, err := i.RunSequence(ctx, height+1)
+		assert.ErrorIs(t, err, ErrSequenceCancelled)
+
+		// Make sure the sequence started from scratch
+		assert.Equal(t, height+1, i.state.getHeight())
+		assert.Equal(t, uint64(0), i.state.getRound())
+		assert.Nil(t, i.state.getLatestPC())
+	})
+
+	t.Run("prepared lock is persisted before the COMMIT message", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			events = make([]string, 0)
+			view   = &proto.View{Height: height, Round: round}
+
+			log       = mockLogger{}
+			transport = mockTransport{
+				multicastFn: func(message *proto.Message) {
+					events = append(events, "multicast "+message.Type.String())
+				},
+			}
+			backend = mockBackend{
+				quorumFn: func(_ uint64) uint64 {
+					return 1
+				},
+				buildCommitMessageFn: func(_ []byte, _ *proto.View) *proto.Message {
+					return commitMessage
+				},
+			}
+			store = mockStateStore{
+				saveStateFn: func(state *PersistedState) error {
+					if state.LatestPC != nil {
+						events = append(events, "lock")
+					}
+
+					return nil
+				},
+				saveMessageFn: func(message *proto.Message) error {
+					events = append(events, "persist "+message.Type.String())
+
+					return nil
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport, WithStateStore(store))
+		i.messages = mockMessages{}
+		i.state.proposalMessage = proposalMessage
+
+		prepared, err := i.handlePrepare(view, 1)
+		require.NoError(t, err)
+		assert.True(t, prepared)
+
+		assert.Equal(
+			t,
+			[]string{"lock", "persist COMMIT", "multicast COMMIT"},
+			events,
+		)
+	})
+
+	t.Run("COMMIT message is not multicast if the prepared lock can't be persisted", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			multicasted = false
+			view        = &proto.View{Height: height, Round: round}
+			storeErr    = errors.New("disk full")
+
+			log       = mockLogger{}
+			transport = mockTransport{
+				multicastFn: func(_ *proto.Message) {
+					multicasted = true
+				},
+			}
+			backend = mockBackend{
+				quorumFn: func(_ uint64) uint64 {
+					return 1
+				},
+				buildCommitMessageFn: func(_ []byte, _ *proto.View) *proto.Message {
+					return commitMessage
+				},
+			}
+			store = mockStateStore{
+				saveStateFn: func(_ *PersistedState) error {
+					return storeErr
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport, WithStateStore(store))
+		i.messages = mockMessages{}
+		i.state.proposalMessage = proposalMessage
+
+		prepared, err := i.handlePrepare(view, 1)
+
+		assert.ErrorIs(t, err, storeErr)
+		assert.False(t, prepared)
+		assert.False(t, multicasted)
+	})
+
+	t.Run("sequence is aborted if the state can't be persisted", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			storeErr = errors.New("disk full")
+
+			log       = mockLogger{}
+			transport = mockTransport{}
+			backend   = mockBackend{}
+			store     = mockStateStore{
+				saveStateFn: func(_ *PersistedState) error {
+					return storeErr
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport, WithStateStore(store))
+
+		_, err := i.RunSequence(context.Background(), height)
+
+		assert.ErrorIs(t, err, storeErr)
+	})
+
+	t.Run("proposal is not multicast if it can't be persisted", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			multicasted = false
+			saves       = 0
+			storeErr    = errors.New("disk full")
+
+			log       = mockLogger{}
+			transport = mockTransport{
+				multicastFn: func(_ *proto.Message) {
+					multicasted = true
+				},
+			}
+			backend = mockBackend{
+				isProposerFn: func(_ []byte, _, _ uint64) bool {
+					return true
+				},
+				buildPrePrepareMessageFn: func(
+					_ []byte,
+					_ *proto.RoundChangeCertificate,
+					view *proto.View,
+				) *proto.Message {
+					return buildBasicPreprepareMessage(proposal, proposalHash, nil, []byte("proposer"), view)
+				},
+			}
+			store = mockStateStore{
+				saveStateFn: func(_ *PersistedState) error {
+					// Only the sequence start is persisted
+					saves++
+					if saves > 1 {
+						return storeErr
+					}
+
+					return nil
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport, WithStateStore(store))
+
+		_, err := i.RunSequence(context.Background(), height)
+
+		assert.ErrorIs(t, err, storeErr)
+		assert.False(t, multicasted)
+	})
+
+	t.Run("messages that can't be persisted are not multicast", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			multicasted = false
+
+			log       = mockLogger{}
+			transport = mockTransport{
+				multicastFn: func(_ *proto.Message) {
+					multicasted = true
+				},
+			}
+			backend = mockBackend{}
+			store   = mockStateStore{
+				saveMessageFn: func(_ *proto.Message) error {
+					return errors.New("disk full")
+				},
+			}
+		)
+
+		i := newTestIBFT(t, log, backend, transport, WithStateStore(store))
+
+		i.sendRoundChangeMessage(height, round)
+
+		assert.False(t, multicasted)
+	})
+}
+
+// TestIBFT_SigningGuard makes sure conflicting outgoing
+// messages are not multicast, and are reported
+func TestIBFT_SigningGuard(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view          = &proto.View{Height: 1, Round: 0}
+		multicasted   = make([]*proto.Message, 0)
+		reportedErr   error
+		reportedMsg   *proto.Message
+		proposalHashA = []byte("proposal hash A")
+		proposalHashB = []byte("proposal hash B")
+
+		log       = mockLogger{}
+		transport = mockTransport{
+			multicastFn: func(message *proto.Message) {
+				multicasted = append(multicasted, message)
+			},
+		}
+		backend = mockBackend{
+			buildPrepareMessageFn: func(hash []byte, view *proto.View) *proto.Message {
+				return buildBasicPrepareMessage(hash, []byte("node"), view)
+			},
+		}
+	)
+
+	i := newTestIBFT(
+		t,
+		log,
+		backend,
+		transport,
+		WithSigningErrorHandler(func(message *proto.Message, err error) {
+			reportedMsg = message
+			reportedErr = err
+		}),
+	)
+
+	i.state.proposalMessage = buildBasicPreprepareMessage(nil, proposalHashA, nil, nil, view)
+	i.sendPrepareMessage(view)
+
+	// Accept a different proposal for the same view
+	i.state.proposalMessage = buildBasicPreprepareMessage(nil, proposalHashB, nil, nil, view)
+	i.sendPrepareMessage(view)
+
+	// Make sure only the first PREPARE was multicast
+	if assert.Len(t, multicasted, 1) {
+		assert.True(t, prepareHashMatches(proposalHashA, multicasted[0]))
+	}
+
+	// Make sure the conflict was reported
+	assert.ErrorIs(t, reportedErr, ErrConflictingMessage)
+	assert.True(t, prepareHashMatches(proposalHashB, reportedMsg))
+}
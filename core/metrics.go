@@ -0,0 +1,38 @@
+package core
+
+import (
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// Metrics defines the sink for consensus metrics
+type Metrics interface {
+	// SetView notes the view the state machine is currently in
+	SetView(view *proto.View)
+
+	// ObserveRoundDuration observes the duration of a single round
+	ObserveRoundDuration(duration time.Duration)
+
+	// IncRoundChange notes a round change for the specified reason
+	IncRoundChange(reason RoundChangeReason)
+
+	// ObserveStateDuration observes the time spent in the specified state
+	ObserveStateDuration(name StateType, duration time.Duration)
+
+	// ObserveSequence observes a finalized sequence
+	ObserveSequence(result *SequenceResult)
+
+	// IncDroppedMessage notes an incoming message rejected for the specified reason
+	IncDroppedMessage(reason DropReason)
+}
+
+// NoopMetrics is the Metrics implementation that discards all metrics
+type NoopMetrics struct{}
+
+func (NoopMetrics) SetView(*proto.View)                           {}
+func (NoopMetrics) ObserveRoundDuration(time.Duration)            {}
+func (NoopMetrics) IncRoundChange(RoundChangeReason)              {}
+func (NoopMetrics) ObserveStateDuration(StateType, time.Duration) {}
+func (NoopMetrics) ObserveSequence(*SequenceResult)               {}
+func (NoopMetrics) IncDroppedMessage(DropReason)                  {}
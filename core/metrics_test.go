@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics is the metrics sink that records all metrics
+type recordingMetrics struct {
+	views          []*proto.View
+	roundDurations []time.Duration
+	roundChanges   []RoundChangeReason
+	states         []StateType
+	stateDurations []time.Duration
+	sequences      []*SequenceResult
+	dropped        []DropReason
+
+	sync.Mutex
+}
+
+func (r *recordingMetrics) SetView(view *proto.View) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.views = append(r.views, view)
+}
+
+func (r *recordingMetrics) ObserveRoundDuration(duration time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.roundDurations = append(r.roundDurations, duration)
+}
+
+func (r *recordingMetrics) IncRoundChange(reason RoundChangeReason) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.roundChanges = append(r.roundChanges, reason)
+}
+
+func (r *recordingMetrics) ObserveStateDuration(name StateType, duration time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.states = append(r.states, name)
+	r.stateDurations = append(r.stateDurations, duration)
+}
+
+func (r *recordingMetrics) ObserveSequence(result *SequenceResult) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.sequences = append(r.sequences, result)
+}
+
+func (r *recordingMetrics) IncDroppedMessage(reason DropReason) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.dropped = append(r.dropped, reason)
+}
+
+// TestIBFT_Metrics makes sure the consensus
+// metrics are fed from the sequence
+func TestIBFT_Metrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sequence is finalized", func(t *testing.T) {
+		t.Parallel()
+
+		metrics := &recordingMetrics{}
+
+		node := newSingleNodeIBFT(
+			t,
+			newSingleNodeBackend(&mockChain{}),
+			WithMetrics(metrics),
+		)
+
+		result, err := node.RunSequence(context.Background(), 1)
+		require.NoError(t, err)
+
+		assert.Equal(t, []*proto.View{{Height: 1, Round: 0}}, metrics.views)
+		assert.Len(t, metrics.roundDurations, 1)
+		assert.Empty(t, metrics.roundChanges)
+		assert.Equal(
+			t,
+			[]StateType{StateNewRound, StatePrepare, StateCommit, StateFin},
+			metrics.states,
+		)
+		assert.Equal(t, []*SequenceResult{result}, metrics.sequences)
+	})
+
+	t.Run("round timeout is recorded", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			metrics = &recordingMetrics{}
+			clock   = NewManualClock(time.Now())
+			errCh   = make(chan error, 1)
+		)
+
+		node := newSingleNodeIBFT(
+			t,
+			mockBackend{
+				buildRoundChangeMessageFn: func(
+					_ []byte,
+					_ *proto.PreparedCertificate,
+					view *proto.View,
+				) *proto.Message {
+					return buildBasicRoundChangeMessage(nil, nil, view, []byte("node 0"))
+				},
+			},
+			WithMetrics(metrics),
+			WithClock(clock),
+			WithMaxRounds(1),
+		)
+
+		go func() {
+			_, err := node.RunSequence(context.Background(), 1)
+
+			errCh <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		clock.Advance(round0Timeout)
+
+		assert.ErrorIs(t, <-errCh, ErrMaxRoundsExceeded)
+
+		metrics.Lock()
+		defer metrics.Unlock()
+
+		// Make sure the round and the time spent
+		// in the new round state were observed
+		assert.Equal(t, []time.Duration{round0Timeout}, metrics.roundDurations)
+		assert.Equal(t, []RoundChangeReason{RoundChangeTimeout}, metrics.roundChanges)
+		assert.Equal(t, []StateType{StateNewRound}, metrics.states)
+		assert.Equal(t, []time.Duration{round0Timeout}, metrics.stateDurations)
+		assert.Empty(t, metrics.sequences)
+	})
+}
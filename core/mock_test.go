@@ -1,14 +1,19 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"testing"
 	"time"
 
 	"github.com/madz-lab/go-ibft/messages"
 	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
 )
 
 // Define delegation methods
@@ -240,6 +245,8 @@ type mockMessages struct {
 		isValid func(message *proto.Message) bool,
 	) []*proto.Message
 	getMostRoundChangeMessagesFn func(uint64, uint64) []*proto.Message
+	getRoundChangeMessagesFn     func(uint64, uint64) []*proto.Message
+	numMessagesFn                func(*proto.View, proto.MessageType) int
 
 	subscribeFn   func(details messages.SubscriptionDetails) *messages.Subscription
 	unsubscribeFn func(id messages.SubscriptionID)
@@ -291,6 +298,70 @@ func (m mockMessages) GetMostRoundChangeMessages(round, height uint64) []*proto.
 	return nil
 }
 
+func (m mockMessages) GetRoundChangeMessages(round, height uint64) []*proto.Message {
+	if m.getRoundChangeMessagesFn != nil {
+		return m.getRoundChangeMessagesFn(round, height)
+	}
+
+	return nil
+}
+
+func (m mockMessages) NumMessages(view *proto.View, messageType proto.MessageType) int {
+	if m.numMessagesFn != nil {
+		return m.numMessagesFn(view, messageType)
+	}
+
+	return 0
+}
+
+// mockStateStore is the mock state store structure that is configurable
+type mockStateStore struct {
+	saveStateFn   func(*PersistedState) error
+	saveMessageFn func(*proto.Message) error
+	loadStateFn   func() (*PersistedState, error)
+}
+
+func (m mockStateStore) SaveState(state *PersistedState) error {
+	if m.saveStateFn != nil {
+		return m.saveStateFn(state)
+	}
+
+	return nil
+}
+
+func (m mockStateStore) SaveMessage(message *proto.Message) error {
+	if m.saveMessageFn != nil {
+		return m.saveMessageFn(message)
+	}
+
+	return nil
+}
+
+func (m mockStateStore) LoadState() (*PersistedState, error) {
+	if m.loadStateFn != nil {
+		return m.loadStateFn()
+	}
+
+	return nil, ErrStateNotFound
+}
+
+// newTestIBFT creates a new IBFT instance,
+// and makes sure the configuration is valid
+func newTestIBFT(
+	t *testing.T,
+	log Logger,
+	backend Backend,
+	transport Transport,
+	opts ...Option,
+) *IBFT {
+	t.Helper()
+
+	i, err := NewIBFT(log, backend, transport, opts...)
+	require.NoError(t, err)
+
+	return i
+}
+
 type (
 	backendConfigCallback   func(*mockBackend)
 	loggerConfigCallback    func(*mockLogger)
@@ -301,11 +372,14 @@ type (
 //
 //nolint:unparam // Logger callback currently not used
 func newMockCluster(
+	t rapid.TB,
 	numNodes uint64,
 	backendCallbackMap map[int]backendConfigCallback,
 	loggerCallbackMap map[int]loggerConfigCallback,
 	transportCallbackMap map[int]transportConfigCallback,
 ) *mockCluster {
+	t.Helper()
+
 	if numNodes < 1 {
 		return nil
 	}
@@ -340,7 +414,10 @@ func newMockCluster(
 		}
 
 		// Create a new instance of the IBFT node
-		nodes[index] = NewIBFT(logger, backend, transport)
+		node, err := NewIBFT(logger, backend, transport)
+		require.NoError(t, err)
+
+		nodes[index] = node
 
 		// Instantiate context for the nodes
 		ctx, cancelFn := context.WithCancel(context.Background())
@@ -351,8 +428,9 @@ func newMockCluster(
 	}
 
 	return &mockCluster{
-		nodes: nodes,
-		ctxs:  nodeCtxs,
+		nodes:   nodes,
+		ctxs:    nodeCtxs,
+		results: make([]*SequenceResult, numNodes),
 	}
 }
 
@@ -387,8 +465,9 @@ func (wg *mockNodeWg) resetDone() {
 
 // mockCluster represents a mock IBFT cluster
 type mockCluster struct {
-	nodes []*IBFT           // references to the nodes in the cluster
-	ctxs  []mockNodeContext // context handlers for the nodes in the cluster
+	nodes   []*IBFT           // references to the nodes in the cluster
+	ctxs    []mockNodeContext // context handlers for the nodes in the cluster
+	results []*SequenceResult // latest sequence results of the nodes in the cluster
 
 	wg mockNodeWg
 }
@@ -401,6 +480,7 @@ func (m *mockCluster) runSequence(height uint64) {
 
 		go func(
 			ctx context.Context,
+			nodeIndex int,
 			node *IBFT,
 			height uint64,
 		) {
@@ -409,8 +489,8 @@ func (m *mockCluster) runSequence(height uint64) {
 			}()
 
 			// Start the main run loop for the node
-			node.RunSequence(ctx, height)
-		}(m.ctxs[nodeIndex].ctx, node, height)
+			m.results[nodeIndex], _ = node.RunSequence(ctx, height)
+		}(m.ctxs[nodeIndex].ctx, nodeIndex, node, height)
 	}
 }
 
@@ -475,9 +555,204 @@ func (m *mockCluster) areAllNodesOnRound(round uint64) bool {
 	return true
 }
 
+// setClock sets the time source for all nodes
+func (m *mockCluster) setClock(clock Clock) {
+	for _, node := range m.nodes {
+		node.clock = clock
+	}
+}
+
 // setBaseTimeout sets the base timeout for rounds
 func (m *mockCluster) setBaseTimeout(timeout time.Duration) {
 	for _, node := range m.nodes {
-		node.baseRoundTimeout = timeout
+		node.timeoutPolicy = ExponentialTimeout{Base: timeout}
+	}
+}
+
+// setRetransmission enables retransmission of outgoing messages for all nodes
+func (m *mockCluster) setRetransmission(interval, maxInterval time.Duration) {
+	for _, node := range m.nodes {
+		node.retransmitInterval = interval
+		node.maxRetransmitInterval = maxInterval
+	}
+}
+
+// setVotingPowerProvider sets the voting power provider for all nodes
+func (m *mockCluster) setVotingPowerProvider(provider VotingPowerProvider) {
+	for _, node := range m.nodes {
+		node.votingPowerProvider = provider
+	}
+}
+
+// clusterBackendCallback returns the backend setup for a cluster node
+// that goes through the consensus states with node 0 as the only proposer
+func clusterBackendCallback(
+	nodes [][]byte,
+	nodeIndex int,
+	proposal,
+	proposalHash []byte,
+	quorum uint64,
+) backendConfigCallback {
+	return func(backend *mockBackend) {
+		backend.quorumFn = func(_ uint64) uint64 {
+			return quorum
+		}
+
+		backend.idFn = func() []byte {
+			return nodes[nodeIndex]
+		}
+
+		backend.isProposerFn = func(from []byte, _ uint64, _ uint64) bool {
+			return bytes.Equal(from, nodes[0])
+		}
+
+		backend.isValidBlockFn = func(newProposal []byte) bool {
+			return bytes.Equal(newProposal, proposal)
+		}
+
+		backend.isValidProposalHashFn = func(p []byte, ph []byte) bool {
+			return bytes.Equal(p, proposal) && bytes.Equal(ph, proposalHash)
+		}
+
+		backend.buildProposalFn = func(_ uint64) []byte {
+			return proposal
+		}
+
+		backend.buildPrePrepareMessageFn = func(
+			proposal []byte,
+			certificate *proto.RoundChangeCertificate,
+			view *proto.View,
+		) *proto.Message {
+			return buildBasicPreprepareMessage(proposal, proposalHash, certificate, nodes[nodeIndex], view)
+		}
+
+		backend.buildPrepareMessageFn = func(_ []byte, view *proto.View) *proto.Message {
+			return buildBasicPrepareMessage(proposalHash, nodes[nodeIndex], view)
+		}
+
+		backend.buildCommitMessageFn = func(_ []byte, view *proto.View) *proto.Message {
+			return buildBasicCommitMessage(proposalHash, []byte("seal"), nodes[nodeIndex], view)
+		}
+
+		backend.buildRoundChangeMessageFn = func(
+			proposal []byte,
+			certificate *proto.PreparedCertificate,
+			view *proto.View,
+		) *proto.Message {
+			return buildBasicRoundChangeMessage(proposal, certificate, view, nodes[nodeIndex])
+		}
+
+		backend.insertBlockFn = func(_ []byte, _ []*messages.CommittedSeal) {}
+	}
+}
+
+// errUnknownSigner is returned by the mock seal aggregator
+// for seals of signers outside the validator set
+var errUnknownSigner = errors.New("unknown signer")
+
+// mockSealAggregator is the deterministic seal aggregator.
+// The bitmap marks the indexes of the signers in the validator set, and the
+// signature is the proposal hash followed by the seal signatures, in validator order
+type mockSealAggregator struct {
+	validators [][]byte
+}
+
+func (m mockSealAggregator) AggregateSeals(
+	_ uint64,
+	proposalHash []byte,
+	committedSeals []*messages.CommittedSeal,
+) (*messages.AggregatedSeal, error) {
+	var (
+		bitmap     = make([]byte, (len(m.validators)+7)/8)
+		signatures = make([][]byte, len(m.validators))
+	)
+
+	for _, seal := range committedSeals {
+		index := m.indexOf(seal.Signer)
+		if index < 0 {
+			return nil, fmt.Errorf("%w: %s", errUnknownSigner, seal.Signer)
+		}
+
+		bitmap[index/8] |= 1 << (index % 8)
+		signatures[index] = seal.Signature
+	}
+
+	signature := append([]byte(nil), proposalHash...)
+	for _, sig := range signatures {
+		signature = append(signature, sig...)
+	}
+
+	return &messages.AggregatedSeal{
+		Bitmap:    bitmap,
+		Signature: signature,
+	}, nil
+}
+
+func (m mockSealAggregator) IsValidAggregatedSeal(
+	_ uint64,
+	proposalHash []byte,
+	seal *messages.AggregatedSeal,
+) bool {
+	return len(seal.Bitmap) == (len(m.validators)+7)/8 && bytes.HasPrefix(seal.Signature, proposalHash)
+}
+
+// indexOf returns the index of the validator, or -1 if it's not in the set
+func (m mockSealAggregator) indexOf(id []byte) int {
+	for index, validator := range m.validators {
+		if bytes.Equal(validator, id) {
+			return index
+		}
+	}
+
+	return -1
+}
+
+// mockSealAggregatorBackend is the mock backend
+// that also implements the SealAggregator extension
+type mockSealAggregatorBackend struct {
+	mockBackend
+	mockSealAggregator
+
+	isValidAggregatedSealFn func(uint64, []byte, *messages.AggregatedSeal) bool
+	insertAggregatedBlockFn func([]byte, *messages.AggregatedSeal)
+}
+
+func (m mockSealAggregatorBackend) IsValidAggregatedSeal(
+	height uint64,
+	proposalHash []byte,
+	seal *messages.AggregatedSeal,
+) bool {
+	if m.isValidAggregatedSealFn != nil {
+		return m.isValidAggregatedSealFn(height, proposalHash, seal)
+	}
+
+	return m.mockSealAggregator.IsValidAggregatedSeal(height, proposalHash, seal)
+}
+
+func (m mockSealAggregatorBackend) InsertAggregatedBlock(
+	_ context.Context,
+	proposal []byte,
+	seal *messages.AggregatedSeal,
+) error {
+	if m.insertAggregatedBlockFn != nil {
+		m.insertAggregatedBlockFn(proposal, seal)
 	}
+
+	return nil
+}
+
+// mockBatchVerifierBackend is the mock backend
+// that also implements the BatchVerifier extension
+type mockBatchVerifierBackend struct {
+	mockBackend
+
+	areValidSendersFn func([]*proto.Message) []bool
+}
+
+func (m mockBatchVerifierBackend) AreValidSenders(messages []*proto.Message) []bool {
+	if m.areValidSendersFn != nil {
+		return m.areValidSendersFn(messages)
+	}
+
+	return make([]bool, len(messages))
 }
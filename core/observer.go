@@ -0,0 +1,114 @@
+package core
+
+import (
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// Observer is notified of consensus lifecycle events.
+// The callbacks are invoked synchronously from the consensus workers,
+// so they should return quickly and must not call back into the IBFT instance.
+// The passed in views are copies, and can be retained
+type Observer interface {
+	// OnSequenceStart is called when the sequence for the height starts
+	OnSequenceStart(height uint64)
+
+	// OnRoundStart is called when a round of the sequence starts
+	OnRoundStart(view *proto.View)
+
+	// OnStateChange is called when the state machine
+	// moves from one state to another within the view
+	OnStateChange(view *proto.View, from, to StateType)
+
+	// OnProposalAccepted is called when a proposal is accepted for the view
+	OnProposalAccepted(view *proto.View, proposalHash []byte)
+
+	// OnQuorumReached is called when a quorum of valid
+	// messages of the specified type is reached for the view
+	OnQuorumReached(view *proto.View, messageType proto.MessageType)
+
+	// OnRoundChange is called when the sequence moves
+	// to the new view for the specified reason
+	OnRoundChange(view *proto.View, reason RoundChangeReason)
+
+	// OnFinalized is called when the sequence finalizes the height
+	OnFinalized(result *SequenceResult)
+}
+
+// NoopObserver is the Observer that ignores all events.
+// It can be embedded to implement only a subset of the callbacks
+type NoopObserver struct{}
+
+func (NoopObserver) OnSequenceStart(uint64)                          {}
+func (NoopObserver) OnRoundStart(*proto.View)                        {}
+func (NoopObserver) OnStateChange(*proto.View, StateType, StateType) {}
+func (NoopObserver) OnProposalAccepted(*proto.View, []byte)          {}
+func (NoopObserver) OnQuorumReached(*proto.View, proto.MessageType)  {}
+func (NoopObserver) OnRoundChange(*proto.View, RoundChangeReason)    {}
+func (NoopObserver) OnFinalized(*SequenceResult)                     {}
+
+// multiObserver is the Observer that relays
+// events to multiple observers, in order
+type multiObserver []Observer
+
+// newObserver combines the passed in observers into a single Observer
+func newObserver(observers []Observer) Observer {
+	switch len(observers) {
+	case 0:
+		return NoopObserver{}
+	case 1:
+		return observers[0]
+	default:
+		return multiObserver(observers)
+	}
+}
+
+func (m multiObserver) OnSequenceStart(height uint64) {
+	for _, observer := range m {
+		observer.OnSequenceStart(height)
+	}
+}
+
+func (m multiObserver) OnRoundStart(view *proto.View) {
+	for _, observer := range m {
+		observer.OnRoundStart(copyView(view))
+	}
+}
+
+func (m multiObserver) OnStateChange(view *proto.View, from, to StateType) {
+	for _, observer := range m {
+		observer.OnStateChange(copyView(view), from, to)
+	}
+}
+
+func (m multiObserver) OnProposalAccepted(view *proto.View, proposalHash []byte) {
+	for _, observer := range m {
+		observer.OnProposalAccepted(copyView(view), proposalHash)
+	}
+}
+
+func (m multiObserver) OnQuorumReached(view *proto.View, messageType proto.MessageType) {
+	for _, observer := range m {
+		observer.OnQuorumReached(copyView(view), messageType)
+	}
+}
+
+func (m multiObserver) OnRoundChange(view *proto.View, reason RoundChangeReason) {
+	for _, observer := range m {
+		observer.OnRoundChange(copyView(view), reason)
+	}
+}
+
+func (m multiObserver) OnFinalized(result *SequenceResult) {
+	for _, observer := range m {
+		observer.OnFinalized(result)
+	}
+}
+
+// copyView returns a copy of the view, so
+// observers can't modify each other's views
+func copyView(view *proto.View) *proto.View {
+	return &proto.View{
+		Height: view.Height,
+		Round:  view.Round,
+	}
+}
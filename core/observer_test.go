@@ -0,0 +1,195 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver is the observer that
+// records all events in a readable form
+type recordingObserver struct {
+	events []string
+
+	sync.Mutex
+}
+
+func (r *recordingObserver) record(format string, args ...interface{}) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingObserver) getEvents() []string {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]string(nil), r.events...)
+}
+
+func (r *recordingObserver) OnSequenceStart(height uint64) {
+	r.record("sequence start %d", height)
+}
+
+func (r *recordingObserver) OnRoundStart(view *proto.View) {
+	r.record("round start %d/%d", view.Height, view.Round)
+}
+
+func (r *recordingObserver) OnStateChange(view *proto.View, from, to StateType) {
+	r.record("state change %d/%d %s -> %s", view.Height, view.Round, from, to)
+}
+
+func (r *recordingObserver) OnProposalAccepted(view *proto.View, proposalHash []byte) {
+	r.record("proposal accepted %d/%d %s", view.Height, view.Round, proposalHash)
+}
+
+func (r *recordingObserver) OnQuorumReached(view *proto.View, messageType proto.MessageType) {
+	r.record("quorum reached %d/%d %s", view.Height, view.Round, messageType)
+}
+
+func (r *recordingObserver) OnRoundChange(view *proto.View, reason RoundChangeReason) {
+	r.record("round change %d/%d %s", view.Height, view.Round, reason)
+}
+
+func (r *recordingObserver) OnFinalized(result *SequenceResult) {
+	r.record("finalized %d/%d", result.Height, result.Round)
+}
+
+// TestIBFT_Observer makes sure the observer is
+// notified of lifecycle events in order
+func TestIBFT_Observer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sequence is finalized", func(t *testing.T) {
+		t.Parallel()
+
+		observer := &recordingObserver{}
+
+		node := newSingleNodeIBFT(
+			t,
+			newSingleNodeBackend(&mockChain{}),
+			WithObserver(observer),
+		)
+
+		_, err := node.RunSequence(context.Background(), 1)
+		require.NoError(t, err)
+
+		assert.Equal(
+			t,
+			[]string{
+				"sequence start 1",
+				"round start 1/0",
+				"proposal accepted 1/0 proposal hash",
+				"state change 1/0 new round -> prepare",
+				"quorum reached 1/0 PREPARE",
+				"state change 1/0 prepare -> commit",
+				"quorum reached 1/0 COMMIT",
+				"state change 1/0 commit -> fin",
+				"finalized 1/0",
+			},
+			observer.getEvents(),
+		)
+	})
+
+	t.Run("round timeout is observed", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			observer = &recordingObserver{}
+			clock    = NewManualClock(time.Now())
+		)
+
+		node := newSingleNodeIBFT(
+			t,
+			mockBackend{
+				buildRoundChangeMessageFn: func(
+					_ []byte,
+					_ *proto.PreparedCertificate,
+					view *proto.View,
+				) *proto.Message {
+					return buildBasicRoundChangeMessage(nil, nil, view, []byte("node 0"))
+				},
+			},
+			WithObserver(observer),
+			WithClock(clock),
+			WithMaxRounds(1),
+		)
+
+		errCh := make(chan error, 1)
+
+		go func() {
+			_, err := node.RunSequence(context.Background(), 1)
+
+			errCh <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		clock.Advance(round0Timeout)
+
+		assert.ErrorIs(t, <-errCh, ErrMaxRoundsExceeded)
+		assert.Equal(
+			t,
+			[]string{
+				"sequence start 1",
+				"round start 1/0",
+				"round change 1/1 round timeout",
+			},
+			observer.getEvents(),
+		)
+	})
+
+	t.Run("multiple observers are notified", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			first  = &recordingObserver{}
+			second = &recordingObserver{}
+		)
+
+		node := newSingleNodeIBFT(
+			t,
+			newSingleNodeBackend(&mockChain{}),
+			WithObserver(first),
+			WithObserver(second),
+		)
+
+		_, err := node.RunSequence(context.Background(), 1)
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, first.getEvents())
+		assert.Equal(t, first.getEvents(), second.getEvents())
+	})
+}
+
+// TestNewObserver makes sure observers
+// are combined correctly
+func TestNewObserver(t *testing.T) {
+	t.Parallel()
+
+	var (
+		first  = &recordingObserver{}
+		second = &recordingObserver{}
+	)
+
+	assert.Equal(t, NoopObserver{}, newObserver(nil))
+	assert.Equal(t, first, newObserver([]Observer{first}))
+
+	observer := newObserver([]Observer{first, second})
+
+	view := &proto.View{Height: 1, Round: 2}
+	observer.OnRoundStart(view)
+
+	// Make sure both observers are notified, in order
+	assert.Equal(t, []string{"round start 1/2"}, first.getEvents())
+	assert.Equal(t, []string{"round start 1/2"}, second.getEvents())
+}
@@ -166,6 +166,7 @@ func TestProperty_AllHonestNodes(t *testing.T) {
 
 		// Create the mock cluster
 		cluster := newMockCluster(
+			t,
 			numNodes,
 			backendCallbackMap,
 			nil,
@@ -359,6 +360,7 @@ func TestProperty_MajorityHonestNodes(t *testing.T) {
 
 		// Create the mock cluster
 		cluster := newMockCluster(
+			t,
 			numNodes,
 			backendCallbackMap,
 			nil,
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// retransmitter keeps track of the latest message
+// this node sent out, for retransmission
+type retransmitter struct {
+	// message is the latest outgoing message
+	message *proto.Message
+
+	// updated is notified each time a new message is sent out
+	updated chan struct{}
+
+	mux sync.Mutex
+}
+
+// newRetransmitter creates a new retransmitter with no outgoing message
+func newRetransmitter() *retransmitter {
+	return &retransmitter{
+		updated: make(chan struct{}, 1),
+	}
+}
+
+// setMessage notes the latest outgoing message
+func (r *retransmitter) setMessage(message *proto.Message) {
+	r.mux.Lock()
+	r.message = message
+	r.mux.Unlock()
+
+	select {
+	case r.updated <- struct{}{}:
+	default:
+		// An update is already pending
+	}
+}
+
+// getMessage returns the latest outgoing message, if any
+func (r *retransmitter) getMessage() *proto.Message {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.message
+}
+
+// retransmitMessages periodically re-multicasts the latest outgoing message
+// for the current view, as long as the state doesn't advance.
+// The interval between retransmissions doubles with each attempt, up to the
+// configured maximum, and is reset whenever a new message is sent out
+func (i *IBFT) retransmitMessages(ctx context.Context) {
+	var (
+		backoff = ExponentialTimeout{
+			Base: i.retransmitInterval,
+			Max:  i.maxRetransmitInterval,
+		}
+		attempt uint64
+		timer   = i.clock.NewTimer(backoff.Timeout(attempt))
+	)
+
+	defer func() {
+		timer.Stop()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-i.retransmit.updated:
+			// The state advanced, start over
+			attempt = 0
+		case <-timer.C():
+			if message := i.retransmit.getMessage(); i.isRetransmittable(message) {
+				i.log.Debug("retransmitting message", "type", message.Type, "round", message.View.Round)
+
+				i.transport.Multicast(message)
+			}
+
+			attempt++
+		}
+
+		timer.Stop()
+		timer = i.clock.NewTimer(backoff.Timeout(attempt))
+	}
+}
+
+// isRetransmittable checks if the outgoing message is still
+// relevant, i.e. it belongs to the current view and the height
+// is not finalized yet
+func (i *IBFT) isRetransmittable(message *proto.Message) bool {
+	if message == nil || i.state.getStateName() == StateFin {
+		return false
+	}
+
+	view := i.state.getView()
+
+	return message.View.Height == view.Height && message.View.Round == view.Round
+}
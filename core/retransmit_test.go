@@ -0,0 +1,243 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lossyTransport is the in-memory transport that drops the first
+// multicast of messages matching the drop function
+type lossyTransport struct {
+	dropFn  func(message *proto.Message) bool
+	relayFn func(message *proto.Message)
+
+	dropped map[string]struct{}
+
+	sync.Mutex
+}
+
+// newLossyTransport creates a new lossy transport
+func newLossyTransport(dropFn func(message *proto.Message) bool) *lossyTransport {
+	return &lossyTransport{
+		dropFn:  dropFn,
+		dropped: make(map[string]struct{}),
+	}
+}
+
+// Multicast relays the message, unless it is the first one to be dropped
+func (l *lossyTransport) Multicast(message *proto.Message) {
+	if l.drop(message) {
+		return
+	}
+
+	l.relayFn(message)
+}
+
+// drop checks if the message should be dropped, and notes it
+func (l *lossyTransport) drop(message *proto.Message) bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if !l.dropFn(message) {
+		return false
+	}
+
+	key := string(message.From) + message.Type.String()
+	if _, ok := l.dropped[key]; ok {
+		return false
+	}
+
+	l.dropped[key] = struct{}{}
+
+	return true
+}
+
+// countingTransport is the transport that counts the multicast messages
+type countingTransport struct {
+	messages []*proto.Message
+
+	sync.Mutex
+}
+
+func (c *countingTransport) Multicast(message *proto.Message) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.messages = append(c.messages, message)
+}
+
+func (c *countingTransport) count() int {
+	c.Lock()
+	defer c.Unlock()
+
+	return len(c.messages)
+}
+
+// TestIBFT_RetransmitMessages makes sure the latest outgoing message
+// is retransmitted with backoff, as long as the state doesn't advance
+func TestIBFT_RetransmitMessages(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view      = &proto.View{Height: 1, Round: 0}
+		interval  = time.Second
+		clock     = NewManualClock(time.Now())
+		transport = &countingTransport{}
+		done      = make(chan struct{})
+	)
+
+	i := newTestIBFT(
+		t,
+		mockLogger{},
+		mockBackend{},
+		transport,
+		WithClock(clock),
+		WithRetransmission(interval, 4*interval),
+	)
+
+	i.state.setView(view)
+	i.state.changeState(StatePrepare)
+
+	// Send out a message before the worker starts
+	i.multicast(buildBasicPrepareMessage([]byte("proposal hash"), []byte("node 0"), view))
+	<-i.retransmit.updated
+
+	assert.Equal(t, 1, transport.count())
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	go func() {
+		defer close(done)
+
+		i.retransmitMessages(ctx)
+	}()
+
+	awaitTimer := func() {
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	awaitTimer()
+
+	// Make sure the intervals double, up to the cap
+	for _, elapsed := range []time.Duration{interval, 2 * interval, 4 * interval, 4 * interval} {
+		clock.Advance(elapsed - time.Nanosecond)
+		awaitTimer()
+
+		count := transport.count()
+
+		clock.Advance(time.Nanosecond)
+		awaitTimer()
+
+		require.Eventually(t, func() bool {
+			return transport.count() == count+1
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// Make sure stale messages are not retransmitted once the round changes
+	i.state.setView(&proto.View{Height: 1, Round: 1})
+
+	count := transport.count()
+
+	clock.Advance(4 * interval)
+	awaitTimer()
+
+	assert.Equal(t, count, transport.count())
+
+	cancelFn()
+	<-done
+
+	assert.Equal(t, 0, clock.Timers())
+}
+
+// TestIBFT_IsRetransmittable makes sure only messages
+// for the current, unfinished, view are retransmitted
+func TestIBFT_IsRetransmittable(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view    = &proto.View{Height: 1, Round: 1}
+		message = buildBasicCommitMessage(nil, nil, []byte("node 0"), view)
+	)
+
+	i := newTestIBFT(t, mockLogger{}, mockBackend{}, mockTransport{})
+
+	i.state.setView(view)
+	i.state.changeState(StateCommit)
+
+	assert.True(t, i.isRetransmittable(message))
+	assert.False(t, i.isRetransmittable(nil))
+
+	i.state.setView(&proto.View{Height: 1, Round: 2})
+	assert.False(t, i.isRetransmittable(message))
+
+	i.state.setView(&proto.View{Height: 2, Round: 1})
+	assert.False(t, i.isRetransmittable(message))
+
+	i.state.setView(view)
+	i.state.changeState(StateFin)
+	assert.False(t, i.isRetransmittable(message))
+}
+
+// TestConsensus_LossyTransport makes sure the cluster finalizes the
+// height in the first round, even though the first COMMIT message
+// of each node is lost, when retransmission is enabled
+func TestConsensus_LossyTransport(t *testing.T) {
+	t.Parallel()
+
+	var (
+		proposal     = []byte("proposal")
+		proposalHash = []byte("proposal hash")
+		numNodes     = uint64(4)
+		nodes        = generateNodeAddresses(numNodes)
+		transports   = make([]*lossyTransport, numNodes)
+	)
+
+	var (
+		backendCallbackMap   = make(map[int]backendConfigCallback)
+		transportCallbackMap = make(map[int]transportConfigCallback)
+	)
+
+	for index := range nodes {
+		index := index
+
+		transports[index] = newLossyTransport(func(message *proto.Message) bool {
+			return message.Type == proto.MessageType_COMMIT
+		})
+
+		backendCallbackMap[index] = clusterBackendCallback(nodes, index, proposal, proposalHash, numNodes)
+		transportCallbackMap[index] = func(transport *mockTransport) {
+			transport.multicastFn = transports[index].Multicast
+		}
+	}
+
+	cluster := newMockCluster(t, numNodes, backendCallbackMap, nil, transportCallbackMap)
+
+	for _, transport := range transports {
+		transport.relayFn = cluster.pushMessage
+	}
+
+	cluster.setRetransmission(10*time.Millisecond, 100*time.Millisecond)
+
+	cluster.runSequence(1)
+	cluster.awaitCompletion()
+
+	for _, result := range cluster.results {
+		if !assert.NotNil(t, result) {
+			continue
+		}
+
+		assert.Equal(t, uint64(0), result.Round)
+		assert.Equal(t, proposal, result.Proposal)
+		assert.Len(t, result.CommittedSeals, int(numNodes))
+		assert.Empty(t, result.RoundChanges)
+	}
+}
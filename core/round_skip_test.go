@@ -0,0 +1,351 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildRoundChanges builds ROUND_CHANGE messages for
+// the height, from the sender to each of the rounds
+func buildRoundChanges(height uint64, senderRounds map[string][]uint64) []*proto.Message {
+	messages := make([]*proto.Message, 0)
+
+	for from, rounds := range senderRounds {
+		for _, round := range rounds {
+			messages = append(messages, buildBasicRoundChangeMessage(
+				nil,
+				nil,
+				&proto.View{Height: height, Round: round},
+				[]byte(from),
+			))
+		}
+	}
+
+	return messages
+}
+
+// TestIBFT_GetRoundSkip makes sure the round backed
+// by f+1 distinct validators is found
+func TestIBFT_GetRoundSkip(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name         string
+		maxFaulty    uint64
+		senderRounds map[string][]uint64
+		expected     uint64
+	}{
+		{
+			"no round change messages",
+			1,
+			nil,
+			0,
+		},
+		{
+			"only f validators",
+			1,
+			map[string][]uint64{
+				"node 1": {5},
+			},
+			0,
+		},
+		{
+			"multiple messages from a single validator",
+			1,
+			map[string][]uint64{
+				"node 1": {2, 3, 4},
+			},
+			0,
+		},
+		{
+			"f+1 validators at the same round",
+			1,
+			map[string][]uint64{
+				"node 1": {3},
+				"node 2": {3},
+			},
+			3,
+		},
+		{
+			"f+1 validators at different rounds",
+			1,
+			map[string][]uint64{
+				"node 1": {3},
+				"node 2": {5},
+				"node 3": {2},
+			},
+			3,
+		},
+		{
+			"f+1 validators spread over several rounds",
+			2,
+			map[string][]uint64{
+				"node 1": {9},
+				"node 2": {4},
+				"node 3": {6},
+				"node 4": {2},
+				"node 5": {1},
+			},
+			4,
+		},
+		{
+			"highest round of each validator is considered",
+			2,
+			map[string][]uint64{
+				"node 1": {2, 6},
+				"node 2": {7},
+				"node 3": {1, 4},
+				"node 4": {3},
+			},
+			4,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				height   = uint64(1)
+				minRound = uint64(1)
+				backend  = mockBackend{
+					maximumFaultyNodesFn: func() uint64 {
+						return testCase.maxFaulty
+					},
+				}
+				messages = mockMessages{
+					getRoundChangeMessagesFn: func(round, messageHeight uint64) []*proto.Message {
+						assert.Equal(t, minRound, round)
+						assert.Equal(t, height, messageHeight)
+
+						return buildRoundChanges(height, testCase.senderRounds)
+					},
+				}
+			)
+
+			i := newTestIBFT(t, mockLogger{}, backend, mockTransport{}, WithMessages(messages))
+
+			assert.Equal(t, testCase.expected, i.getRoundSkip(height, minRound))
+		})
+	}
+}
+
+// TestIBFT_RoundSkip makes sure the node moves to the round
+// backed by f+1 validators, and sends its own ROUND_CHANGE
+func TestIBFT_RoundSkip(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height       = uint64(1)
+		roundChanges = make(chan *proto.Message, 1)
+		observer     = &recordingObserver{}
+		errCh        = make(chan error, 1)
+
+		backend = mockBackend{
+			isValidSenderFn: func(_ *proto.Message) bool {
+				return true
+			},
+			maximumFaultyNodesFn: func() uint64 {
+				return 1
+			},
+			quorumFn: func(_ uint64) uint64 {
+				return 3
+			},
+			buildRoundChangeMessageFn: func(
+				_ []byte,
+				_ *proto.PreparedCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return buildBasicRoundChangeMessage(nil, nil, view, []byte("node 0"))
+			},
+		}
+		transport = mockTransport{
+			multicastFn: func(message *proto.Message) {
+				if message.Type == proto.MessageType_ROUND_CHANGE {
+					roundChanges <- message
+				}
+			},
+		}
+	)
+
+	i := newTestIBFT(t, mockLogger{}, backend, transport, WithObserver(observer))
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	go func() {
+		_, err := i.RunSequence(ctx, height)
+
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return i.Status().RoundStarted
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A single validator at a higher round is not enough
+	for _, message := range buildRoundChanges(height, map[string][]uint64{"node 1": {3}}) {
+		i.AddMessage(message)
+	}
+
+	assert.Never(t, func() bool {
+		return i.state.getRound() != 0
+	}, 100*time.Millisecond, 10*time.Millisecond)
+
+	// f+1 validators at rounds 3 and higher, without a quorum for an RCC
+	for _, message := range buildRoundChanges(height, map[string][]uint64{"node 2": {5}}) {
+		i.AddMessage(message)
+	}
+
+	select {
+	case message := <-roundChanges:
+		assert.Equal(t, uint64(3), message.View.Round)
+	case <-time.After(5 * time.Second):
+		t.Fatal("round change not sent")
+	}
+
+	assert.Equal(t, uint64(3), i.state.getRound())
+
+	cancelFn()
+
+	assert.ErrorIs(t, <-errCh, ErrSequenceCancelled)
+	assert.Contains(
+		t,
+		observer.getEvents(),
+		fmt.Sprintf("round change 1/3 %s", RoundChangeFutureRoundChanges),
+	)
+}
+
+// roundAwareMessages is the message store
+// that records the last round it was notified of
+type roundAwareMessages struct {
+	*messages.Messages
+
+	round atomic.Uint64
+}
+
+func (m *roundAwareMessages) SetRound(round uint64) {
+	m.round.Store(round)
+	m.Messages.SetRound(round)
+}
+
+// TestIBFT_RoundSkip_BeyondWindow makes sure a node that fell behind
+// by more than the round window still catches up through round changes
+func TestIBFT_RoundSkip_BeyondWindow(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height       = uint64(1)
+		round        = uint64(DefaultMaxRoundsAhead + 5)
+		view         = &proto.View{Height: height, Round: round}
+		roundChanges = make(chan *proto.Message, 1)
+		errCh        = make(chan error, 1)
+
+		backend = mockBackend{
+			isValidSenderFn: func(_ *proto.Message) bool {
+				return true
+			},
+			maximumFaultyNodesFn: func() uint64 {
+				return 1
+			},
+			quorumFn: func(_ uint64) uint64 {
+				return 3
+			},
+			buildRoundChangeMessageFn: func(
+				_ []byte,
+				_ *proto.PreparedCertificate,
+				view *proto.View,
+			) *proto.Message {
+				return buildBasicRoundChangeMessage(nil, nil, view, []byte("node 0"))
+			},
+		}
+		transport = mockTransport{
+			multicastFn: func(message *proto.Message) {
+				if message.Type == proto.MessageType_ROUND_CHANGE {
+					roundChanges <- message
+				}
+			},
+		}
+	)
+
+	store := &roundAwareMessages{Messages: messages.NewMessages()}
+	defer store.Close()
+
+	i := newTestIBFT(t, mockLogger{}, backend, transport, WithMessages(store))
+
+	ctx, cancelFn := context.WithCancel(context.Background())
+	defer cancelFn()
+
+	go func() {
+		_, err := i.RunSequence(ctx, height)
+
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		return i.Status().RoundStarted
+	}, 5*time.Second, 10*time.Millisecond)
+
+	proposalMessage := buildBasicPreprepareMessage(
+		[]byte("proposal"),
+		[]byte("hash"),
+		&proto.RoundChangeCertificate{},
+		[]byte("node 1"),
+		view,
+	)
+
+	// Only ROUND_CHANGE messages are accepted beyond the round window
+	assert.ErrorIs(
+		t,
+		i.AddMessage(buildBasicPrepareMessage([]byte("hash"), []byte("node 1"), view)),
+		ErrFutureRound,
+	)
+	assert.ErrorIs(t, i.AddMessage(proposalMessage), ErrFutureRound)
+
+	// ROUND_CHANGE messages are still bounded by their own window
+	for _, message := range buildRoundChanges(height, map[string][]uint64{
+		"node 1": {DefaultMaxRoundChangeRoundsAhead + 1},
+		"node 2": {DefaultMaxRoundChangeRoundsAhead + 1},
+	}) {
+		assert.ErrorIs(t, i.AddMessage(message), ErrFutureRound)
+	}
+
+	// f+1 validators beyond the round window
+	for _, message := range buildRoundChanges(height, map[string][]uint64{
+		"node 1": {round},
+		"node 2": {round},
+	}) {
+		assert.NoError(t, i.AddMessage(message))
+	}
+
+	select {
+	case message := <-roundChanges:
+		assert.Equal(t, round, message.View.Round)
+	case <-time.After(5 * time.Second):
+		t.Fatal("round change not sent")
+	}
+
+	assert.Equal(t, round, i.state.getRound())
+
+	// The store is notified of the new round, so it can evict the rounds far above it
+	require.Eventually(t, func() bool {
+		return store.round.Load() == round
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The proposal for the round is accepted once the node caught up
+	assert.NoError(t, i.AddMessage(proposalMessage))
+
+	cancelFn()
+
+	assert.ErrorIs(t, <-errCh, ErrSequenceCancelled)
+}
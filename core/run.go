@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Run runs consecutive sequences, starting from the specified height,
+// until the context is cancelled. The result of each finalized height is
+// sent to the results channel, if set.
+// If the backend implements ChainReader, the next height is derived from the
+// latest inserted block, so heights finalized through block sync are skipped.
+// Sequences skipped by the Syncer don't produce a result
+func (i *IBFT) Run(ctx context.Context, startHeight uint64, results chan<- *SequenceResult) error {
+	height := startHeight
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		height = i.nextHeight(height)
+		start := i.clock.Now()
+
+		result, err := i.RunSequence(ctx, height)
+
+		switch {
+		case errors.Is(err, ErrSequenceCancelled):
+			return nil
+		case errors.Is(err, ErrSequenceSkipped):
+			// The height was finalized through sync, move on
+			height++
+
+			continue
+		case err != nil:
+			return fmt.Errorf("unable to finalize height %d: %w", height, err)
+		}
+
+		if results != nil {
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		if !i.awaitBlockInterval(ctx, start) {
+			return nil
+		}
+
+		height++
+	}
+}
+
+// nextHeight returns the height the next sequence should run for,
+// taking into account blocks inserted outside of consensus
+func (i *IBFT) nextHeight(height uint64) uint64 {
+	reader, ok := i.backend.(ChainReader)
+	if !ok {
+		return height
+	}
+
+	latest := reader.LatestHeight()
+	if latest < height {
+		return height
+	}
+
+	i.log.Info("skipping finalized heights", "height", height, "latest", latest)
+
+	return latest + 1
+}
+
+// awaitBlockInterval waits until the minimum block interval passes
+// since the specified sequence start. It returns false if the
+// context is cancelled in the meantime
+func (i *IBFT) awaitBlockInterval(ctx context.Context, start time.Time) bool {
+	elapsed := i.clock.Now().Sub(start)
+	if i.minBlockInterval <= 0 || elapsed >= i.minBlockInterval {
+		return true
+	}
+
+	select {
+	case <-i.clock.After(i.minBlockInterval - elapsed):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
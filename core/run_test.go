@@ -0,0 +1,297 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockChainReaderBackend is the mock backend
+// that also implements the ChainReader extension
+type mockChainReaderBackend struct {
+	mockBackend
+
+	latestHeightFn func() uint64
+}
+
+func (m mockChainReaderBackend) LatestHeight() uint64 {
+	return m.latestHeightFn()
+}
+
+// mockChain keeps track of the blocks inserted by a single node
+type mockChain struct {
+	heights []uint64
+
+	sync.Mutex
+}
+
+// insert notes the height of the inserted block
+func (c *mockChain) insert(height uint64) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.heights = append(c.heights, height)
+}
+
+// latest returns the height of the latest inserted block
+func (c *mockChain) latest() uint64 {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.heights) == 0 {
+		return 0
+	}
+
+	return c.heights[len(c.heights)-1]
+}
+
+// newSingleNodeBackend creates a backend for a single validator
+// that is the proposer for every height, and inserts
+// the finalized heights into the specified chain
+func newSingleNodeBackend(chain *mockChain) mockBackend {
+	var (
+		id            = []byte("node 0")
+		proposalHash  = []byte("proposal hash")
+		committedSeal = []byte("seal")
+	)
+
+	return mockBackend{
+		idFn: func() []byte {
+			return id
+		},
+		quorumFn: func(_ uint64) uint64 {
+			return 1
+		},
+		isProposerFn: func(_ []byte, _ uint64, _ uint64) bool {
+			return true
+		},
+		isValidBlockFn: func(_ []byte) bool {
+			return true
+		},
+		isValidSenderFn: func(_ *proto.Message) bool {
+			return true
+		},
+		isValidProposalHashFn: func(_ []byte, _ []byte) bool {
+			return true
+		},
+		isValidCommittedSealFn: func(_ []byte, _ *messages.CommittedSeal) bool {
+			return true
+		},
+		buildProposalFn: func(height uint64) []byte {
+			return []byte{byte(height)}
+		},
+		buildPrePrepareMessageFn: func(
+			proposal []byte,
+			certificate *proto.RoundChangeCertificate,
+			view *proto.View,
+		) *proto.Message {
+			return buildBasicPreprepareMessage(proposal, proposalHash, certificate, id, view)
+		},
+		buildPrepareMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+			return buildBasicPrepareMessage(proposalHash, id, view)
+		},
+		buildCommitMessageFn: func(_ []byte, view *proto.View) *proto.Message {
+			return buildBasicCommitMessage(proposalHash, committedSeal, id, view)
+		},
+		insertBlockFn: func(proposal []byte, _ []*messages.CommittedSeal) {
+			chain.insert(uint64(proposal[0]))
+		},
+	}
+}
+
+// newSingleNodeIBFT creates a new IBFT instance for a single validator,
+// that relays its own messages back to itself
+func newSingleNodeIBFT(t *testing.T, backend Backend, opts ...Option) *IBFT {
+	t.Helper()
+
+	var node *IBFT
+
+	transport := mockTransport{
+		multicastFn: func(message *proto.Message) {
+			node.AddMessage(message)
+		},
+	}
+
+	node = newTestIBFT(t, mockLogger{}, backend, transport, opts...)
+
+	return node
+}
+
+// TestIBFT_Run makes sure the driver runs
+// consecutive sequences correctly
+func TestIBFT_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("heights are finalized back to back", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			chain   = &mockChain{}
+			results = make(chan *SequenceResult)
+			errCh   = make(chan error, 1)
+		)
+
+		node := newSingleNodeIBFT(t, newSingleNodeBackend(chain))
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+
+		go func() {
+			errCh <- node.Run(ctx, 1, results)
+		}()
+
+		for height := uint64(1); height <= 3; height++ {
+			result := <-results
+
+			assert.Equal(t, height, result.Height)
+			assert.Equal(t, []byte{byte(height)}, result.Proposal)
+		}
+
+		cancelFn()
+
+		// Make sure the driver stopped cleanly
+		assert.NoError(t, <-errCh)
+		assert.Equal(t, []uint64{1, 2, 3}, chain.heights[:3])
+	})
+
+	t.Run("heights finalized by block sync are skipped", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			chain   = &mockChain{heights: []uint64{4}}
+			results = make(chan *SequenceResult)
+			errCh   = make(chan error, 1)
+		)
+
+		backend := mockChainReaderBackend{
+			mockBackend:    newSingleNodeBackend(chain),
+			latestHeightFn: chain.latest,
+		}
+
+		node := newSingleNodeIBFT(t, backend)
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+
+		go func() {
+			errCh <- node.Run(ctx, 1, results)
+		}()
+
+		// Wait for the driver to insert the next height,
+		// while it's blocked on delivering the result
+		require.Eventually(t, func() bool {
+			return chain.latest() == 5
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Simulate block sync inserting more blocks
+		chain.insert(10)
+
+		// Make sure the driver continued from the latest height
+		assert.Equal(t, uint64(5), (<-results).Height)
+		assert.Equal(t, uint64(11), (<-results).Height)
+
+		cancelFn()
+
+		assert.NoError(t, <-errCh)
+	})
+
+	t.Run("min block interval is respected", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			chain   = &mockChain{}
+			clock   = NewManualClock(time.Now())
+			results = make(chan *SequenceResult, 2)
+			errCh   = make(chan error, 1)
+		)
+
+		node := newSingleNodeIBFT(
+			t,
+			newSingleNodeBackend(chain),
+			WithClock(clock),
+			WithMinBlockInterval(5*time.Second),
+		)
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+
+		go func() {
+			errCh <- node.Run(ctx, 1, results)
+		}()
+
+		assert.Equal(t, uint64(1), (<-results).Height)
+
+		// Wait for the driver to start waiting for the interval
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Make sure the next height isn't started early
+		clock.Advance(5*time.Second - time.Nanosecond)
+		assert.Len(t, results, 0)
+		assert.Equal(t, uint64(1), chain.latest())
+
+		clock.Advance(time.Nanosecond)
+
+		assert.Equal(t, uint64(2), (<-results).Height)
+
+		cancelFn()
+
+		assert.NoError(t, <-errCh)
+	})
+
+	t.Run("sequence errors are returned", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			clock = NewManualClock(time.Now())
+			errCh = make(chan error, 1)
+		)
+
+		// The node is never the proposer, so the
+		// sequence can't finalize
+		node := newSingleNodeIBFT(
+			t,
+			mockBackend{
+				buildRoundChangeMessageFn: func(
+					_ []byte,
+					_ *proto.PreparedCertificate,
+					view *proto.View,
+				) *proto.Message {
+					return buildBasicRoundChangeMessage(nil, nil, view, []byte("node 0"))
+				},
+			},
+			WithClock(clock),
+			WithMaxRounds(1),
+		)
+
+		go func() {
+			errCh <- node.Run(context.Background(), 1, nil)
+		}()
+
+		require.Eventually(t, func() bool {
+			return clock.Timers() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		clock.Advance(round0Timeout)
+
+		assert.ErrorIs(t, <-errCh, ErrMaxRoundsExceeded)
+	})
+
+	t.Run("cancelled context stops the driver", func(t *testing.T) {
+		t.Parallel()
+
+		node := newSingleNodeIBFT(t, mockBackend{})
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		cancelFn()
+
+		assert.NoError(t, node.Run(ctx, 1, nil))
+	})
+}
@@ -0,0 +1,33 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/madz-lab/go-ibft/messages"
+)
+
+// ErrInvalidAggregatedSeal is returned when the aggregate
+// of valid committed seals fails verification
+var ErrInvalidAggregatedSeal = errors.New("invalid aggregated seal")
+
+// aggregateSeals combines the committed seals for the accepted
+// proposal into a single aggregated seal, and verifies it
+func (i *IBFT) aggregateSeals(
+	aggregator SealAggregator,
+	height uint64,
+	committedSeals []*messages.CommittedSeal,
+) (*messages.AggregatedSeal, error) {
+	proposalHash := i.state.getProposalHash()
+
+	aggregatedSeal, err := aggregator.AggregateSeals(height, proposalHash, committedSeals)
+	if err != nil {
+		return nil, fmt.Errorf("unable to aggregate %d seals: %w", len(committedSeals), err)
+	}
+
+	if aggregatedSeal == nil || !aggregator.IsValidAggregatedSeal(height, proposalHash, aggregatedSeal) {
+		return nil, ErrInvalidAggregatedSeal
+	}
+
+	return aggregatedSeal, nil
+}
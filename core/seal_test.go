@@ -0,0 +1,250 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMockSealAggregator makes sure the mock
+// seal aggregator is deterministic
+func TestMockSealAggregator(t *testing.T) {
+	t.Parallel()
+
+	var (
+		aggregator = mockSealAggregator{validators: generateNodeAddresses(10)}
+		hash       = []byte("hash")
+		seals      = []*messages.CommittedSeal{
+			{Signer: []byte("node 9"), Signature: []byte("9")},
+			{Signer: []byte("node 0"), Signature: []byte("0")},
+			{Signer: []byte("node 3"), Signature: []byte("3")},
+		}
+	)
+
+	aggregated, err := aggregator.AggregateSeals(1, hash, seals)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte{0b00001001, 0b00000010}, aggregated.Bitmap)
+	assert.Equal(t, []byte("hash039"), aggregated.Signature)
+	assert.True(t, aggregator.IsValidAggregatedSeal(1, hash, aggregated))
+	assert.False(t, aggregator.IsValidAggregatedSeal(1, []byte("other hash"), aggregated))
+
+	// Make sure the order of the seals doesn't matter
+	reversed, err := aggregator.AggregateSeals(1, hash, []*messages.CommittedSeal{seals[2], seals[1], seals[0]})
+	require.NoError(t, err)
+
+	assert.Equal(t, aggregated, reversed)
+
+	_, err = aggregator.AggregateSeals(1, hash, []*messages.CommittedSeal{{Signer: []byte("unknown")}})
+	assert.ErrorIs(t, err, errUnknownSigner)
+}
+
+// TestIBFT_AggregatedSeal makes sure the committed seals are
+// aggregated, and the proposal is inserted with the aggregate
+func TestIBFT_AggregatedSeal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		chain    = &mockChain{}
+		inserted *messages.AggregatedSeal
+
+		backend = mockSealAggregatorBackend{
+			mockBackend:        newSingleNodeBackend(chain),
+			mockSealAggregator: mockSealAggregator{validators: generateNodeAddresses(1)},
+			insertAggregatedBlockFn: func(proposal []byte, seal *messages.AggregatedSeal) {
+				chain.insert(uint64(proposal[0]))
+
+				inserted = seal
+			},
+		}
+	)
+
+	// Make sure the per-validator seals are not used for insertion
+	backend.insertBlockFn = func(_ []byte, _ []*messages.CommittedSeal) {
+		t.Fatal("block inserted with per-validator seals")
+	}
+
+	node := newSingleNodeIBFT(t, backend)
+
+	result, err := node.RunSequence(context.Background(), 1)
+	require.NoError(t, err)
+
+	expected := &messages.AggregatedSeal{
+		Bitmap:    []byte{0b00000001},
+		Signature: []byte("proposal hashseal"),
+	}
+
+	assert.Equal(t, uint64(1), chain.latest())
+	assert.Equal(t, expected, inserted)
+	assert.Equal(t, expected, result.AggregatedSeal)
+	assert.Len(t, result.CommittedSeals, 1)
+}
+
+// TestIBFT_HandleCommit_Aggregation makes sure the state doesn't
+// move to fin if the committed seals can't be aggregated
+func TestIBFT_HandleCommit_Aggregation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view         = &proto.View{Height: 1, Round: 0}
+		proposalHash = []byte("proposal hash")
+		nodes        = generateNodeAddresses(4)
+
+		commitMessages = []*proto.Message{
+			buildBasicCommitMessage(proposalHash, []byte("seal"), nodes[0], view),
+			buildBasicCommitMessage(proposalHash, []byte("seal"), nodes[1], view),
+			buildBasicCommitMessage(proposalHash, []byte("seal"), nodes[2], view),
+		}
+
+		messageStore = mockMessages{
+			getValidMessagesFn: func(
+				_ *proto.View,
+				_ proto.MessageType,
+				isValid func(*proto.Message) bool,
+			) []*proto.Message {
+				return filterMessages(commitMessages, isValid)
+			},
+		}
+		baseBackend = mockBackend{
+			isValidProposalHashFn: func(_ []byte, _ []byte) bool {
+				return true
+			},
+			isValidCommittedSealFn: func(_ []byte, _ *messages.CommittedSeal) bool {
+				return true
+			},
+		}
+	)
+
+	testTable := []struct {
+		name       string
+		validators [][]byte
+		isValid    bool
+		finalized  bool
+	}{
+		{
+			"seals are aggregated",
+			nodes,
+			true,
+			true,
+		},
+		{
+			"seals can't be aggregated",
+			nodes[:2],
+			true,
+			false,
+		},
+		{
+			"aggregated seal is invalid",
+			nodes,
+			false,
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			backend := mockSealAggregatorBackend{
+				mockBackend:        baseBackend,
+				mockSealAggregator: mockSealAggregator{validators: testCase.validators},
+				isValidAggregatedSealFn: func(_ uint64, _ []byte, _ *messages.AggregatedSeal) bool {
+					return testCase.isValid
+				},
+			}
+
+			i := newTestIBFT(t, mockLogger{}, backend, mockTransport{}, WithMessages(messageStore))
+
+			i.state.setView(view)
+			i.state.setProposalMessage(buildBasicPreprepareMessage(nil, proposalHash, nil, nodes[0], view))
+			i.state.changeState(StateCommit)
+
+			assert.Equal(t, testCase.finalized, i.handleCommit(view, 3))
+
+			if !testCase.finalized {
+				assert.Equal(t, StateCommit, i.state.getStateName())
+				assert.Nil(t, i.state.getAggregatedSeal())
+
+				return
+			}
+
+			assert.Equal(t, StateFin, i.state.getStateName())
+			assert.Equal(t, []byte{0b00000111}, i.state.getAggregatedSeal().Bitmap)
+		})
+	}
+}
+
+// TestConsensus_AggregatedSeal makes sure all nodes in the
+// cluster insert the proposal with the same aggregated seal
+func TestConsensus_AggregatedSeal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		proposal     = []byte("proposal")
+		proposalHash = []byte("proposal hash")
+		numNodes     = uint64(4)
+		nodes        = generateNodeAddresses(numNodes)
+
+		insertedSeals = make([]*messages.AggregatedSeal, numNodes)
+		insertedLock  sync.Mutex
+
+		backendCallbackMap   = make(map[int]backendConfigCallback)
+		transportCallbackMap = make(map[int]transportConfigCallback)
+
+		cluster *mockCluster
+	)
+
+	for index := range nodes {
+		backendCallbackMap[index] = clusterBackendCallback(nodes, index, proposal, proposalHash, numNodes)
+		transportCallbackMap[index] = func(transport *mockTransport) {
+			transport.multicastFn = func(message *proto.Message) {
+				cluster.pushMessage(message)
+			}
+		}
+	}
+
+	cluster = newMockCluster(t, numNodes, backendCallbackMap, nil, transportCallbackMap)
+
+	// Extend the node backends with the seal aggregator
+	for index, node := range cluster.nodes {
+		index := index
+
+		mockBackend, ok := node.backend.(*mockBackend)
+		require.True(t, ok)
+
+		node.backend = mockSealAggregatorBackend{
+			mockBackend:        *mockBackend,
+			mockSealAggregator: mockSealAggregator{validators: nodes},
+			insertAggregatedBlockFn: func(_ []byte, seal *messages.AggregatedSeal) {
+				insertedLock.Lock()
+				defer insertedLock.Unlock()
+
+				insertedSeals[index] = seal
+			},
+		}
+	}
+
+	cluster.runSequence(1)
+	cluster.awaitCompletion()
+
+	expected := &messages.AggregatedSeal{
+		Bitmap:    []byte{0b00001111},
+		Signature: []byte("proposal hashsealsealsealseal"),
+	}
+
+	for index, result := range cluster.results {
+		if !assert.NotNil(t, result) {
+			continue
+		}
+
+		assert.Equal(t, expected, result.AggregatedSeal)
+		assert.Equal(t, expected, insertedSeals[index])
+	}
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages"
+)
+
+// RoundChangeReason is the cause of a round change
+// during a single consensus sequence
+type RoundChangeReason uint8
+
+const (
+	// RoundChangeTimeout is the round change caused by an expired round timer
+	RoundChangeTimeout RoundChangeReason = iota
+
+	// RoundChangeFutureProposal is the round change caused by
+	// a valid proposal for a higher round
+	RoundChangeFutureProposal
+
+	// RoundChangeFutureRCC is the round change caused by
+	// a valid Round Change Certificate for a higher round
+	RoundChangeFutureRCC
+
+	// RoundChangeFutureRoundChanges is the round change caused by ROUND_CHANGE
+	// messages from f+1 distinct validators for higher rounds
+	RoundChangeFutureRoundChanges
+)
+
+func (r RoundChangeReason) String() string {
+	switch r {
+	case RoundChangeTimeout:
+		return "round timeout"
+	case RoundChangeFutureProposal:
+		return "future proposal"
+	case RoundChangeFutureRCC:
+		return "future RCC"
+	case RoundChangeFutureRoundChanges:
+		return "future round changes"
+	}
+
+	return ""
+}
+
+// RoundChange describes a single round hop within a sequence
+type RoundChange struct {
+	// Round is the round the node moved to
+	Round uint64
+
+	// Reason is the cause of the round change
+	Reason RoundChangeReason
+}
+
+// SequenceResult is the outcome of a finalized consensus sequence
+type SequenceResult struct {
+	// Proposal is the proposal that was inserted
+	Proposal []byte
+
+	// ProposalHash is the hash of the inserted proposal
+	ProposalHash []byte
+
+	// CommittedSeals are the committed seals the proposal was inserted with
+	CommittedSeals []*messages.CommittedSeal
+
+	// AggregatedSeal is the aggregate of the committed seals
+	// the proposal was inserted with, if the backend aggregates seals
+	AggregatedSeal *messages.AggregatedSeal
+
+	// RoundChanges are the round changes that happened during the sequence
+	RoundChanges []RoundChange
+
+	// Height is the height of the sequence
+	Height uint64
+
+	// Round is the round in which the proposal was finalized
+	Round uint64
+
+	// Duration is the total duration of the sequence
+	Duration time.Duration
+}
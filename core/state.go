@@ -2,29 +2,38 @@ package core
 
 import (
 	"sync"
+	"time"
 
 	"github.com/madz-lab/go-ibft/messages"
 	"github.com/madz-lab/go-ibft/messages/proto"
 )
 
-type stateType uint8
+// StateType is the state of the IBFT state machine within a round
+type StateType uint8
 
 const (
-	newRound stateType = iota
-	prepare
-	commit
-	fin
+	// StateNewRound is the state in which the proposal is awaited
+	StateNewRound StateType = iota
+
+	// StatePrepare is the state in which PREPARE messages are gathered
+	StatePrepare
+
+	// StateCommit is the state in which COMMIT messages are gathered
+	StateCommit
+
+	// StateFin is the state in which the finalized block is inserted
+	StateFin
 )
 
-func (s stateType) String() string {
+func (s StateType) String() string {
 	switch s {
-	case newRound:
+	case StateNewRound:
 		return "new round"
-	case prepare:
+	case StatePrepare:
 		return "prepare"
-	case commit:
+	case StateCommit:
 		return "commit"
-	case fin:
+	case StateFin:
 		return "fin"
 	}
 
@@ -48,11 +57,21 @@ type state struct {
 	//	validated commit seals
 	seals []*messages.CommittedSeal
 
+	// aggregatedSeal is the aggregate of the validated
+	// commit seals, if the backend aggregates seals
+	aggregatedSeal *messages.AggregatedSeal
+
 	//	flags for different states
 	roundStarted bool
 
 	// current state name
-	name stateType
+	name StateType
+
+	// stateStarted is the time the current state was entered
+	stateStarted time.Time
+
+	// roundStartedAt is the time the current round was started
+	roundStartedAt time.Time
 
 	sync.RWMutex
 }
@@ -72,8 +91,9 @@ func (s *state) clear(height uint64) {
 	defer s.Unlock()
 
 	s.seals = nil
+	s.aggregatedSeal = nil
 	s.roundStarted = false
-	s.name = newRound
+	s.name = StateNewRound
 	s.proposalMessage = nil
 	s.latestPC = nil
 	s.latestPreparedProposedBlock = nil
@@ -151,18 +171,48 @@ func (s *state) getCommittedSeals() []*messages.CommittedSeal {
 	return s.seals
 }
 
-func (s *state) getStateName() stateType {
+func (s *state) getAggregatedSeal() *messages.AggregatedSeal {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.aggregatedSeal
+}
+
+func (s *state) getStateName() StateType {
 	s.RLock()
 	defer s.RUnlock()
 
 	return s.name
 }
 
-func (s *state) changeState(name stateType) {
+// changeState sets the state name, and returns the previous one
+func (s *state) changeState(name StateType) StateType {
 	s.Lock()
 	defer s.Unlock()
 
+	previous := s.name
 	s.name = name
+
+	return previous
+}
+
+// setStateStarted sets the time the current state was
+// entered, and returns the previously set time
+func (s *state) setStateStarted(started time.Time) time.Time {
+	s.Lock()
+	defer s.Unlock()
+
+	previous := s.stateStarted
+	s.stateStarted = started
+
+	return previous
+}
+
+func (s *state) setRoundStartedAt(startedAt time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.roundStartedAt = startedAt
 }
 
 func (s *state) setRoundStarted(started bool) {
@@ -186,21 +236,37 @@ func (s *state) setCommittedSeals(seals []*messages.CommittedSeal) {
 	s.seals = seals
 }
 
-func (s *state) newRound() {
+func (s *state) setAggregatedSeal(seal *messages.AggregatedSeal) {
 	s.Lock()
 	defer s.Unlock()
 
-	if !s.roundStarted {
-		// Round is not yet started, kick the round off
-		s.name = newRound
-		s.roundStarted = true
+	s.aggregatedSeal = seal
+}
+
+// newRound kicks the round off, if it's not yet started.
+// It returns false if the round was already started, like
+// when it was restored with an accepted proposal
+func (s *state) newRound() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.roundStarted {
+		return false
 	}
+
+	// Round is not yet started, kick the round off
+	s.name = StateNewRound
+	s.roundStarted = true
+
+	return true
 }
 
+// finalizePrepare locks the prepared certificate, moves to
+// the commit state, and returns the previous state name
 func (s *state) finalizePrepare(
 	certificate *proto.PreparedCertificate,
 	latestPPB []byte,
-) {
+) StateType {
 	s.Lock()
 	defer s.Unlock()
 
@@ -208,5 +274,59 @@ func (s *state) finalizePrepare(
 	s.latestPreparedProposedBlock = latestPPB
 
 	// Move to the commit state
-	s.name = commit
+	previous := s.name
+	s.name = StateCommit
+
+	return previous
+}
+
+// snapshot returns the part of the state that needs to be persisted
+func (s *state) snapshot() *PersistedState {
+	s.RLock()
+	defer s.RUnlock()
+
+	name := s.name
+	if name == StateFin {
+		// Committed seals are not persisted, so they
+		// need to be gathered again after a restart
+		name = StateCommit
+	}
+
+	return &PersistedState{
+		View: &proto.View{
+			Height: s.view.Height,
+			Round:  s.view.Round,
+		},
+		ProposalMessage:             s.proposalMessage,
+		LatestPC:                    s.latestPC,
+		LatestPreparedProposedBlock: s.latestPreparedProposedBlock,
+		Name:                        name,
+	}
+}
+
+// restore overwrites the state with the persisted state
+func (s *state) restore(persisted *PersistedState) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.view = &proto.View{
+		Height: persisted.View.Height,
+		Round:  persisted.View.Round,
+	}
+
+	s.seals = nil
+	s.aggregatedSeal = nil
+	s.proposalMessage = persisted.ProposalMessage
+	s.latestPC = persisted.LatestPC
+	s.latestPreparedProposedBlock = persisted.LatestPreparedProposedBlock
+
+	s.name = StateNewRound
+	s.roundStarted = false
+
+	if s.proposalMessage != nil {
+		// The proposal for the round was already accepted,
+		// continue from the persisted state
+		s.name = persisted.Name
+		s.roundStarted = true
+	}
 }
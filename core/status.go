@@ -0,0 +1,76 @@
+package core
+
+import (
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// statusMessageTypes are the message types counted in the status
+var statusMessageTypes = []proto.MessageType{
+	proto.MessageType_PREPREPARE,
+	proto.MessageType_PREPARE,
+	proto.MessageType_COMMIT,
+	proto.MessageType_ROUND_CHANGE,
+}
+
+// Status is a point-in-time snapshot of the IBFT state machine.
+// It does not share any memory with the running instance
+type Status struct {
+	// View is the current view (height, round)
+	View *proto.View
+
+	// State is the current state of the state machine
+	State StateType
+
+	// RoundStarted indicates if the current round was kicked off
+	RoundStarted bool
+
+	// ProposalHash is the hash of the accepted proposal
+	// for the current round, if any
+	ProposalHash []byte
+
+	// HasPreparedCertificate indicates if a prepared
+	// certificate is held for the current height
+	HasPreparedCertificate bool
+
+	// Messages is the number of stored messages
+	// for the current view, by message type
+	Messages map[proto.MessageType]int
+
+	// RoundStartedAt is the time the current round was started.
+	// It is zero if no round was started yet
+	RoundStartedAt time.Time
+}
+
+// Status returns a snapshot of the current state of the state machine.
+// It is safe to call concurrently with a running sequence
+func (i *IBFT) Status() Status {
+	status := i.state.status()
+
+	status.Messages = make(map[proto.MessageType]int, len(statusMessageTypes))
+	for _, messageType := range statusMessageTypes {
+		status.Messages[messageType] = i.messages.NumMessages(status.View, messageType)
+	}
+
+	return status
+}
+
+// status returns a snapshot of the state,
+// without the message counts
+func (s *state) status() Status {
+	s.RLock()
+	defer s.RUnlock()
+
+	return Status{
+		View: &proto.View{
+			Height: s.view.Height,
+			Round:  s.view.Round,
+		},
+		State:                  s.name,
+		RoundStarted:           s.roundStarted,
+		ProposalHash:           append([]byte(nil), s.proposalMessage.GetPreprepareData().GetProposalHash()...),
+		HasPreparedCertificate: s.latestPC != nil,
+		RoundStartedAt:         s.roundStartedAt,
+	}
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIBFT_Status makes sure the status snapshot
+// reflects the state machine correctly
+func TestIBFT_Status(t *testing.T) {
+	t.Parallel()
+
+	t.Run("initial status", func(t *testing.T) {
+		t.Parallel()
+
+		i := newTestIBFT(t, mockLogger{}, mockBackend{}, mockTransport{})
+
+		assert.Equal(
+			t,
+			Status{
+				View:  &proto.View{Height: 0, Round: 0},
+				State: StateNewRound,
+				Messages: map[proto.MessageType]int{
+					proto.MessageType_PREPREPARE:   0,
+					proto.MessageType_PREPARE:      0,
+					proto.MessageType_COMMIT:       0,
+					proto.MessageType_ROUND_CHANGE: 0,
+				},
+			},
+			i.Status(),
+		)
+	})
+
+	t.Run("status reflects the state", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			view         = &proto.View{Height: 5, Round: 2}
+			proposalHash = []byte("proposal hash")
+			startedAt    = time.Unix(100, 0)
+
+			proposal = buildBasicPreprepareMessage(
+				[]byte("proposal"),
+				proposalHash,
+				nil,
+				[]byte("proposer"),
+				view,
+			)
+		)
+
+		i := newTestIBFT(
+			t,
+			mockLogger{},
+			mockBackend{},
+			mockTransport{},
+			WithMessages(mockMessages{
+				numMessagesFn: func(messageView *proto.View, messageType proto.MessageType) int {
+					assert.Equal(t, view, messageView)
+
+					if messageType == proto.MessageType_PREPARE {
+						return 3
+					}
+
+					return 0
+				},
+			}),
+		)
+
+		i.state.setView(view)
+		i.state.setProposalMessage(proposal)
+		i.state.setRoundStarted(true)
+		i.state.setRoundStartedAt(startedAt)
+		i.state.finalizePrepare(&proto.PreparedCertificate{}, []byte("proposal"))
+
+		status := i.Status()
+
+		assert.Equal(t, view, status.View)
+		assert.Equal(t, StateCommit, status.State)
+		assert.True(t, status.RoundStarted)
+		assert.Equal(t, proposalHash, status.ProposalHash)
+		assert.True(t, status.HasPreparedCertificate)
+		assert.Equal(t, 3, status.Messages[proto.MessageType_PREPARE])
+		assert.Equal(t, 0, status.Messages[proto.MessageType_COMMIT])
+		assert.Equal(t, startedAt, status.RoundStartedAt)
+
+		// Make sure the snapshot doesn't share memory with the state
+		status.View.Round = 10
+		status.ProposalHash[0] = 'x'
+
+		assert.Equal(t, uint64(2), i.state.getRound())
+		assert.Equal(t, []byte("proposal hash"), i.state.getProposalHash())
+	})
+
+	t.Run("status is safe to read during a sequence", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			clock   = NewManualClock(time.Unix(100, 0))
+			results = make(chan *SequenceResult)
+		)
+
+		node := newSingleNodeIBFT(
+			t,
+			newSingleNodeBackend(&mockChain{}),
+			WithClock(clock),
+		)
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+		defer cancelFn()
+
+		go func() {
+			_ = node.Run(ctx, 1, results)
+		}()
+
+		// Read the status while the sequences are running
+		for height := uint64(1); height <= 3; height++ {
+			_ = node.Status()
+
+			require.Equal(t, height, (<-results).Height)
+		}
+
+		// The driver is blocked on delivering the next result,
+		// so the state is the finalized state of height 4
+		require.Eventually(t, func() bool {
+			return node.Status().State == StateFin
+		}, 5*time.Second, 10*time.Millisecond)
+
+		status := node.Status()
+
+		assert.Equal(t, uint64(4), status.View.Height)
+		assert.Equal(t, clock.Now(), status.RoundStartedAt)
+		assert.Equal(t, 1, status.Messages[proto.MessageType_COMMIT])
+	})
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// ErrStateNotFound is returned by a StateStore when
+// there is no persisted consensus state
+var ErrStateNotFound = errors.New("persisted state not found")
+
+// PersistedState is the part of the consensus state that
+// needs to survive a node restart
+type PersistedState struct {
+	// View is the current view (height, round)
+	View *proto.View
+
+	// ProposalMessage is the accepted proposal for the current round
+	ProposalMessage *proto.Message
+
+	// LatestPC is the latest prepared certificate (the prepared lock)
+	LatestPC *proto.PreparedCertificate
+
+	// LatestPreparedProposedBlock is the block for which
+	// the latest prepared certificate was formed
+	LatestPreparedProposedBlock []byte
+
+	// Messages are the outgoing messages recorded for the
+	// current height. They are only populated on load
+	Messages []*proto.Message
+
+	// Name is the current state name
+	Name StateType
+}
+
+// StateStore defines an interface for persisting the
+// consensus state, so that a restarted node continues
+// in the same view, with the same prepared lock
+type StateStore interface {
+	// SaveState persists a consensus state transition.
+	// If it fails, the sequence is aborted with the error
+	SaveState(state *PersistedState) error
+
+	// SaveMessage persists an outgoing message.
+	// It is called before the message is multicast
+	SaveMessage(message *proto.Message) error
+
+	// LoadState loads the latest persisted state, along with the
+	// outgoing messages for its height. It returns ErrStateNotFound
+	// if nothing was persisted
+	LoadState() (*PersistedState, error)
+}
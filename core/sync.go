@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// ErrSequenceSkipped is returned when the sequence is abandoned
+// because the height was finalized through the Syncer
+var ErrSequenceSkipped = errors.New("sequence skipped, height was synced")
+
+// Syncer is notified when the node falls behind the rest of the network
+type Syncer interface {
+	// Sync is called when messages from at least f+1 distinct validators
+	// are received for a height above the current one. It should bring the
+	// local chain up to date, and return true if the current height was
+	// finalized in the meantime, in which case the current sequence is skipped.
+	// The context is cancelled once the current sequence is done
+	Sync(ctx context.Context, height uint64) bool
+}
+
+// futureSenders tracks the distinct senders of messages
+// for heights above the current one, and their voting power
+type futureSenders struct {
+	// senders maps the height -> distinct senders
+	senders map[uint64]map[string]struct{}
+
+	// power maps the height -> accumulated voting power of the senders
+	power map[uint64]uint64
+
+	mux sync.Mutex
+}
+
+// newFutureSenders creates a new empty future sender tracker
+func newFutureSenders() *futureSenders {
+	return &futureSenders{
+		senders: make(map[uint64]map[string]struct{}),
+		power:   make(map[uint64]uint64),
+	}
+}
+
+// add notes the sender with the specified voting power for the height,
+// and returns the accumulated voting power of the distinct senders
+// for it, before and after the sender was added
+func (f *futureSenders) add(height uint64, from []byte, power uint64) (uint64, uint64) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	senders, ok := f.senders[height]
+	if !ok {
+		senders = make(map[string]struct{})
+		f.senders[height] = senders
+	}
+
+	previous := f.power[height]
+
+	if _, seen := senders[string(from)]; seen {
+		return previous, previous
+	}
+
+	senders[string(from)] = struct{}{}
+	f.power[height] = previous + power
+
+	return previous, f.power[height]
+}
+
+// prune removes all heights up to and including the specified height
+func (f *futureSenders) prune(height uint64) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	for senderHeight := range f.senders {
+		if senderHeight <= height {
+			delete(f.senders, senderHeight)
+			delete(f.power, senderHeight)
+		}
+	}
+}
+
+// trackFutureMessage notes the sender of a message for a future height,
+// and triggers the syncer once validators with f+1 voting power are seen for it
+func (i *IBFT) trackFutureMessage(message *proto.Message) {
+	if i.syncer == nil || message.View.Height <= i.state.getHeight() {
+		return
+	}
+
+	var (
+		height    = message.View.Height
+		threshold = i.weakQuorum(height)
+
+		previous, current = i.futureSenders.add(height, message.From, i.votingPower(height, message.From))
+	)
+
+	// Trigger only once per height, when the threshold is crossed
+	if previous >= threshold || current < threshold {
+		return
+	}
+
+	select {
+	case i.syncTrigger <- message.View.Height:
+	default:
+		// A sync is already pending
+	}
+}
+
+// watchForSync waits for evidence of a higher height, and lets the syncer
+// catch up. It signals on the synced channel if the height was finalized through sync
+func (i *IBFT) watchForSync(ctx context.Context, height uint64, synced chan<- struct{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case target := <-i.syncTrigger:
+			if target <= height {
+				// Stale trigger from a previous sequence
+				continue
+			}
+
+			i.log.Info("validators are at a higher height", "height", height, "target", target)
+
+			if !i.syncer.Sync(ctx, target) {
+				continue
+			}
+
+			select {
+			case synced <- struct{}{}:
+			case <-ctx.Done():
+			}
+
+			return
+		}
+	}
+}
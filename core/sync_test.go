@@ -0,0 +1,252 @@
+package core
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSyncer is the mock syncer that is configurable
+type mockSyncer struct {
+	syncFn func(context.Context, uint64) bool
+}
+
+func (m mockSyncer) Sync(ctx context.Context, height uint64) bool {
+	if m.syncFn != nil {
+		return m.syncFn(ctx, height)
+	}
+
+	return false
+}
+
+// newSyncTestIBFT creates a non-proposer node that
+// tolerates a single faulty node, with the passed in syncer
+func newSyncTestIBFT(t *testing.T, syncer Syncer) *IBFT {
+	t.Helper()
+
+	backend := mockBackend{
+		isValidSenderFn: func(_ *proto.Message) bool {
+			return true
+		},
+		maximumFaultyNodesFn: func() uint64 {
+			return 1
+		},
+		quorumFn: func(_ uint64) uint64 {
+			return 3
+		},
+	}
+
+	return newTestIBFT(t, mockLogger{}, backend, mockTransport{}, WithSyncer(syncer))
+}
+
+// buildFutureMessage builds a PREPARE message from the sender for the height
+func buildFutureMessage(from string, height uint64) *proto.Message {
+	return buildBasicPrepareMessage(
+		[]byte("proposal hash"),
+		[]byte(from),
+		&proto.View{Height: height, Round: 0},
+	)
+}
+
+// TestIBFT_Syncer makes sure the syncer is triggered
+// by f+1 validators at a higher height
+func TestIBFT_Syncer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("synced height is skipped", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			syncedHeight = make(chan uint64, 1)
+			errCh        = make(chan error, 1)
+		)
+
+		i := newSyncTestIBFT(t, mockSyncer{
+			syncFn: func(_ context.Context, height uint64) bool {
+				syncedHeight <- height
+
+				return true
+			},
+		})
+
+		go func() {
+			_, err := i.RunSequence(context.Background(), 1)
+
+			errCh <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			return i.Status().RoundStarted
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// f+1 distinct validators are at height 5
+		i.AddMessage(buildFutureMessage("node 1", 5))
+		i.AddMessage(buildFutureMessage("node 2", 5))
+
+		assert.ErrorIs(t, <-errCh, ErrSequenceSkipped)
+		assert.Equal(t, uint64(5), <-syncedHeight)
+	})
+
+	t.Run("sequence continues if the height is not synced", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			syncCalls = int64(0)
+			errCh     = make(chan error, 1)
+		)
+
+		i := newSyncTestIBFT(t, mockSyncer{
+			syncFn: func(_ context.Context, _ uint64) bool {
+				atomic.AddInt64(&syncCalls, 1)
+
+				return false
+			},
+		})
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+
+		go func() {
+			_, err := i.RunSequence(ctx, 1)
+
+			errCh <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			return i.Status().RoundStarted
+		}, 5*time.Second, 10*time.Millisecond)
+
+		for _, from := range []string{"node 1", "node 2", "node 3"} {
+			i.AddMessage(buildFutureMessage(from, 5))
+		}
+
+		require.Eventually(t, func() bool {
+			return atomic.LoadInt64(&syncCalls) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Make sure the sequence is still running
+		select {
+		case err := <-errCh:
+			t.Fatalf("sequence stopped unexpectedly, %v", err)
+		default:
+		}
+
+		cancelFn()
+
+		assert.ErrorIs(t, <-errCh, ErrSequenceCancelled)
+
+		// Make sure the syncer was triggered only once for the height
+		assert.Equal(t, int64(1), atomic.LoadInt64(&syncCalls))
+	})
+
+	t.Run("f validators don't trigger the syncer", func(t *testing.T) {
+		t.Parallel()
+
+		i := newSyncTestIBFT(t, mockSyncer{})
+		i.state.setView(&proto.View{Height: 1, Round: 0})
+
+		// The same sender multiple times, and a
+		// single sender at a different height
+		i.AddMessage(buildFutureMessage("node 1", 5))
+		i.AddMessage(buildFutureMessage("node 1", 5))
+		i.AddMessage(buildFutureMessage("node 2", 6))
+
+		// Messages for the current height
+		i.AddMessage(buildFutureMessage("node 3", 1))
+		i.AddMessage(buildFutureMessage("node 4", 1))
+
+		assert.Len(t, i.syncTrigger, 0)
+
+		i.AddMessage(buildFutureMessage("node 2", 5))
+
+		assert.Len(t, i.syncTrigger, 1)
+		assert.Equal(t, uint64(5), <-i.syncTrigger)
+	})
+
+	t.Run("messages beyond the height window trigger the syncer", func(t *testing.T) {
+		t.Parallel()
+
+		i := newSyncTestIBFT(t, mockSyncer{})
+		i.state.setView(&proto.View{Height: 1, Round: 0})
+
+		height := uint64(1 + DefaultMaxHeightsAhead + 1)
+
+		// The messages are not stored, but the senders are tracked
+		assert.ErrorIs(t, i.AddMessage(buildFutureMessage("node 1", height)), ErrFutureHeight)
+		assert.ErrorIs(t, i.AddMessage(buildFutureMessage("node 2", height)), ErrFutureHeight)
+
+		assert.Len(t, i.syncTrigger, 1)
+		assert.Equal(t, height, <-i.syncTrigger)
+	})
+
+	t.Run("driver moves past the synced height", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			syncing = make(chan uint64, 1)
+			errCh   = make(chan error, 1)
+		)
+
+		i := newSyncTestIBFT(t, mockSyncer{
+			syncFn: func(_ context.Context, height uint64) bool {
+				syncing <- height
+
+				return true
+			},
+		})
+
+		ctx, cancelFn := context.WithCancel(context.Background())
+
+		go func() {
+			errCh <- i.Run(ctx, 1, nil)
+		}()
+
+		require.Eventually(t, func() bool {
+			return i.Status().RoundStarted
+		}, 5*time.Second, 10*time.Millisecond)
+
+		i.AddMessage(buildFutureMessage("node 1", 2))
+		i.AddMessage(buildFutureMessage("node 2", 2))
+
+		assert.Equal(t, uint64(2), <-syncing)
+
+		// Make sure the driver moved on to the next height
+		require.Eventually(t, func() bool {
+			status := i.Status()
+
+			return status.View.Height == 2 && status.RoundStarted
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cancelFn()
+
+		assert.NoError(t, <-errCh)
+	})
+}
+
+// TestFutureSenders makes sure distinct senders
+// are tracked per height
+func TestFutureSenders(t *testing.T) {
+	t.Parallel()
+
+	senders := newFutureSenders()
+
+	add := func(height uint64, from string, power uint64) [2]uint64 {
+		previous, current := senders.add(height, []byte(from), power)
+
+		return [2]uint64{previous, current}
+	}
+
+	assert.Equal(t, [2]uint64{0, 1}, add(5, "node 1", 1))
+	assert.Equal(t, [2]uint64{1, 1}, add(5, "node 1", 1))
+	assert.Equal(t, [2]uint64{1, 11}, add(5, "node 2", 10))
+	assert.Equal(t, [2]uint64{0, 1}, add(6, "node 1", 1))
+
+	senders.prune(5)
+
+	assert.Equal(t, [2]uint64{0, 1}, add(5, "node 1", 1))
+	assert.Equal(t, [2]uint64{1, 3}, add(6, "node 2", 2))
+}
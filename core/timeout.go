@@ -0,0 +1,173 @@
+package core
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// maxRoundTimeout is the largest representable round timeout
+const maxRoundTimeout = time.Duration(math.MaxInt64)
+
+// RoundTimeoutPolicy defines the timeout for each round of consensus
+type RoundTimeoutPolicy interface {
+	// Timeout returns the timeout for the specified round.
+	// It must never be negative
+	Timeout(round uint64) time.Duration
+}
+
+// ExponentialTimeout is the round timeout policy that doubles
+// the base timeout with each round (Base * 2^round), capped at Max
+type ExponentialTimeout struct {
+	// Base is the timeout of round 0
+	Base time.Duration
+
+	// Max is the timeout cap. Zero means the timeout is
+	// only capped at the largest representable duration
+	Max time.Duration
+}
+
+// Timeout returns the timeout for the specified round
+func (e ExponentialTimeout) Timeout(round uint64) time.Duration {
+	limit := timeoutLimit(e.Max)
+
+	if e.Base <= 0 {
+		return 0
+	}
+
+	// Base * 2^round exceeds the limit (or overflows)
+	// if Base is larger than limit / 2^round
+	if round >= 63 || e.Base > limit>>round {
+		return limit
+	}
+
+	return e.Base << round
+}
+
+// LinearTimeout is the round timeout policy that increases
+// the base timeout by a fixed amount with each round
+// (Base + round * Increment), capped at Max
+type LinearTimeout struct {
+	// Base is the timeout of round 0
+	Base time.Duration
+
+	// Increment is the amount the timeout is increased by each round
+	Increment time.Duration
+
+	// Max is the timeout cap. Zero means the timeout is
+	// only capped at the largest representable duration
+	Max time.Duration
+}
+
+// Timeout returns the timeout for the specified round
+func (l LinearTimeout) Timeout(round uint64) time.Duration {
+	limit := timeoutLimit(l.Max)
+
+	if l.Base <= 0 && l.Increment <= 0 {
+		return 0
+	}
+
+	base := l.Base
+	if base < 0 {
+		base = 0
+	}
+
+	if base >= limit {
+		return limit
+	}
+
+	if l.Increment <= 0 {
+		return base
+	}
+
+	// round * Increment exceeds the remaining headroom
+	if round > uint64((limit-base)/l.Increment) {
+		return limit
+	}
+
+	return base + time.Duration(round)*l.Increment
+}
+
+// ConstantTimeout is the round timeout policy
+// that uses the same timeout for every round
+type ConstantTimeout time.Duration
+
+// Timeout returns the timeout for the specified round
+func (c ConstantTimeout) Timeout(_ uint64) time.Duration {
+	if c < 0 {
+		return 0
+	}
+
+	return time.Duration(c)
+}
+
+// JitteredTimeout is the round timeout policy that adds a random jitter
+// on top of another policy, so that nodes don't time out in lockstep
+type JitteredTimeout struct {
+	// policy is the wrapped round timeout policy
+	policy RoundTimeoutPolicy
+
+	// rng is the jitter source
+	rng *rand.Rand
+
+	// fraction is the maximum jitter, as a fraction of the timeout
+	fraction float64
+
+	mux sync.Mutex
+}
+
+// NewJitteredTimeout creates a new round timeout policy that adds a random jitter
+// in [0, fraction * timeout) to the timeout of the wrapped policy.
+// The fraction is clamped to [0, 1]
+func NewJitteredTimeout(policy RoundTimeoutPolicy, fraction float64) *JitteredTimeout {
+	switch {
+	case fraction < 0, math.IsNaN(fraction):
+		fraction = 0
+	case fraction > 1:
+		fraction = 1
+	}
+
+	return &JitteredTimeout{
+		policy:   policy,
+		fraction: fraction,
+		//nolint:gosec // The jitter does not need to be cryptographically secure
+		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Timeout returns the timeout for the specified round
+func (j *JitteredTimeout) Timeout(round uint64) time.Duration {
+	timeout := j.policy.Timeout(round)
+
+	j.mux.Lock()
+	factor := j.rng.Float64()
+	j.mux.Unlock()
+
+	jitter := float64(timeout) * j.fraction * factor
+
+	// Saturate instead of overflowing
+	if jitter >= float64(maxRoundTimeout-timeout) {
+		return maxRoundTimeout
+	}
+
+	return timeout + time.Duration(jitter)
+}
+
+// timeoutLimit returns the effective timeout cap
+func timeoutLimit(limit time.Duration) time.Duration {
+	if limit <= 0 {
+		return maxRoundTimeout
+	}
+
+	return limit
+}
+
+// addTimeouts adds up the timeouts, saturating instead of overflowing
+func addTimeouts(a, b time.Duration) time.Duration {
+	if b > 0 && a > maxRoundTimeout-b {
+		return maxRoundTimeout
+	}
+
+	return a + b
+}
@@ -0,0 +1,216 @@
+package core
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// highRounds are the rounds at which naive
+// timeout calculations overflow
+var highRounds = []uint64{
+	31,
+	32,
+	62,
+	63,
+	64,
+	100,
+	1000,
+	math.MaxUint32,
+	math.MaxUint64,
+}
+
+// TestExponentialTimeout makes sure the exponential
+// timeout doubles with each round, and is capped
+func TestExponentialTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("timeout doubles with each round", func(t *testing.T) {
+		t.Parallel()
+
+		policy := ExponentialTimeout{Base: time.Second}
+
+		assert.Equal(t, time.Second, policy.Timeout(0))
+		assert.Equal(t, 2*time.Second, policy.Timeout(1))
+		assert.Equal(t, 8*time.Second, policy.Timeout(3))
+		assert.Equal(t, 1024*time.Second, policy.Timeout(10))
+	})
+
+	t.Run("timeout is capped", func(t *testing.T) {
+		t.Parallel()
+
+		policy := ExponentialTimeout{
+			Base: time.Second,
+			Max:  time.Minute,
+		}
+
+		assert.Equal(t, 32*time.Second, policy.Timeout(5))
+		assert.Equal(t, time.Minute, policy.Timeout(6))
+
+		for _, round := range highRounds {
+			assert.Equal(t, time.Minute, policy.Timeout(round), "round %d", round)
+		}
+	})
+
+	t.Run("uncapped timeout saturates for high rounds", func(t *testing.T) {
+		t.Parallel()
+
+		policy := ExponentialTimeout{Base: 10 * time.Second}
+
+		previous := policy.Timeout(0)
+
+		for round := uint64(1); round <= 200; round++ {
+			timeout := policy.Timeout(round)
+
+			// Make sure the timeout never decreases, or overflows
+			assert.GreaterOrEqual(t, timeout, previous, "round %d", round)
+
+			previous = timeout
+		}
+
+		for _, round := range highRounds {
+			assert.Equal(t, maxRoundTimeout, policy.Timeout(round), "round %d", round)
+		}
+	})
+
+	t.Run("base timeout above the cap", func(t *testing.T) {
+		t.Parallel()
+
+		policy := ExponentialTimeout{
+			Base: time.Hour,
+			Max:  time.Minute,
+		}
+
+		assert.Equal(t, time.Minute, policy.Timeout(0))
+	})
+}
+
+// TestLinearTimeout makes sure the linear timeout
+// grows by the increment with each round, and is capped
+func TestLinearTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("timeout grows linearly", func(t *testing.T) {
+		t.Parallel()
+
+		policy := LinearTimeout{
+			Base:      10 * time.Second,
+			Increment: 5 * time.Second,
+		}
+
+		assert.Equal(t, 10*time.Second, policy.Timeout(0))
+		assert.Equal(t, 15*time.Second, policy.Timeout(1))
+		assert.Equal(t, 60*time.Second, policy.Timeout(10))
+
+		// Make sure the timeout never decreases, or overflows
+		previous := policy.Timeout(0)
+
+		for _, round := range highRounds {
+			timeout := policy.Timeout(round)
+
+			assert.GreaterOrEqual(t, timeout, previous, "round %d", round)
+
+			previous = timeout
+		}
+
+		assert.Equal(t, 1000*5*time.Second+10*time.Second, policy.Timeout(1000))
+		assert.Equal(t, maxRoundTimeout, policy.Timeout(math.MaxUint32))
+		assert.Equal(t, maxRoundTimeout, policy.Timeout(math.MaxUint64))
+	})
+
+	t.Run("timeout is capped", func(t *testing.T) {
+		t.Parallel()
+
+		policy := LinearTimeout{
+			Base:      10 * time.Second,
+			Increment: 5 * time.Second,
+			Max:       30 * time.Second,
+		}
+
+		assert.Equal(t, 30*time.Second, policy.Timeout(4))
+		assert.Equal(t, 30*time.Second, policy.Timeout(5))
+
+		for _, round := range highRounds {
+			assert.Equal(t, 30*time.Second, policy.Timeout(round), "round %d", round)
+		}
+	})
+
+	t.Run("zero increment", func(t *testing.T) {
+		t.Parallel()
+
+		policy := LinearTimeout{Base: time.Second}
+
+		assert.Equal(t, time.Second, policy.Timeout(math.MaxUint64))
+	})
+}
+
+// TestConstantTimeout makes sure the constant
+// timeout is the same for every round
+func TestConstantTimeout(t *testing.T) {
+	t.Parallel()
+
+	policy := ConstantTimeout(3 * time.Second)
+
+	assert.Equal(t, 3*time.Second, policy.Timeout(0))
+
+	for _, round := range highRounds {
+		assert.Equal(t, 3*time.Second, policy.Timeout(round), "round %d", round)
+	}
+
+	// Make sure negative timeouts are not returned
+	assert.Equal(t, time.Duration(0), ConstantTimeout(-time.Second).Timeout(0))
+}
+
+// TestJitteredTimeout makes sure the jitter
+// stays within the configured bounds
+func TestJitteredTimeout(t *testing.T) {
+	t.Parallel()
+
+	t.Run("jitter is within bounds", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			base   = 10 * time.Second
+			policy = NewJitteredTimeout(ConstantTimeout(base), 0.5)
+		)
+
+		for i := 0; i < 1000; i++ {
+			timeout := policy.Timeout(uint64(i))
+
+			assert.GreaterOrEqual(t, timeout, base)
+			assert.Less(t, timeout, base+base/2)
+		}
+	})
+
+	t.Run("jitter saturates for high rounds", func(t *testing.T) {
+		t.Parallel()
+
+		policy := NewJitteredTimeout(ExponentialTimeout{Base: time.Second}, 1)
+
+		for _, round := range highRounds {
+			timeout := policy.Timeout(round)
+
+			assert.Greater(t, timeout, time.Duration(0), "round %d", round)
+		}
+	})
+
+	t.Run("fraction is clamped", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, 0.0, NewJitteredTimeout(ConstantTimeout(0), -1).fraction)
+		assert.Equal(t, 0.0, NewJitteredTimeout(ConstantTimeout(0), math.NaN()).fraction)
+		assert.Equal(t, 1.0, NewJitteredTimeout(ConstantTimeout(0), 2).fraction)
+	})
+}
+
+// TestAddTimeouts makes sure the additional
+// timeout does not overflow the round timeout
+func TestAddTimeouts(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 3*time.Second, addTimeouts(time.Second, 2*time.Second))
+	assert.Equal(t, maxRoundTimeout, addTimeouts(maxRoundTimeout, time.Second))
+	assert.Equal(t, maxRoundTimeout, addTimeouts(maxRoundTimeout-time.Second, time.Hour))
+}
@@ -5,6 +5,7 @@ import "github.com/madz-lab/go-ibft/messages/proto"
 // Transport defines an interface
 // the node uses to communicate with other peers
 type Transport interface {
-	// Multicast multicasts the message to other peers
+	// Multicast multicasts the message to other peers.
+	// If retransmission is enabled, it can be called concurrently
 	Multicast(message *proto.Message)
 }
@@ -0,0 +1,263 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// DefaultMaxMessageSize is the default size limit of incoming messages, in bytes
+const DefaultMaxMessageSize = 32 * 1024 * 1024
+
+// maxMessageDepth is the maximum nesting depth of messages within certificates
+// that is validated. Certificates of the PREPREPARE messages in prepared certificates
+// are never inspected by the state machine, so deeper messages are only bounded by size
+const maxMessageDepth = 2
+
+const (
+	// DefaultMaxHeightsAhead is the default number of heights above the
+	// current one that incoming messages are accepted for
+	DefaultMaxHeightsAhead = 10
+
+	// DefaultMaxRoundsAhead is the default number of rounds above the
+	// current one that incoming messages are accepted for
+	DefaultMaxRoundsAhead = 10
+
+	// DefaultMaxRoundChangeRoundsAhead is the default number of rounds above the
+	// current one that ROUND_CHANGE messages for the current height are accepted for
+	DefaultMaxRoundChangeRoundsAhead = 100
+)
+
+var (
+	// ErrMalformedPayload is returned for messages that are structurally invalid
+	ErrMalformedPayload = errors.New("malformed message payload")
+
+	// ErrMessageTooLarge is returned for messages over the size limit
+	ErrMessageTooLarge = errors.New("message too large")
+
+	// ErrInvalidSender is returned for messages from senders the backend rejects
+	ErrInvalidSender = errors.New("invalid message sender")
+
+	// ErrStaleHeight is returned for messages for heights below the current one
+	ErrStaleHeight = errors.New("message for a stale height")
+
+	// ErrOldRound is returned for messages for rounds
+	// below the current one, at the current height
+	ErrOldRound = errors.New("message for an old round")
+
+	// ErrFutureHeight is returned for messages for heights
+	// beyond the window of heights ahead of the current one
+	ErrFutureHeight = errors.New("message for a height too far ahead")
+
+	// ErrFutureRound is returned for messages for rounds
+	// beyond the window of rounds ahead of the current one
+	ErrFutureRound = errors.New("message for a round too far ahead")
+)
+
+// DropReason is the cause of an incoming message rejection
+type DropReason uint8
+
+const (
+	// DropMalformed is the rejection of a structurally invalid message
+	DropMalformed DropReason = iota
+
+	// DropTooLarge is the rejection of a message over the size limit
+	DropTooLarge
+
+	// DropInvalidSender is the rejection of a message from an invalid sender
+	DropInvalidSender
+
+	// DropStaleHeight is the rejection of a message for a stale height
+	DropStaleHeight
+
+	// DropOldRound is the rejection of a message for an old round
+	DropOldRound
+
+	// DropFutureHeight is the rejection of a message
+	// for a height beyond the window of heights ahead
+	DropFutureHeight
+
+	// DropFutureRound is the rejection of a message
+	// for a round beyond the window of rounds ahead
+	DropFutureRound
+)
+
+func (r DropReason) String() string {
+	switch r {
+	case DropMalformed:
+		return "malformed"
+	case DropTooLarge:
+		return "too large"
+	case DropInvalidSender:
+		return "invalid sender"
+	case DropStaleHeight:
+		return "stale height"
+	case DropOldRound:
+		return "old round"
+	case DropFutureHeight:
+		return "future height"
+	case DropFutureRound:
+		return "future round"
+	}
+
+	return ""
+}
+
+// dropReason returns the drop reason for the AddMessage rejection error
+func dropReason(err error) DropReason {
+	switch {
+	case errors.Is(err, ErrMessageTooLarge):
+		return DropTooLarge
+	case errors.Is(err, ErrInvalidSender):
+		return DropInvalidSender
+	case errors.Is(err, ErrStaleHeight):
+		return DropStaleHeight
+	case errors.Is(err, ErrOldRound):
+		return DropOldRound
+	case errors.Is(err, ErrFutureHeight):
+		return DropFutureHeight
+	case errors.Is(err, ErrFutureRound):
+		return DropFutureRound
+	default:
+		return DropMalformed
+	}
+}
+
+// ValidateMessage checks that the message is structurally valid: the view and the sender
+// are set, the payload matches the message type, and the required payload fields are present.
+// Messages nested in certificates are validated as well. A positive size limit bounds the
+// encoded size of the message. It doesn't verify signatures, senders or the message view
+func ValidateMessage(message *proto.Message, maxSize int) error {
+	if err := validateMessage(message, 0); err != nil {
+		return err
+	}
+
+	if maxSize > 0 {
+		if size := protobuf.Size(message); size > maxSize {
+			return fmt.Errorf("%w: %d bytes, the limit is %d", ErrMessageTooLarge, size, maxSize)
+		}
+	}
+
+	return nil
+}
+
+// validateMessage validates the message structure,
+// at the specified certificate nesting depth
+func validateMessage(message *proto.Message, depth int) error {
+	switch {
+	case message == nil:
+		return fmt.Errorf("%w: message is not set", ErrMalformedPayload)
+	case depth > maxMessageDepth:
+		return nil
+	case message.View == nil:
+		return fmt.Errorf("%w: view is not set", ErrMalformedPayload)
+	case len(message.From) == 0:
+		return fmt.Errorf("%w: sender is not set", ErrMalformedPayload)
+	}
+
+	switch message.Type {
+	case proto.MessageType_PREPREPARE:
+		return validatePreprepare(message.GetPreprepareData(), depth)
+	case proto.MessageType_PREPARE:
+		return validatePrepare(message.GetPrepareData())
+	case proto.MessageType_COMMIT:
+		return validateCommit(message.GetCommitData())
+	case proto.MessageType_ROUND_CHANGE:
+		return validateRoundChange(message.GetRoundChangeData(), depth)
+	default:
+		return fmt.Errorf("%w: unknown message type %d", ErrMalformedPayload, message.Type)
+	}
+}
+
+// validatePreprepare validates the PREPREPARE payload
+func validatePreprepare(data *proto.PrePrepareMessage, depth int) error {
+	switch {
+	case data == nil:
+		return fmt.Errorf("%w: payload doesn't match the PREPREPARE type", ErrMalformedPayload)
+	case len(data.Proposal) == 0:
+		return fmt.Errorf("%w: proposal is not set", ErrMalformedPayload)
+	case len(data.ProposalHash) == 0:
+		return fmt.Errorf("%w: proposal hash is not set", ErrMalformedPayload)
+	case data.Certificate == nil:
+		return nil
+	}
+
+	for _, message := range data.Certificate.RoundChangeMessages {
+		if err := validateNestedMessage(message, proto.MessageType_ROUND_CHANGE, depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePrepare validates the PREPARE payload
+func validatePrepare(data *proto.PrepareMessage) error {
+	switch {
+	case data == nil:
+		return fmt.Errorf("%w: payload doesn't match the PREPARE type", ErrMalformedPayload)
+	case len(data.ProposalHash) == 0:
+		return fmt.Errorf("%w: proposal hash is not set", ErrMalformedPayload)
+	}
+
+	return nil
+}
+
+// validateCommit validates the COMMIT payload
+func validateCommit(data *proto.CommitMessage) error {
+	switch {
+	case data == nil:
+		return fmt.Errorf("%w: payload doesn't match the COMMIT type", ErrMalformedPayload)
+	case len(data.ProposalHash) == 0:
+		return fmt.Errorf("%w: proposal hash is not set", ErrMalformedPayload)
+	case len(data.CommittedSeal) == 0:
+		return fmt.Errorf("%w: committed seal is not set", ErrMalformedPayload)
+	}
+
+	return nil
+}
+
+// validateRoundChange validates the ROUND_CHANGE payload
+func validateRoundChange(data *proto.RoundChangeMessage, depth int) error {
+	if data == nil {
+		return fmt.Errorf("%w: payload doesn't match the ROUND_CHANGE type", ErrMalformedPayload)
+	}
+
+	certificate := data.LatestPreparedCertificate
+	if certificate == nil {
+		return nil
+	}
+
+	if certificate.ProposalMessage == nil {
+		return fmt.Errorf("%w: prepared certificate proposal is not set", ErrMalformedPayload)
+	}
+
+	if err := validateNestedMessage(certificate.ProposalMessage, proto.MessageType_PREPREPARE, depth); err != nil {
+		return err
+	}
+
+	for _, message := range certificate.PrepareMessages {
+		if err := validateNestedMessage(message, proto.MessageType_PREPARE, depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateNestedMessage validates the message
+// nested in a certificate, and its type
+func validateNestedMessage(message *proto.Message, messageType proto.MessageType, depth int) error {
+	if message != nil && message.Type != messageType {
+		return fmt.Errorf(
+			"%w: certificate contains a %s message instead of %s",
+			ErrMalformedPayload,
+			message.Type,
+			messageType,
+		)
+	}
+
+	return validateMessage(message, depth+1)
+}
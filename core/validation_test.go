@@ -0,0 +1,353 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// TestValidateMessage makes sure structurally
+// invalid messages are rejected
+func TestValidateMessage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view         = &proto.View{Height: 1, Round: 1}
+		from         = []byte("node 0")
+		proposal     = []byte("proposal")
+		proposalHash = []byte("proposal hash")
+
+		prepare    = buildBasicPrepareMessage(proposalHash, from, view)
+		preprepare = buildBasicPreprepareMessage(proposal, proposalHash, nil, from, view)
+		pc         = &proto.PreparedCertificate{
+			ProposalMessage: preprepare,
+			PrepareMessages: []*proto.Message{prepare},
+		}
+		roundChange = buildBasicRoundChangeMessage(proposal, pc, view, from)
+	)
+
+	// modify returns a copy of the message, modified by the callback
+	modify := func(message *proto.Message, modifyFn func(*proto.Message)) *proto.Message {
+		//nolint:forcetypeassert // The clone is always a message
+		clone := protobuf.Clone(message).(*proto.Message)
+		modifyFn(clone)
+
+		return clone
+	}
+
+	testTable := []struct {
+		name        string
+		message     *proto.Message
+		maxSize     int
+		expectedErr error
+	}{
+		{
+			"valid PREPREPARE",
+			buildBasicPreprepareMessage(
+				proposal,
+				proposalHash,
+				&proto.RoundChangeCertificate{RoundChangeMessages: []*proto.Message{roundChange}},
+				from,
+				view,
+			),
+			0,
+			nil,
+		},
+		{
+			"valid PREPARE",
+			prepare,
+			0,
+			nil,
+		},
+		{
+			"valid COMMIT",
+			buildBasicCommitMessage(proposalHash, []byte("seal"), from, view),
+			0,
+			nil,
+		},
+		{
+			"valid ROUND_CHANGE",
+			roundChange,
+			0,
+			nil,
+		},
+		{
+			"valid ROUND_CHANGE without a prepared certificate",
+			buildBasicRoundChangeMessage(nil, nil, view, from),
+			0,
+			nil,
+		},
+		{
+			"nil message",
+			nil,
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"view not set",
+			modify(prepare, func(m *proto.Message) { m.View = nil }),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"sender not set",
+			modify(prepare, func(m *proto.Message) { m.From = nil }),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"unknown message type",
+			modify(prepare, func(m *proto.Message) { m.Type = proto.MessageType(100) }),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"payload doesn't match the type",
+			modify(prepare, func(m *proto.Message) { m.Type = proto.MessageType_COMMIT }),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"payload not set",
+			modify(prepare, func(m *proto.Message) { m.Payload = nil }),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"empty proposal",
+			buildBasicPreprepareMessage(nil, proposalHash, nil, from, view),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"empty PREPREPARE proposal hash",
+			buildBasicPreprepareMessage(proposal, nil, nil, from, view),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"empty PREPARE proposal hash",
+			buildBasicPrepareMessage(nil, from, view),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"empty COMMIT proposal hash",
+			buildBasicCommitMessage(nil, []byte("seal"), from, view),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"empty committed seal",
+			buildBasicCommitMessage(proposalHash, nil, from, view),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"prepared certificate without a proposal",
+			buildBasicRoundChangeMessage(
+				proposal,
+				&proto.PreparedCertificate{PrepareMessages: []*proto.Message{prepare}},
+				view,
+				from,
+			),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"prepared certificate with a wrong message type",
+			buildBasicRoundChangeMessage(
+				proposal,
+				&proto.PreparedCertificate{
+					ProposalMessage: preprepare,
+					PrepareMessages: []*proto.Message{preprepare},
+				},
+				view,
+				from,
+			),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"prepared certificate with a malformed message",
+			buildBasicRoundChangeMessage(
+				proposal,
+				&proto.PreparedCertificate{
+					ProposalMessage: preprepare,
+					PrepareMessages: []*proto.Message{buildBasicPrepareMessage(nil, from, view)},
+				},
+				view,
+				from,
+			),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"round change certificate with a nil message",
+			buildBasicPreprepareMessage(
+				proposal,
+				proposalHash,
+				&proto.RoundChangeCertificate{RoundChangeMessages: []*proto.Message{nil}},
+				from,
+				view,
+			),
+			0,
+			ErrMalformedPayload,
+		},
+		{
+			"message over the size limit",
+			buildBasicPreprepareMessage(bytes.Repeat([]byte{1}, 1024), proposalHash, nil, from, view),
+			1024,
+			ErrMessageTooLarge,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateMessage(testCase.message, testCase.maxSize)
+
+			if testCase.expectedErr == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+// TestIBFT_AddMessage_Rejected makes sure rejected messages
+// are not added, and the rejection reason is returned
+func TestIBFT_AddMessage_Rejected(t *testing.T) {
+	t.Parallel()
+
+	var (
+		from  = []byte("node 0")
+		added = make([]*proto.Message, 0)
+
+		backend = mockBackend{
+			isValidSenderFn: func(message *proto.Message) bool {
+				return bytes.Equal(message.From, from)
+			},
+		}
+		store = mockMessages{
+			addMessageFn: func(message *proto.Message) {
+				added = append(added, message)
+			},
+		}
+	)
+
+	metrics := &recordingMetrics{}
+
+	i := newTestIBFT(
+		t,
+		mockLogger{},
+		backend,
+		mockTransport{},
+		WithMessages(store),
+		WithMaxMessageSize(1024),
+		WithMessageWindow(2, 3),
+		WithRoundChangeWindow(5),
+		WithMetrics(metrics),
+	)
+	i.state.setView(&proto.View{Height: 5, Round: 2})
+
+	testTable := []struct {
+		message     *proto.Message
+		expectedErr error
+	}{
+		{
+			buildBasicPrepareMessage(nil, from, &proto.View{Height: 5, Round: 2}),
+			ErrMalformedPayload,
+		},
+		{
+			buildBasicPreprepareMessage(
+				bytes.Repeat([]byte{1}, 1024),
+				[]byte("proposal hash"),
+				nil,
+				from,
+				&proto.View{Height: 5, Round: 2},
+			),
+			ErrMessageTooLarge,
+		},
+		{
+			buildBasicPrepareMessage([]byte("proposal hash"), []byte("node 1"), &proto.View{Height: 5, Round: 2}),
+			ErrInvalidSender,
+		},
+		{
+			buildBasicPrepareMessage([]byte("proposal hash"), from, &proto.View{Height: 4, Round: 2}),
+			ErrStaleHeight,
+		},
+		{
+			buildBasicPrepareMessage([]byte("proposal hash"), from, &proto.View{Height: 5, Round: 1}),
+			ErrOldRound,
+		},
+		{
+			buildBasicPrepareMessage([]byte("proposal hash"), from, &proto.View{Height: 8, Round: 0}),
+			ErrFutureHeight,
+		},
+		{
+			buildBasicPrepareMessage([]byte("proposal hash"), from, &proto.View{Height: 5, Round: 6}),
+			ErrFutureRound,
+		},
+		{
+			buildBasicPrepareMessage([]byte("proposal hash"), from, &proto.View{Height: 6, Round: 4}),
+			ErrFutureRound,
+		},
+		{
+			buildBasicRoundChangeMessage(nil, nil, &proto.View{Height: 5, Round: 8}, from),
+			ErrFutureRound,
+		},
+		{
+			buildBasicRoundChangeMessage(nil, nil, &proto.View{Height: 6, Round: 4}, from),
+			ErrFutureRound,
+		},
+	}
+
+	for _, testCase := range testTable {
+		assert.ErrorIs(t, i.AddMessage(testCase.message), testCase.expectedErr)
+	}
+
+	assert.Empty(t, added)
+
+	// Make sure the rejections are reported, by reason
+	assert.Equal(
+		t,
+		[]DropReason{
+			DropMalformed,
+			DropTooLarge,
+			DropInvalidSender,
+			DropStaleHeight,
+			DropOldRound,
+			DropFutureHeight,
+			DropFutureRound,
+			DropFutureRound,
+			DropFutureRound,
+			DropFutureRound,
+		},
+		metrics.dropped,
+	)
+
+	// Make sure valid messages are added
+	valid := []*proto.Message{
+		buildBasicCommitMessage([]byte("proposal hash"), []byte("seal"), from, &proto.View{Height: 5, Round: 3}),
+		buildBasicPrepareMessage([]byte("proposal hash"), from, &proto.View{Height: 5, Round: 5}),
+		buildBasicPrepareMessage([]byte("proposal hash"), from, &proto.View{Height: 7, Round: 3}),
+		buildBasicRoundChangeMessage(nil, nil, &proto.View{Height: 5, Round: 7}, from),
+	}
+
+	for _, message := range valid {
+		require.NoError(t, i.AddMessage(message))
+	}
+
+	assert.Equal(t, valid, added)
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bytes"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// VotingPowerProvider provides the voting power of validators,
+// for chains with stake-weighted validator sets.
+// Quorums are reached once the accumulated voting power of the message senders
+// exceeds 2/3 of the total voting power, and f+1 thresholds once it exceeds 1/3
+type VotingPowerProvider interface {
+	// VotingPower returns the voting power of the sender at the specified height.
+	// Senders that are not part of the validator set have no voting power
+	VotingPower(height uint64, from []byte) uint64
+
+	// TotalVotingPower returns the total voting power
+	// of the validator set at the specified height
+	TotalVotingPower(height uint64) uint64
+}
+
+// quorumVotingPower returns the voting power
+// needed for a quorum, floor(2 * total / 3) + 1
+func quorumVotingPower(total uint64) uint64 {
+	// 2 * total / 3 is split up so it can't overflow
+	return 2*(total/3) + (2*(total%3))/3 + 1
+}
+
+// weakQuorumVotingPower returns the voting power that guarantees
+// at least one honest validator, floor(total / 3) + 1
+func weakQuorumVotingPower(total uint64) uint64 {
+	return total/3 + 1
+}
+
+// quorum returns the voting power needed for a quorum at the height.
+// Without a voting power provider, each validator has a voting power
+// of 1, and the quorum is determined by the backend
+func (i *IBFT) quorum(height uint64) uint64 {
+	if i.votingPowerProvider == nil {
+		return i.backend.Quorum(height)
+	}
+
+	return quorumVotingPower(i.votingPowerProvider.TotalVotingPower(height))
+}
+
+// weakQuorum returns the voting power of f+1 validators at the height,
+// that guarantees at least one of them is honest
+func (i *IBFT) weakQuorum(height uint64) uint64 {
+	if i.votingPowerProvider == nil {
+		return i.backend.MaximumFaultyNodes() + 1
+	}
+
+	return weakQuorumVotingPower(i.votingPowerProvider.TotalVotingPower(height))
+}
+
+// votingPower returns the voting power of the sender at the height
+func (i *IBFT) votingPower(height uint64, from []byte) uint64 {
+	if i.votingPowerProvider == nil {
+		return 1
+	}
+
+	return i.votingPowerProvider.VotingPower(height, from)
+}
+
+// votingPowerFn returns the voting power function for
+// message subscriptions at the height. Without a voting power
+// provider, subscriptions count each sender once
+func (i *IBFT) votingPowerFn(height uint64) func(from []byte) uint64 {
+	if i.votingPowerProvider == nil {
+		return nil
+	}
+
+	return func(from []byte) uint64 {
+		return i.votingPowerProvider.VotingPower(height, from)
+	}
+}
+
+// accumulatedVotingPower returns the accumulated voting power of the
+// message senders at the height. Each sender is counted only once
+func (i *IBFT) accumulatedVotingPower(height uint64, msgs []*proto.Message) uint64 {
+	var (
+		power   uint64
+		senders = make(map[string]struct{}, len(msgs))
+	)
+
+	for _, msg := range msgs {
+		from := string(msg.GetFrom())
+		if _, counted := senders[from]; counted {
+			continue
+		}
+
+		senders[from] = struct{}{}
+		power += i.votingPower(height, msg.GetFrom())
+	}
+
+	return power
+}
+
+// proposerVotingPower returns the voting power of
+// the sender of the accepted proposal at the height
+func (i *IBFT) proposerVotingPower(height uint64) uint64 {
+	return i.votingPower(height, i.state.getProposalMessage().GetFrom())
+}
+
+// excludeSender returns the messages that are not from the sender
+func excludeSender(msgs []*proto.Message, from []byte) []*proto.Message {
+	filtered := make([]*proto.Message, 0, len(msgs))
+
+	for _, msg := range msgs {
+		if !bytes.Equal(msg.GetFrom(), from) {
+			filtered = append(filtered, msg)
+		}
+	}
+
+	return filtered
+}
+
+// saturatingSub returns a - b, or 0 if b is larger than a
+func saturatingSub(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+
+	return a - b
+}
@@ -0,0 +1,316 @@
+package core
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockVotingPowerProvider is the voting power
+// provider with a fixed power per sender
+type mockVotingPowerProvider map[string]uint64
+
+func (m mockVotingPowerProvider) VotingPower(_ uint64, from []byte) uint64 {
+	return m[string(from)]
+}
+
+func (m mockVotingPowerProvider) TotalVotingPower(_ uint64) uint64 {
+	var total uint64
+
+	for _, power := range m {
+		total += power
+	}
+
+	return total
+}
+
+// TestQuorumVotingPower makes sure the quorum and f+1
+// voting power thresholds are calculated correctly
+func TestQuorumVotingPower(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		total              uint64
+		expectedQuorum     uint64
+		expectedWeakQuorum uint64
+	}{
+		{0, 1, 1},
+		{1, 1, 1},
+		{3, 3, 2},
+		{4, 3, 2},
+		{100, 67, 34},
+		{math.MaxUint64, math.MaxUint64/3*2 + 1, math.MaxUint64/3 + 1},
+	}
+
+	for _, testCase := range testTable {
+		assert.Equal(t, testCase.expectedQuorum, quorumVotingPower(testCase.total), testCase.total)
+		assert.Equal(t, testCase.expectedWeakQuorum, weakQuorumVotingPower(testCase.total), testCase.total)
+	}
+}
+
+// TestIBFT_VotingPower makes sure equal weights are the default,
+// and the voting power provider is used if set
+func TestIBFT_VotingPower(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height  = uint64(1)
+		backend = mockBackend{
+			quorumFn: func(_ uint64) uint64 {
+				return 3
+			},
+			maximumFaultyNodesFn: func() uint64 {
+				return 1
+			},
+		}
+		msgs = []*proto.Message{
+			{From: []byte("node 0")},
+			{From: []byte("node 1")},
+		}
+	)
+
+	t.Run("equal weights", func(t *testing.T) {
+		t.Parallel()
+
+		i := newTestIBFT(t, mockLogger{}, backend, mockTransport{})
+
+		assert.Equal(t, uint64(3), i.quorum(height))
+		assert.Equal(t, uint64(2), i.weakQuorum(height))
+		assert.Equal(t, uint64(1), i.votingPower(height, []byte("node 0")))
+		assert.Equal(t, uint64(2), i.accumulatedVotingPower(height, msgs))
+		assert.Nil(t, i.votingPowerFn(height))
+	})
+
+	t.Run("stake weighted", func(t *testing.T) {
+		t.Parallel()
+
+		provider := mockVotingPowerProvider{
+			"node 0": 60,
+			"node 1": 30,
+			"node 2": 10,
+		}
+
+		i := newTestIBFT(t, mockLogger{}, backend, mockTransport{}, WithVotingPowerProvider(provider))
+
+		assert.Equal(t, uint64(67), i.quorum(height))
+		assert.Equal(t, uint64(34), i.weakQuorum(height))
+		assert.Equal(t, uint64(60), i.votingPower(height, []byte("node 0")))
+		assert.Equal(t, uint64(0), i.votingPower(height, []byte("unknown")))
+		assert.Equal(t, uint64(90), i.accumulatedVotingPower(height, msgs))
+
+		// Each sender is counted only once
+		assert.Equal(t, uint64(90), i.accumulatedVotingPower(height, append(msgs, msgs[0])))
+		assert.Equal(t, uint64(30), i.votingPowerFn(height)([]byte("node 1")))
+	})
+}
+
+// TestIBFT_GetRoundSkip_VotingPower makes sure round skips
+// are based on the voting power of the senders
+func TestIBFT_GetRoundSkip_VotingPower(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height   = uint64(1)
+		provider = mockVotingPowerProvider{
+			"node 0": 10,
+			"node 1": 10,
+			"node 2": 30,
+			"node 3": 50,
+		}
+		messages = mockMessages{
+			getRoundChangeMessagesFn: func(_, _ uint64) []*proto.Message {
+				return buildRoundChanges(height, map[string][]uint64{
+					"node 1": {8},
+					"node 2": {5},
+					"node 3": {3},
+				})
+			},
+		}
+	)
+
+	i := newTestIBFT(
+		t,
+		mockLogger{},
+		mockBackend{},
+		mockTransport{},
+		WithMessages(messages),
+		WithVotingPowerProvider(provider),
+	)
+
+	// Nodes 1 and 2 have 40 voting power, which is over 1/3 of the total
+	assert.Equal(t, uint64(5), i.getRoundSkip(height, 1))
+}
+
+// TestConsensus_VotingPower makes sure the cluster finalizes the height
+// once a quorum of voting power is reached, even though the number of
+// participating validators is lower than the equal weights quorum
+func TestConsensus_VotingPower(t *testing.T) {
+	t.Parallel()
+
+	var (
+		proposal     = []byte("proposal")
+		proposalHash = []byte("proposal hash")
+		numNodes     = uint64(4)
+		nodes        = generateNodeAddresses(numNodes)
+
+		// Nodes 0 and 1 hold 70% of the voting power
+		provider = mockVotingPowerProvider{
+			string(nodes[0]): 40,
+			string(nodes[1]): 30,
+			string(nodes[2]): 20,
+			string(nodes[3]): 10,
+		}
+
+		backendCallbackMap   = make(map[int]backendConfigCallback)
+		transportCallbackMap = make(map[int]transportConfigCallback)
+
+		cluster *mockCluster
+	)
+
+	for index := range nodes {
+		index := index
+
+		// Make sure the equal weights quorum can't be reached
+		backendCallbackMap[index] = clusterBackendCallback(nodes, index, proposal, proposalHash, numNodes)
+		transportCallbackMap[index] = func(transport *mockTransport) {
+			transport.multicastFn = func(message *proto.Message) {
+				// Only nodes 0 and 1 participate
+				if index > 1 {
+					return
+				}
+
+				cluster.pushMessage(message)
+			}
+		}
+	}
+
+	cluster = newMockCluster(t, numNodes, backendCallbackMap, nil, transportCallbackMap)
+	cluster.setVotingPowerProvider(provider)
+
+	cluster.runSequence(1)
+	cluster.awaitCompletion()
+
+	for _, result := range cluster.results {
+		if !assert.NotNil(t, result) {
+			continue
+		}
+
+		assert.Equal(t, uint64(0), result.Round)
+		assert.Equal(t, proposal, result.Proposal)
+		assert.Len(t, result.CommittedSeals, 2)
+		assert.Empty(t, result.RoundChanges)
+	}
+}
+
+// TestIBFT_HandlePrepare_ProposerCountedOnce makes sure the voting power
+// of the proposer is counted only once, even if it also sent a PREPARE
+func TestIBFT_HandlePrepare_ProposerCountedOnce(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view         = &proto.View{Height: 1, Round: 0}
+		proposalHash = []byte("proposal hash")
+
+		// The proposer holds 40 of the total 100 voting power,
+		// so the quorum of 67 needs at least 27 from the others
+		provider = mockVotingPowerProvider{
+			"proposer": 40,
+			"node 1":   20,
+			"node 2":   20,
+			"node 3":   20,
+		}
+
+		proposalMessage = buildBasicPreprepareMessage(
+			[]byte("proposal"),
+			proposalHash,
+			nil,
+			[]byte("proposer"),
+			view,
+		)
+	)
+
+	testTable := []struct {
+		name          string
+		senders       []string
+		quorumReached bool
+	}{
+		{
+			"proposer PREPARE is not counted again",
+			[]string{"proposer", "node 1"},
+			false,
+		},
+		{
+			"quorum of distinct senders",
+			[]string{"proposer", "node 1", "node 2"},
+			true,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			prepares := make([]*proto.Message, 0, len(testCase.senders))
+			for _, sender := range testCase.senders {
+				prepares = append(prepares, buildBasicPrepareMessage(proposalHash, []byte(sender), view))
+			}
+
+			var (
+				backend = mockBackend{
+					isValidProposalHashFn: func(_ []byte, hash []byte) bool {
+						return bytes.Equal(proposalHash, hash)
+					},
+				}
+				messages = mockMessages{
+					getValidMessagesFn: func(
+						_ *proto.View,
+						_ proto.MessageType,
+						isValid func(*proto.Message) bool,
+					) []*proto.Message {
+						valid := make([]*proto.Message, 0, len(prepares))
+
+						for _, message := range prepares {
+							if isValid(message) {
+								valid = append(valid, message)
+							}
+						}
+
+						return valid
+					},
+				}
+			)
+
+			i := newTestIBFT(
+				t,
+				mockLogger{},
+				backend,
+				mockTransport{},
+				WithMessages(messages),
+				WithVotingPowerProvider(provider),
+			)
+			i.state.proposalMessage = proposalMessage
+
+			prepared, err := i.handlePrepare(view, i.quorum(view.Height))
+			require.NoError(t, err)
+			assert.Equal(t, testCase.quorumReached, prepared)
+
+			if !testCase.quorumReached {
+				return
+			}
+
+			// The PREPARE of the proposer is left out of the certificate
+			certificate := i.state.getLatestPC()
+			assert.Len(t, certificate.PrepareMessages, len(testCase.senders)-1)
+
+			for _, message := range certificate.PrepareMessages {
+				assert.NotEqual(t, []byte("proposer"), message.From)
+			}
+		})
+	}
+}
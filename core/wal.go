@@ -0,0 +1,462 @@
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+const (
+	// walRecordState is the record type for state transitions
+	walRecordState byte = iota + 1
+
+	// walRecordMessage is the record type for outgoing messages
+	walRecordMessage
+)
+
+const (
+	// walHeaderSize is the size of the record header (type + payload length)
+	walHeaderSize = 5
+
+	// walChecksumSize is the size of the record checksum
+	walChecksumSize = 4
+
+	// walMaxRecordSize is the maximum size of a record payload
+	walMaxRecordSize = 1 << 28
+)
+
+var errCorruptRecord = errors.New("corrupt WAL record")
+
+// FileWAL is a file-backed write-ahead log that implements
+// the StateStore interface. Each record is synced to disk
+// before the write returns. The log is compacted every time
+// a state for a new height is saved
+type FileWAL struct {
+	// file is the open log file
+	file *os.File
+
+	// path is the location of the log file
+	path string
+
+	// height is the height of the latest state record
+	height uint64
+
+	// hasState is the flag indicating if the log
+	// contains a state record
+	hasState bool
+
+	mux sync.Mutex
+}
+
+// NewFileWAL opens (or creates) the write-ahead log at the specified path.
+// A partially written record at the tail, left behind by a crash,
+// is discarded
+func NewFileWAL(path string) (*FileWAL, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open WAL, %w", err)
+	}
+
+	w := &FileWAL{
+		file: file,
+		path: path,
+	}
+
+	state, offset, err := w.replay()
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	// Drop the torn tail, if any
+	if err := file.Truncate(offset); err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("unable to truncate WAL, %w", err)
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+
+		return nil, fmt.Errorf("unable to seek WAL, %w", err)
+	}
+
+	if state != nil {
+		w.height = state.View.Height
+		w.hasState = true
+	}
+
+	return w, nil
+}
+
+// SaveState persists a consensus state transition
+func (w *FileWAL) SaveState(state *PersistedState) error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	raw, err := marshalPersistedState(state)
+	if err != nil {
+		return err
+	}
+
+	record, err := encodeWALRecord(walRecordState, raw)
+	if err != nil {
+		return err
+	}
+
+	if !w.hasState || w.height != state.View.Height {
+		// New height, older records are no longer needed
+		if err := w.rotate(record); err != nil {
+			return err
+		}
+
+		w.height = state.View.Height
+		w.hasState = true
+
+		return nil
+	}
+
+	return w.append(record)
+}
+
+// SaveMessage persists an outgoing message
+func (w *FileWAL) SaveMessage(message *proto.Message) error {
+	raw, err := protobuf.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("unable to marshal message, %w", err)
+	}
+
+	record, err := encodeWALRecord(walRecordMessage, raw)
+	if err != nil {
+		return err
+	}
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	return w.append(record)
+}
+
+// LoadState loads the latest persisted state, along with
+// the outgoing messages for its height
+func (w *FileWAL) LoadState() (*PersistedState, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	state, _, err := w.replay()
+	if err != nil {
+		return nil, err
+	}
+
+	if state == nil {
+		return nil, ErrStateNotFound
+	}
+
+	return state, nil
+}
+
+// Close closes the underlying log file
+func (w *FileWAL) Close() error {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	return w.file.Close()
+}
+
+// append appends the record to the log, and syncs it to disk
+func (w *FileWAL) append(record []byte) error {
+	if _, err := w.file.Write(record); err != nil {
+		return fmt.Errorf("unable to write WAL record, %w", err)
+	}
+
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("unable to sync WAL, %w", err)
+	}
+
+	return nil
+}
+
+// rotate atomically replaces the log with a new log
+// that contains only the specified record
+func (w *FileWAL) rotate(record []byte) error {
+	tmpPath := w.path + ".tmp"
+
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to create WAL, %w", err)
+	}
+
+	if _, err := tmp.Write(record); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("unable to write WAL record, %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+
+		return fmt.Errorf("unable to sync WAL, %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to close WAL, %w", err)
+	}
+
+	if err := os.Rename(tmpPath, w.path); err != nil {
+		return fmt.Errorf("unable to replace WAL, %w", err)
+	}
+
+	// Make sure the rename itself is durable
+	if dir, err := os.Open(filepath.Dir(w.path)); err == nil {
+		_ = dir.Sync()
+		_ = dir.Close()
+	}
+
+	file, err := os.OpenFile(w.path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open WAL, %w", err)
+	}
+
+	_ = w.file.Close()
+	w.file = file
+
+	return nil
+}
+
+// replay reads the log from the start, and returns the latest state
+// with the messages for its height, and the offset of the last valid record
+func (w *FileWAL) replay() (*PersistedState, int64, error) {
+	var (
+		state  *PersistedState
+		offset int64
+
+		msgs   = make([]*proto.Message, 0)
+		reader = io.NewSectionReader(w.file, 0, 1<<62)
+	)
+
+	for {
+		recordType, payload, size, err := readWALRecord(reader)
+		if err != nil {
+			// The rest of the log is either empty, or a torn write
+			break
+		}
+
+		switch recordType {
+		case walRecordState:
+			decoded, err := unmarshalPersistedState(payload)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if state == nil || state.View.Height != decoded.View.Height {
+				msgs = make([]*proto.Message, 0)
+			}
+
+			state = decoded
+		case walRecordMessage:
+			message := &proto.Message{}
+			if err := protobuf.Unmarshal(payload, message); err != nil {
+				return nil, 0, fmt.Errorf("%w, %s", errCorruptRecord, err.Error())
+			}
+
+			msgs = append(msgs, message)
+		default:
+			return nil, 0, fmt.Errorf("%w, unknown type %d", errCorruptRecord, recordType)
+		}
+
+		offset += size
+	}
+
+	if state == nil {
+		return nil, offset, nil
+	}
+
+	// Only the messages for the current height are relevant
+	for _, message := range msgs {
+		if message.View != nil && message.View.Height == state.View.Height {
+			state.Messages = append(state.Messages, message)
+		}
+	}
+
+	return state, offset, nil
+}
+
+// encodeWALRecord encodes the record as type | length | payload | checksum
+func encodeWALRecord(recordType byte, payload []byte) ([]byte, error) {
+	if len(payload) > walMaxRecordSize {
+		return nil, fmt.Errorf("WAL record too large, %d bytes", len(payload))
+	}
+
+	record := make([]byte, walHeaderSize, walHeaderSize+len(payload)+walChecksumSize)
+
+	record[0] = recordType
+	binary.BigEndian.PutUint32(record[1:walHeaderSize], uint32(len(payload)))
+
+	record = append(record, payload...)
+	record = binary.BigEndian.AppendUint32(record, crc32.ChecksumIEEE(record))
+
+	return record, nil
+}
+
+// readWALRecord reads a single record, and returns
+// its type, payload and total size
+func readWALRecord(reader io.Reader) (byte, []byte, int64, error) {
+	header := make([]byte, walHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return 0, nil, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > walMaxRecordSize {
+		return 0, nil, 0, errCorruptRecord
+	}
+
+	body := make([]byte, int(length)+walChecksumSize)
+
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return 0, nil, 0, err
+	}
+
+	var (
+		payload  = body[:length]
+		checksum = binary.BigEndian.Uint32(body[length:])
+		computed = crc32.Update(crc32.ChecksumIEEE(header), crc32.IEEETable, payload)
+	)
+
+	if checksum != computed {
+		return 0, nil, 0, errCorruptRecord
+	}
+
+	return header[0], payload, int64(walHeaderSize + len(body)), nil
+}
+
+// marshalPersistedState encodes the state as a sequence of optional fields
+func marshalPersistedState(state *PersistedState) ([]byte, error) {
+	raw := []byte{byte(state.Name)}
+
+	for _, message := range []protobuf.Message{
+		state.View,
+		state.ProposalMessage,
+		state.LatestPC,
+	} {
+		field, err := marshalProto(message)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal state, %w", err)
+		}
+
+		raw = appendOptionalField(raw, field)
+	}
+
+	return appendOptionalField(raw, state.LatestPreparedProposedBlock), nil
+}
+
+// unmarshalPersistedState decodes the state encoded with marshalPersistedState
+func unmarshalPersistedState(raw []byte) (*PersistedState, error) {
+	if len(raw) < 1 {
+		return nil, errCorruptRecord
+	}
+
+	var (
+		state = &PersistedState{
+			Name: StateType(raw[0]),
+		}
+
+		fields = make([][]byte, 4)
+		rest   = raw[1:]
+		err    error
+	)
+
+	for index := range fields {
+		if fields[index], rest, err = readOptionalField(rest); err != nil {
+			return nil, err
+		}
+	}
+
+	if fields[0] == nil {
+		return nil, fmt.Errorf("%w, missing view", errCorruptRecord)
+	}
+
+	state.View = &proto.View{}
+	if err := protobuf.Unmarshal(fields[0], state.View); err != nil {
+		return nil, fmt.Errorf("%w, %s", errCorruptRecord, err.Error())
+	}
+
+	if fields[1] != nil {
+		state.ProposalMessage = &proto.Message{}
+		if err := protobuf.Unmarshal(fields[1], state.ProposalMessage); err != nil {
+			return nil, fmt.Errorf("%w, %s", errCorruptRecord, err.Error())
+		}
+	}
+
+	if fields[2] != nil {
+		state.LatestPC = &proto.PreparedCertificate{}
+		if err := protobuf.Unmarshal(fields[2], state.LatestPC); err != nil {
+			return nil, fmt.Errorf("%w, %s", errCorruptRecord, err.Error())
+		}
+	}
+
+	state.LatestPreparedProposedBlock = fields[3]
+
+	return state, nil
+}
+
+// marshalProto marshals the message, returning a nil slice for unset messages
+func marshalProto(message protobuf.Message) ([]byte, error) {
+	if !message.ProtoReflect().IsValid() {
+		return nil, nil
+	}
+
+	raw, err := protobuf.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw == nil {
+		// Set messages with no populated fields are encoded as empty
+		raw = []byte{}
+	}
+
+	return raw, nil
+}
+
+// appendOptionalField appends the field as length + 1 | data,
+// where a zero length prefix denotes an unset field
+func appendOptionalField(raw, field []byte) []byte {
+	if field == nil {
+		return binary.AppendUvarint(raw, 0)
+	}
+
+	raw = binary.AppendUvarint(raw, uint64(len(field))+1)
+
+	return append(raw, field...)
+}
+
+// readOptionalField reads a field encoded with appendOptionalField
+func readOptionalField(raw []byte) ([]byte, []byte, error) {
+	length, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return nil, nil, errCorruptRecord
+	}
+
+	raw = raw[n:]
+
+	if length == 0 {
+		return nil, raw, nil
+	}
+
+	length--
+	if uint64(len(raw)) < length {
+		return nil, nil, errCorruptRecord
+	}
+
+	return raw[:length:length], raw[length:], nil
+}
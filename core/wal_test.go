@@ -0,0 +1,210 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// newTestWAL creates a new WAL in a temporary directory
+func newTestWAL(t *testing.T) (*FileWAL, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "consensus.wal")
+
+	wal, err := NewFileWAL(path)
+	require.NoError(t, err)
+
+	return wal, path
+}
+
+// generatePersistedState generates a persisted state
+// with a prepared lock for the specified view
+func generatePersistedState(height, round uint64) *PersistedState {
+	var (
+		view     = &proto.View{Height: height, Round: round}
+		proposal = []byte("proposal")
+
+		proposalMessage = buildBasicPreprepareMessage(
+			proposal,
+			[]byte("proposal hash"),
+			nil,
+			[]byte("proposer"),
+			view,
+		)
+	)
+
+	return &PersistedState{
+		View:            view,
+		ProposalMessage: proposalMessage,
+		LatestPC: &proto.PreparedCertificate{
+			ProposalMessage: proposalMessage,
+			PrepareMessages: []*proto.Message{
+				buildBasicPrepareMessage([]byte("proposal hash"), []byte("node"), view),
+			},
+		},
+		LatestPreparedProposedBlock: proposal,
+		Name:                        StateCommit,
+	}
+}
+
+// assertStatesEqual makes sure the persisted states match
+func assertStatesEqual(t *testing.T, expected, actual *PersistedState) {
+	t.Helper()
+
+	assert.True(t, protobuf.Equal(expected.View, actual.View))
+	assert.True(t, protobuf.Equal(expected.ProposalMessage, actual.ProposalMessage))
+	assert.True(t, protobuf.Equal(expected.LatestPC, actual.LatestPC))
+	assert.Equal(t, expected.LatestPreparedProposedBlock, actual.LatestPreparedProposedBlock)
+	assert.Equal(t, expected.Name, actual.Name)
+}
+
+// TestFileWAL_SaveLoad makes sure the persisted
+// state is loaded correctly after a restart
+func TestFileWAL_SaveLoad(t *testing.T) {
+	t.Parallel()
+
+	wal, path := newTestWAL(t)
+
+	// Make sure there is no state initially
+	_, err := wal.LoadState()
+	assert.ErrorIs(t, err, ErrStateNotFound)
+
+	var (
+		initialState = &PersistedState{
+			View: &proto.View{Height: 1, Round: 0},
+			Name: StateNewRound,
+		}
+		lockedState = generatePersistedState(1, 1)
+		message     = buildBasicCommitMessage(
+			[]byte("proposal hash"),
+			[]byte("seal"),
+			[]byte("node"),
+			lockedState.View,
+		)
+	)
+
+	require.NoError(t, wal.SaveState(initialState))
+	require.NoError(t, wal.SaveState(lockedState))
+	require.NoError(t, wal.SaveMessage(message))
+	require.NoError(t, wal.Close())
+
+	// Reopen the log
+	wal, err = NewFileWAL(path)
+	require.NoError(t, err)
+
+	defer wal.Close()
+
+	loadedState, err := wal.LoadState()
+	require.NoError(t, err)
+
+	// Make sure the latest state is loaded, with the outgoing messages
+	assertStatesEqual(t, lockedState, loadedState)
+
+	require.Len(t, loadedState.Messages, 1)
+	assert.True(t, protobuf.Equal(message, loadedState.Messages[0]))
+}
+
+// TestFileWAL_Compaction makes sure the log only
+// keeps the records for the latest height
+func TestFileWAL_Compaction(t *testing.T) {
+	t.Parallel()
+
+	wal, _ := newTestWAL(t)
+	defer wal.Close()
+
+	oldState := generatePersistedState(1, 0)
+
+	require.NoError(t, wal.SaveState(oldState))
+	require.NoError(t, wal.SaveMessage(
+		buildBasicPrepareMessage([]byte("hash"), []byte("node"), oldState.View),
+	))
+
+	newState := &PersistedState{
+		View: &proto.View{Height: 2, Round: 0},
+		Name: StateNewRound,
+	}
+
+	require.NoError(t, wal.SaveState(newState))
+
+	loadedState, err := wal.LoadState()
+	require.NoError(t, err)
+
+	// Make sure only the new height is present
+	assertStatesEqual(t, newState, loadedState)
+	assert.Empty(t, loadedState.Messages)
+}
+
+// TestFileWAL_TornWrite makes sure a partially written
+// record is discarded when the log is opened
+func TestFileWAL_TornWrite(t *testing.T) {
+	t.Parallel()
+
+	wal, path := newTestWAL(t)
+
+	state := generatePersistedState(3, 2)
+
+	require.NoError(t, wal.SaveState(state))
+
+	// Simulate a crash in the middle of a record write
+	record, err := encodeWALRecord(walRecordMessage, []byte("partial record"))
+	require.NoError(t, err)
+
+	_, err = wal.file.Write(record[:len(record)-3])
+	require.NoError(t, err)
+	require.NoError(t, wal.Close())
+
+	wal, err = NewFileWAL(path)
+	require.NoError(t, err)
+
+	defer wal.Close()
+
+	// Make sure the latest complete state is loaded
+	loadedState, err := wal.LoadState()
+	require.NoError(t, err)
+
+	assertStatesEqual(t, state, loadedState)
+
+	// Make sure the log is usable after the torn tail is dropped
+	message := buildBasicPrepareMessage([]byte("hash"), []byte("node"), state.View)
+
+	require.NoError(t, wal.SaveMessage(message))
+
+	loadedState, err = wal.LoadState()
+	require.NoError(t, err)
+
+	require.Len(t, loadedState.Messages, 1)
+	assert.True(t, protobuf.Equal(message, loadedState.Messages[0]))
+}
+
+// TestFileWAL_CorruptRecord makes sure records with
+// invalid checksums are not replayed
+func TestFileWAL_CorruptRecord(t *testing.T) {
+	t.Parallel()
+
+	wal, path := newTestWAL(t)
+
+	require.NoError(t, wal.SaveState(generatePersistedState(1, 0)))
+	require.NoError(t, wal.Close())
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Flip a bit in the payload
+	raw[walHeaderSize] ^= 0xff
+
+	require.NoError(t, os.WriteFile(path, raw, 0o600))
+
+	wal, err = NewFileWAL(path)
+	require.NoError(t, err)
+
+	defer wal.Close()
+
+	_, err = wal.LoadState()
+	assert.ErrorIs(t, err, ErrStateNotFound)
+}
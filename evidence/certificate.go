@@ -0,0 +1,135 @@
+package evidence
+
+import (
+	"bytes"
+
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// validRCC checks if the round change certificate of the well-formed proposal
+// could have been produced by an honest proposer: a quorum of valid round
+// change messages from distinct validators for the proposal view, and the
+// proposal matching one of their prepared certificates, if there are any
+func validRCC(proposal *proto.Message, validatorSet ValidatorSet) bool {
+	var (
+		height = proposal.View.Height
+		round  = proposal.View.Round
+
+		certificate = messages.ExtractRoundChangeCertificate(proposal)
+	)
+
+	if certificate == nil || len(certificate.RoundChangeMessages) == 0 {
+		return false
+	}
+
+	var (
+		votingPower    uint64
+		preparedHashes = make([][]byte, 0)
+	)
+
+	for _, message := range certificate.RoundChangeMessages {
+		if !wellFormed(message, proto.MessageType_ROUND_CHANGE) ||
+			message.View.Height != height ||
+			message.View.Round != round ||
+			!validatorSet.IsValidSender(message) ||
+			!validRoundChange(message, round, validatorSet) {
+			return false
+		}
+
+		if pc := messages.ExtractLatestPC(message); pc != nil {
+			preparedHashes = append(preparedHashes, messages.ExtractProposalHash(pc.ProposalMessage))
+		}
+
+		votingPower += validatorSet.VotingPower(height, message.From)
+	}
+
+	if !messages.HasUniqueSenders(certificate.RoundChangeMessages) || votingPower < validatorSet.Quorum(height) {
+		return false
+	}
+
+	// Honest proposers repropose one of the prepared proposals
+	if len(preparedHashes) == 0 {
+		return true
+	}
+
+	hash := messages.ExtractProposalHash(proposal)
+
+	for _, preparedHash := range preparedHashes {
+		if bytes.Equal(preparedHash, hash) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validRoundChange checks if the prepared certificate of the well-formed round change
+// message is valid for rounds below the limit, and matches the prepared proposal.
+// Messages without a prepared certificate must not have a prepared proposal
+func validRoundChange(message *proto.Message, roundLimit uint64, validatorSet ValidatorSet) bool {
+	var (
+		proposal    = messages.ExtractLastPreparedProposedBlock(message)
+		certificate = messages.ExtractLatestPC(message)
+	)
+
+	if certificate == nil {
+		return proposal == nil
+	}
+
+	if !validPC(certificate, roundLimit, message.View.Height, validatorSet) {
+		return false
+	}
+
+	return validatorSet.IsValidProposalHash(proposal, messages.ExtractProposalHash(certificate.ProposalMessage))
+}
+
+// validPC checks if the prepared certificate holds a quorum of valid PREPREPARE
+// and PREPARE messages from distinct validators for the same proposal, at the
+// height and for rounds below the limit, with the proposal sent by the proposer.
+// The proposer counts towards the quorum through the proposal, so a PREPARE
+// of its own is ignored, instead of being treated as misbehaviour
+func validPC(certificate *proto.PreparedCertificate, roundLimit, height uint64, validatorSet ValidatorSet) bool {
+	proposal := certificate.ProposalMessage
+
+	if !wellFormed(proposal, proto.MessageType_PREPREPARE) || len(certificate.PrepareMessages) == 0 {
+		return false
+	}
+
+	allMessages := []*proto.Message{proposal}
+
+	for _, message := range certificate.PrepareMessages {
+		if bytes.Equal(message.GetFrom(), proposal.From) {
+			continue
+		}
+
+		if !wellFormed(message, proto.MessageType_PREPARE) {
+			return false
+		}
+
+		allMessages = append(allMessages, message)
+	}
+
+	if !messages.HasUniqueSenders(allMessages) ||
+		!messages.HaveSameProposalHash(allMessages) ||
+		!messages.AllHaveLowerRound(allMessages, roundLimit) ||
+		!messages.AllHaveSameHeight(allMessages, height) {
+		return false
+	}
+
+	if !validatorSet.IsProposer(proposal.From, height, proposal.View.Round) {
+		return false
+	}
+
+	var votingPower uint64
+
+	for _, message := range allMessages {
+		if !validatorSet.IsValidSender(message) {
+			return false
+		}
+
+		votingPower += validatorSet.VotingPower(height, message.From)
+	}
+
+	return votingPower >= validatorSet.Quorum(height)
+}
@@ -0,0 +1,224 @@
+// Package evidence defines the portable proof of validator misbehaviour,
+// and its verification against the validator set
+package evidence
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/madz-lab/go-ibft/core"
+	evidenceProto "github.com/madz-lab/go-ibft/evidence/proto"
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+var (
+	// ErrMalformedEvidence is returned for evidence that is structurally invalid
+	ErrMalformedEvidence = errors.New("malformed evidence")
+
+	// ErrInvalidSignature is returned for evidence with messages
+	// that are not signed by their sender, or are from non-validators
+	ErrInvalidSignature = errors.New("invalid evidence message signature")
+
+	// ErrNoMisbehaviour is returned for well-formed evidence
+	// that doesn't prove any misbehaviour
+	ErrNoMisbehaviour = errors.New("evidence doesn't prove misbehaviour")
+)
+
+// ValidatorSet is the validator set at the height of the misbehaviour.
+// The quorum and the voting power are expressed in the same units,
+// so validator sets where each validator has a single vote
+// return 1 as the voting power of each validator
+type ValidatorSet interface {
+	core.Verifier
+
+	// Quorum returns the voting power required for a quorum at the height
+	Quorum(height uint64) uint64
+
+	// VotingPower returns the voting power of the validator at the height
+	VotingPower(height uint64, from []byte) uint64
+}
+
+// NewDuplicateEvidence creates the evidence of the conflicting messages
+// detected by the message store, based on their type
+func NewDuplicateEvidence(equivocation *messages.EquivocationEvidence) (*evidenceProto.Evidence, error) {
+	var (
+		first  = equivocation.First
+		second = equivocation.Second
+	)
+
+	switch first.Type {
+	case proto.MessageType_PREPREPARE:
+		return &evidenceProto.Evidence{
+			Kind: &evidenceProto.Evidence_DuplicateProposal{
+				DuplicateProposal: &evidenceProto.DuplicateProposal{First: first, Second: second},
+			},
+		}, nil
+	case proto.MessageType_PREPARE:
+		return &evidenceProto.Evidence{
+			Kind: &evidenceProto.Evidence_DuplicatePrepare{
+				DuplicatePrepare: &evidenceProto.DuplicatePrepare{First: first, Second: second},
+			},
+		}, nil
+	case proto.MessageType_COMMIT:
+		return &evidenceProto.Evidence{
+			Kind: &evidenceProto.Evidence_DuplicateCommit{
+				DuplicateCommit: &evidenceProto.DuplicateCommit{First: first, Second: second},
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: no duplicate evidence for %s messages", ErrMalformedEvidence, first.Type)
+	}
+}
+
+// VerifyEvidence checks that the evidence proves the misbehaviour of a validator
+// from the validator set. It only depends on the evidence and the validator set,
+// so the same evidence is verified the same way on every node.
+// Certificates are checked conservatively: a PREPARE of the proposer in a prepared
+// certificate is ignored, and a reproposal only needs to match one of the prepared
+// proposals of its round change certificate
+func VerifyEvidence(evidence *evidenceProto.Evidence, validatorSet ValidatorSet) error {
+	switch kind := evidence.GetKind().(type) {
+	case *evidenceProto.Evidence_DuplicateProposal:
+		return verifyDuplicateProposal(kind.DuplicateProposal, validatorSet)
+	case *evidenceProto.Evidence_DuplicatePrepare:
+		return verifyDuplicate(
+			kind.DuplicatePrepare.GetFirst(),
+			kind.DuplicatePrepare.GetSecond(),
+			proto.MessageType_PREPARE,
+			validatorSet,
+		)
+	case *evidenceProto.Evidence_DuplicateCommit:
+		return verifyDuplicate(
+			kind.DuplicateCommit.GetFirst(),
+			kind.DuplicateCommit.GetSecond(),
+			proto.MessageType_COMMIT,
+			validatorSet,
+		)
+	case *evidenceProto.Evidence_InvalidCertificate:
+		return verifyInvalidCertificate(kind.InvalidCertificate.GetMessage(), validatorSet)
+	default:
+		return fmt.Errorf("%w: evidence kind is not set", ErrMalformedEvidence)
+	}
+}
+
+// verifyDuplicateProposal verifies that the conflicting
+// proposals are sent by the proposer for the view
+func verifyDuplicateProposal(evidence *evidenceProto.DuplicateProposal, validatorSet ValidatorSet) error {
+	first := evidence.GetFirst()
+
+	if err := verifyDuplicate(first, evidence.GetSecond(), proto.MessageType_PREPREPARE, validatorSet); err != nil {
+		return err
+	}
+
+	if !validatorSet.IsProposer(first.From, first.View.Height, first.View.Round) {
+		return fmt.Errorf("%w: sender is not the proposer for the view", ErrNoMisbehaviour)
+	}
+
+	return nil
+}
+
+// verifyDuplicate verifies that the signed messages of the specified type are
+// from the same sender, for the same view, and for different proposals
+func verifyDuplicate(first, second *proto.Message, messageType proto.MessageType, validatorSet ValidatorSet) error {
+	for _, message := range []*proto.Message{first, second} {
+		if err := verifyMessage(message, messageType, validatorSet); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case !bytes.Equal(first.From, second.From):
+		return fmt.Errorf("%w: messages are from different senders", ErrNoMisbehaviour)
+	case first.View.Height != second.View.Height, first.View.Round != second.View.Round:
+		return fmt.Errorf("%w: messages are for different views", ErrNoMisbehaviour)
+	case bytes.Equal(proposalHash(first), proposalHash(second)):
+		return fmt.Errorf("%w: messages are for the same proposal", ErrNoMisbehaviour)
+	}
+
+	return nil
+}
+
+// verifyInvalidCertificate verifies that the signed message carries an invalid certificate
+func verifyInvalidCertificate(message *proto.Message, validatorSet ValidatorSet) error {
+	if message == nil {
+		return fmt.Errorf("%w: message is not set", ErrMalformedEvidence)
+	}
+
+	switch message.Type {
+	case proto.MessageType_PREPREPARE:
+		if err := verifyMessage(message, proto.MessageType_PREPREPARE, validatorSet); err != nil {
+			return err
+		}
+
+		if message.View.Round == 0 {
+			return fmt.Errorf("%w: round 0 proposals don't need a certificate", ErrNoMisbehaviour)
+		}
+
+		if validRCC(message, validatorSet) {
+			return fmt.Errorf("%w: round change certificate is valid", ErrNoMisbehaviour)
+		}
+	case proto.MessageType_ROUND_CHANGE:
+		if err := verifyMessage(message, proto.MessageType_ROUND_CHANGE, validatorSet); err != nil {
+			return err
+		}
+
+		if validRoundChange(message, message.View.Round, validatorSet) {
+			return fmt.Errorf("%w: prepared certificate is valid", ErrNoMisbehaviour)
+		}
+	default:
+		return fmt.Errorf("%w: %s messages don't carry certificates", ErrMalformedEvidence, message.Type)
+	}
+
+	return nil
+}
+
+// verifyMessage makes sure the message is well-formed,
+// of the specified type, and signed by a validator
+func verifyMessage(message *proto.Message, messageType proto.MessageType, validatorSet ValidatorSet) error {
+	if !wellFormed(message, messageType) {
+		return fmt.Errorf("%w: expected a well-formed %s message", ErrMalformedEvidence, messageType)
+	}
+
+	if !validatorSet.IsValidSender(message) {
+		return fmt.Errorf("%w: %s message from %x", ErrInvalidSignature, messageType, message.From)
+	}
+
+	return nil
+}
+
+// wellFormed checks that the message has a view, a sender,
+// and the payload of the specified message type
+func wellFormed(message *proto.Message, messageType proto.MessageType) bool {
+	if message == nil || message.View == nil || len(message.From) == 0 || message.Type != messageType {
+		return false
+	}
+
+	switch messageType {
+	case proto.MessageType_PREPREPARE:
+		return message.GetPreprepareData() != nil
+	case proto.MessageType_PREPARE:
+		return message.GetPrepareData() != nil
+	case proto.MessageType_COMMIT:
+		return message.GetCommitData() != nil
+	case proto.MessageType_ROUND_CHANGE:
+		return message.GetRoundChangeData() != nil
+	default:
+		return false
+	}
+}
+
+// proposalHash extracts the proposal hash from the well-formed message
+func proposalHash(message *proto.Message) []byte {
+	switch message.Type {
+	case proto.MessageType_PREPREPARE:
+		return messages.ExtractProposalHash(message)
+	case proto.MessageType_PREPARE:
+		return messages.ExtractPrepareHash(message)
+	case proto.MessageType_COMMIT:
+		return messages.ExtractCommitHash(message)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,539 @@
+package evidence
+
+import (
+	"testing"
+
+	evidenceProto "github.com/madz-lab/go-ibft/evidence/proto"
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+var validators = []string{"node 0", "node 1", "node 2", "node 3"}
+
+// mockValidatorSet is the validator set of 4 validators, with round-robin
+// proposers. Messages are signed with the "sig:" prefixed sender, and
+// proposal hashes are the "hash:" prefixed proposals
+type mockValidatorSet struct {
+	// votingPower maps the validator -> voting power,
+	// every validator has a voting power of 1 if not set
+	votingPower map[string]uint64
+}
+
+func (m mockValidatorSet) IsValidBlock([]byte) bool {
+	return true
+}
+
+func (m mockValidatorSet) IsValidSender(message *proto.Message) bool {
+	return m.VotingPower(message.View.Height, message.From) > 0 &&
+		string(message.Signature) == "sig:"+string(message.From)
+}
+
+func (m mockValidatorSet) IsProposer(id []byte, _, round uint64) bool {
+	return string(id) == validators[round%uint64(len(validators))]
+}
+
+func (m mockValidatorSet) IsValidProposalHash(proposal, hash []byte) bool {
+	return string(hash) == "hash:"+string(proposal)
+}
+
+func (m mockValidatorSet) IsValidCommittedSeal([]byte, *messages.CommittedSeal) bool {
+	return true
+}
+
+func (m mockValidatorSet) Quorum(height uint64) uint64 {
+	var total uint64
+
+	for _, validator := range validators {
+		total += m.VotingPower(height, []byte(validator))
+	}
+
+	return 2*total/3 + 1
+}
+
+func (m mockValidatorSet) VotingPower(_ uint64, from []byte) uint64 {
+	if m.votingPower != nil {
+		return m.votingPower[string(from)]
+	}
+
+	for _, validator := range validators {
+		if validator == string(from) {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// signed signs the message on behalf of its sender
+func signed(message *proto.Message) *proto.Message {
+	message.Signature = []byte("sig:" + string(message.From))
+
+	return message
+}
+
+func buildPreprepare(
+	from string,
+	round uint64,
+	proposal string,
+	certificate *proto.RoundChangeCertificate,
+) *proto.Message {
+	return signed(&proto.Message{
+		View: &proto.View{Height: 1, Round: round},
+		From: []byte(from),
+		Type: proto.MessageType_PREPREPARE,
+		Payload: &proto.Message_PreprepareData{
+			PreprepareData: &proto.PrePrepareMessage{
+				Proposal:     []byte(proposal),
+				ProposalHash: []byte("hash:" + proposal),
+				Certificate:  certificate,
+			},
+		},
+	})
+}
+
+func buildPrepare(from string, round uint64, proposal string) *proto.Message {
+	return signed(&proto.Message{
+		View: &proto.View{Height: 1, Round: round},
+		From: []byte(from),
+		Type: proto.MessageType_PREPARE,
+		Payload: &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{
+				ProposalHash: []byte("hash:" + proposal),
+			},
+		},
+	})
+}
+
+func buildCommit(from string, round uint64, proposal string) *proto.Message {
+	return signed(&proto.Message{
+		View: &proto.View{Height: 1, Round: round},
+		From: []byte(from),
+		Type: proto.MessageType_COMMIT,
+		Payload: &proto.Message_CommitData{
+			CommitData: &proto.CommitMessage{
+				ProposalHash:  []byte("hash:" + proposal),
+				CommittedSeal: []byte("seal"),
+			},
+		},
+	})
+}
+
+func buildRoundChange(
+	from string,
+	round uint64,
+	proposal string,
+	certificate *proto.PreparedCertificate,
+) *proto.Message {
+	var block []byte
+	if proposal != "" {
+		block = []byte(proposal)
+	}
+
+	return signed(&proto.Message{
+		View: &proto.View{Height: 1, Round: round},
+		From: []byte(from),
+		Type: proto.MessageType_ROUND_CHANGE,
+		Payload: &proto.Message_RoundChangeData{
+			RoundChangeData: &proto.RoundChangeMessage{
+				LastPreparedProposedBlock: block,
+				LatestPreparedCertificate: certificate,
+			},
+		},
+	})
+}
+
+// buildPC builds the prepared certificate for the proposal in round 1,
+// proposed by node 1, and prepared by the passed in validators
+func buildPC(proposal string, preparers ...string) *proto.PreparedCertificate {
+	prepares := make([]*proto.Message, 0, len(preparers))
+	for _, from := range preparers {
+		prepares = append(prepares, buildPrepare(from, 1, proposal))
+	}
+
+	return &proto.PreparedCertificate{
+		ProposalMessage: buildPreprepare("node 1", 1, proposal, nil),
+		PrepareMessages: prepares,
+	}
+}
+
+// buildRCC builds the round change certificate for round 2
+func buildRCC(roundChanges ...*proto.Message) *proto.RoundChangeCertificate {
+	return &proto.RoundChangeCertificate{RoundChangeMessages: roundChanges}
+}
+
+func duplicateProposal(first, second *proto.Message) *evidenceProto.Evidence {
+	return &evidenceProto.Evidence{
+		Kind: &evidenceProto.Evidence_DuplicateProposal{
+			DuplicateProposal: &evidenceProto.DuplicateProposal{First: first, Second: second},
+		},
+	}
+}
+
+func duplicatePrepare(first, second *proto.Message) *evidenceProto.Evidence {
+	return &evidenceProto.Evidence{
+		Kind: &evidenceProto.Evidence_DuplicatePrepare{
+			DuplicatePrepare: &evidenceProto.DuplicatePrepare{First: first, Second: second},
+		},
+	}
+}
+
+func duplicateCommit(first, second *proto.Message) *evidenceProto.Evidence {
+	return &evidenceProto.Evidence{
+		Kind: &evidenceProto.Evidence_DuplicateCommit{
+			DuplicateCommit: &evidenceProto.DuplicateCommit{First: first, Second: second},
+		},
+	}
+}
+
+func invalidCertificate(message *proto.Message) *evidenceProto.Evidence {
+	return &evidenceProto.Evidence{
+		Kind: &evidenceProto.Evidence_InvalidCertificate{
+			InvalidCertificate: &evidenceProto.InvalidCertificate{Message: message},
+		},
+	}
+}
+
+// TestVerifyEvidence makes sure only evidence
+// of actual misbehaviour is verified
+func TestVerifyEvidence(t *testing.T) {
+	t.Parallel()
+
+	unsigned := buildPrepare("node 2", 0, "block 2")
+	unsigned.Signature = nil
+
+	nonValidator := buildPrepare("node 4", 0, "block 2")
+
+	testTable := []struct {
+		name        string
+		evidence    *evidenceProto.Evidence
+		expectedErr error
+	}{
+		{
+			"kind is not set",
+			&evidenceProto.Evidence{},
+			ErrMalformedEvidence,
+		},
+		{
+			"duplicate proposal",
+			duplicateProposal(
+				buildPreprepare("node 0", 0, "block 1", nil),
+				buildPreprepare("node 0", 0, "block 2", nil),
+			),
+			nil,
+		},
+		{
+			"duplicate proposal from a non-proposer",
+			duplicateProposal(
+				buildPreprepare("node 1", 0, "block 1", nil),
+				buildPreprepare("node 1", 0, "block 2", nil),
+			),
+			ErrNoMisbehaviour,
+		},
+		{
+			"same proposal",
+			duplicateProposal(
+				buildPreprepare("node 0", 0, "block 1", nil),
+				buildPreprepare("node 0", 0, "block 1", nil),
+			),
+			ErrNoMisbehaviour,
+		},
+		{
+			"proposal is missing",
+			duplicateProposal(buildPreprepare("node 0", 0, "block 1", nil), nil),
+			ErrMalformedEvidence,
+		},
+		{
+			"duplicate prepare",
+			duplicatePrepare(buildPrepare("node 2", 0, "block 1"), buildPrepare("node 2", 0, "block 2")),
+			nil,
+		},
+		{
+			"prepares from different senders",
+			duplicatePrepare(buildPrepare("node 2", 0, "block 1"), buildPrepare("node 3", 0, "block 2")),
+			ErrNoMisbehaviour,
+		},
+		{
+			"prepares for different views",
+			duplicatePrepare(buildPrepare("node 2", 0, "block 1"), buildPrepare("node 2", 1, "block 2")),
+			ErrNoMisbehaviour,
+		},
+		{
+			"prepare is not signed",
+			duplicatePrepare(buildPrepare("node 2", 0, "block 1"), unsigned),
+			ErrInvalidSignature,
+		},
+		{
+			"prepares from a non-validator",
+			duplicatePrepare(buildPrepare("node 4", 0, "block 1"), nonValidator),
+			ErrInvalidSignature,
+		},
+		{
+			"duplicate commit",
+			duplicateCommit(buildCommit("node 3", 0, "block 1"), buildCommit("node 3", 0, "block 2")),
+			nil,
+		},
+		{
+			"commit evidence with prepares",
+			duplicateCommit(buildPrepare("node 3", 0, "block 1"), buildPrepare("node 3", 0, "block 2")),
+			ErrMalformedEvidence,
+		},
+		{
+			"valid round change certificate",
+			invalidCertificate(buildPreprepare("node 2", 2, "block 1", buildRCC(
+				buildRoundChange("node 0", 2, "", nil),
+				buildRoundChange("node 2", 2, "block 1", buildPC("block 1", "node 2", "node 3")),
+				buildRoundChange("node 3", 2, "", nil),
+			))),
+			ErrNoMisbehaviour,
+		},
+		{
+			"round change certificate below quorum",
+			invalidCertificate(buildPreprepare("node 2", 2, "block 1", buildRCC(
+				buildRoundChange("node 0", 2, "", nil),
+				buildRoundChange("node 3", 2, "", nil),
+			))),
+			nil,
+		},
+		{
+			"round change certificate with duplicate senders",
+			invalidCertificate(buildPreprepare("node 2", 2, "block 1", buildRCC(
+				buildRoundChange("node 0", 2, "", nil),
+				buildRoundChange("node 3", 2, "", nil),
+				buildRoundChange("node 3", 2, "", nil),
+			))),
+			nil,
+		},
+		{
+			"round change certificate for a different round",
+			invalidCertificate(buildPreprepare("node 2", 2, "block 1", buildRCC(
+				buildRoundChange("node 0", 1, "", nil),
+				buildRoundChange("node 2", 1, "", nil),
+				buildRoundChange("node 3", 1, "", nil),
+			))),
+			nil,
+		},
+		{
+			"proposal doesn't match the prepared proposal",
+			invalidCertificate(buildPreprepare("node 2", 2, "block 2", buildRCC(
+				buildRoundChange("node 0", 2, "", nil),
+				buildRoundChange("node 2", 2, "block 1", buildPC("block 1", "node 2", "node 3")),
+				buildRoundChange("node 3", 2, "", nil),
+			))),
+			nil,
+		},
+		{
+			"proposal without a round change certificate",
+			invalidCertificate(buildPreprepare("node 2", 2, "block 1", nil)),
+			nil,
+		},
+		{
+			"round 0 proposal",
+			invalidCertificate(buildPreprepare("node 0", 0, "block 1", nil)),
+			ErrNoMisbehaviour,
+		},
+		{
+			"valid prepared certificate",
+			invalidCertificate(buildRoundChange("node 0", 2, "block 1", buildPC("block 1", "node 2", "node 3"))),
+			ErrNoMisbehaviour,
+		},
+		{
+			"prepared certificate with a prepare from the proposer",
+			invalidCertificate(buildRoundChange("node 2", 2, "block 1", buildPC("block 1", "node 1", "node 2", "node 3"))),
+			ErrNoMisbehaviour,
+		},
+		{
+			"prepared certificate below quorum without the proposer's prepare",
+			invalidCertificate(buildRoundChange("node 2", 2, "block 1", buildPC("block 1", "node 1", "node 2"))),
+			nil,
+		},
+		{
+			"round change without a prepared certificate",
+			invalidCertificate(buildRoundChange("node 0", 2, "", nil)),
+			ErrNoMisbehaviour,
+		},
+		{
+			"prepared certificate below quorum",
+			invalidCertificate(buildRoundChange("node 0", 2, "block 1", buildPC("block 1", "node 2"))),
+			nil,
+		},
+		{
+			"prepared certificate for a later round",
+			invalidCertificate(buildRoundChange("node 0", 1, "block 1", buildPC("block 1", "node 2", "node 3"))),
+			nil,
+		},
+		{
+			"prepared certificate with a proposal from a non-proposer",
+			invalidCertificate(buildRoundChange("node 0", 2, "block 1", &proto.PreparedCertificate{
+				ProposalMessage: buildPreprepare("node 2", 1, "block 1", nil),
+				PrepareMessages: []*proto.Message{
+					buildPrepare("node 0", 1, "block 1"),
+					buildPrepare("node 3", 1, "block 1"),
+				},
+			})),
+			nil,
+		},
+		{
+			"prepared certificate with conflicting prepares",
+			invalidCertificate(buildRoundChange("node 0", 2, "block 1", &proto.PreparedCertificate{
+				ProposalMessage: buildPreprepare("node 1", 1, "block 1", nil),
+				PrepareMessages: []*proto.Message{
+					buildPrepare("node 2", 1, "block 1"),
+					buildPrepare("node 3", 1, "block 2"),
+				},
+			})),
+			nil,
+		},
+		{
+			"prepared certificate without a proposal",
+			invalidCertificate(buildRoundChange("node 0", 2, "block 1", &proto.PreparedCertificate{
+				PrepareMessages: []*proto.Message{
+					buildPrepare("node 2", 1, "block 1"),
+					buildPrepare("node 3", 1, "block 1"),
+				},
+			})),
+			nil,
+		},
+		{
+			"prepared proposal doesn't match the certificate",
+			invalidCertificate(buildRoundChange("node 0", 2, "block 2", buildPC("block 1", "node 2", "node 3"))),
+			nil,
+		},
+		{
+			"prepared proposal without a certificate",
+			invalidCertificate(buildRoundChange("node 0", 2, "block 1", nil)),
+			nil,
+		},
+		{
+			"certificate evidence with a prepare",
+			invalidCertificate(buildPrepare("node 0", 2, "block 1")),
+			ErrMalformedEvidence,
+		},
+		{
+			"certificate evidence without a message",
+			invalidCertificate(nil),
+			ErrMalformedEvidence,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := VerifyEvidence(testCase.evidence, mockValidatorSet{})
+
+			if testCase.expectedErr == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, testCase.expectedErr)
+		})
+	}
+}
+
+// TestVerifyEvidence_VotingPower makes sure certificate
+// quorums are based on the voting power of the senders
+func TestVerifyEvidence_VotingPower(t *testing.T) {
+	t.Parallel()
+
+	validatorSet := mockValidatorSet{
+		votingPower: map[string]uint64{
+			"node 0": 1,
+			"node 1": 5,
+			"node 2": 1,
+			"node 3": 1,
+		},
+	}
+
+	// The proposer and a single preparer hold a quorum of 6 out of 8
+	assert.ErrorIs(
+		t,
+		VerifyEvidence(
+			invalidCertificate(buildRoundChange("node 0", 2, "block 1", buildPC("block 1", "node 2"))),
+			validatorSet,
+		),
+		ErrNoMisbehaviour,
+	)
+
+	// Three validators without the heavy one are not a quorum
+	assert.NoError(
+		t,
+		VerifyEvidence(
+			invalidCertificate(buildPreprepare("node 2", 2, "block 1", buildRCC(
+				buildRoundChange("node 0", 2, "", nil),
+				buildRoundChange("node 2", 2, "", nil),
+				buildRoundChange("node 3", 2, "", nil),
+			))),
+			validatorSet,
+		),
+	)
+}
+
+// TestNewDuplicateEvidence makes sure the conflicts detected by the
+// message store are converted into portable, verifiable evidence
+func TestNewDuplicateEvidence(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name   string
+		first  *proto.Message
+		second *proto.Message
+	}{
+		{
+			"proposals",
+			buildPreprepare("node 0", 0, "block 1", nil),
+			buildPreprepare("node 0", 0, "block 2", nil),
+		},
+		{
+			"prepares",
+			buildPrepare("node 1", 0, "block 1"),
+			buildPrepare("node 1", 0, "block 2"),
+		},
+		{
+			"commits",
+			buildCommit("node 2", 0, "block 1"),
+			buildCommit("node 2", 0, "block 2"),
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			evidence, err := NewDuplicateEvidence(&messages.EquivocationEvidence{
+				First:  testCase.first,
+				Second: testCase.second,
+			})
+			require.NoError(t, err)
+
+			// Make sure the evidence survives encoding
+			raw, err := protobuf.Marshal(evidence)
+			require.NoError(t, err)
+
+			decoded := &evidenceProto.Evidence{}
+			require.NoError(t, protobuf.Unmarshal(raw, decoded))
+
+			assert.True(t, protobuf.Equal(evidence, decoded))
+			assert.NoError(t, VerifyEvidence(decoded, mockValidatorSet{}))
+		})
+	}
+
+	t.Run("round changes", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewDuplicateEvidence(&messages.EquivocationEvidence{
+			First:  buildRoundChange("node 0", 1, "", nil),
+			Second: buildRoundChange("node 0", 1, "block 1", nil),
+		})
+
+		assert.ErrorIs(t, err, ErrMalformedEvidence)
+	})
+}
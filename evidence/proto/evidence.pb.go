@@ -0,0 +1,541 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        (unknown)
+// source: evidence.proto
+
+package proto
+
+import (
+	proto "github.com/madz-lab/go-ibft/messages/proto"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Evidence is the proof of misbehaviour of a single validator
+type Evidence struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// kind is the specific misbehaviour
+	//
+	// Types that are assignable to Kind:
+	//	*Evidence_DuplicateProposal
+	//	*Evidence_DuplicatePrepare
+	//	*Evidence_DuplicateCommit
+	//	*Evidence_InvalidCertificate
+	Kind isEvidence_Kind `protobuf_oneof:"kind"`
+}
+
+func (x *Evidence) Reset() {
+	*x = Evidence{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_evidence_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Evidence) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Evidence) ProtoMessage() {}
+
+func (x *Evidence) ProtoReflect() protoreflect.Message {
+	mi := &file_evidence_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Evidence.ProtoReflect.Descriptor instead.
+func (*Evidence) Descriptor() ([]byte, []int) {
+	return file_evidence_proto_rawDescGZIP(), []int{0}
+}
+
+func (m *Evidence) GetKind() isEvidence_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return nil
+}
+
+func (x *Evidence) GetDuplicateProposal() *DuplicateProposal {
+	if x, ok := x.GetKind().(*Evidence_DuplicateProposal); ok {
+		return x.DuplicateProposal
+	}
+	return nil
+}
+
+func (x *Evidence) GetDuplicatePrepare() *DuplicatePrepare {
+	if x, ok := x.GetKind().(*Evidence_DuplicatePrepare); ok {
+		return x.DuplicatePrepare
+	}
+	return nil
+}
+
+func (x *Evidence) GetDuplicateCommit() *DuplicateCommit {
+	if x, ok := x.GetKind().(*Evidence_DuplicateCommit); ok {
+		return x.DuplicateCommit
+	}
+	return nil
+}
+
+func (x *Evidence) GetInvalidCertificate() *InvalidCertificate {
+	if x, ok := x.GetKind().(*Evidence_InvalidCertificate); ok {
+		return x.InvalidCertificate
+	}
+	return nil
+}
+
+type isEvidence_Kind interface {
+	isEvidence_Kind()
+}
+
+type Evidence_DuplicateProposal struct {
+	DuplicateProposal *DuplicateProposal `protobuf:"bytes,1,opt,name=duplicateProposal,proto3,oneof"`
+}
+
+type Evidence_DuplicatePrepare struct {
+	DuplicatePrepare *DuplicatePrepare `protobuf:"bytes,2,opt,name=duplicatePrepare,proto3,oneof"`
+}
+
+type Evidence_DuplicateCommit struct {
+	DuplicateCommit *DuplicateCommit `protobuf:"bytes,3,opt,name=duplicateCommit,proto3,oneof"`
+}
+
+type Evidence_InvalidCertificate struct {
+	InvalidCertificate *InvalidCertificate `protobuf:"bytes,4,opt,name=invalidCertificate,proto3,oneof"`
+}
+
+func (*Evidence_DuplicateProposal) isEvidence_Kind() {}
+
+func (*Evidence_DuplicatePrepare) isEvidence_Kind() {}
+
+func (*Evidence_DuplicateCommit) isEvidence_Kind() {}
+
+func (*Evidence_InvalidCertificate) isEvidence_Kind() {}
+
+// DuplicateProposal is the evidence of a proposer sending
+// PREPREPARE messages for different proposals in the same view
+type DuplicateProposal struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// first is the signed PREPREPARE message received first
+	First *proto.Message `protobuf:"bytes,1,opt,name=first,proto3" json:"first,omitempty"`
+	// second is the conflicting signed PREPREPARE message
+	Second *proto.Message `protobuf:"bytes,2,opt,name=second,proto3" json:"second,omitempty"`
+}
+
+func (x *DuplicateProposal) Reset() {
+	*x = DuplicateProposal{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_evidence_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DuplicateProposal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DuplicateProposal) ProtoMessage() {}
+
+func (x *DuplicateProposal) ProtoReflect() protoreflect.Message {
+	mi := &file_evidence_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DuplicateProposal.ProtoReflect.Descriptor instead.
+func (*DuplicateProposal) Descriptor() ([]byte, []int) {
+	return file_evidence_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DuplicateProposal) GetFirst() *proto.Message {
+	if x != nil {
+		return x.First
+	}
+	return nil
+}
+
+func (x *DuplicateProposal) GetSecond() *proto.Message {
+	if x != nil {
+		return x.Second
+	}
+	return nil
+}
+
+// DuplicatePrepare is the evidence of a validator sending
+// PREPARE messages for different proposals in the same view
+type DuplicatePrepare struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// first is the signed PREPARE message received first
+	First *proto.Message `protobuf:"bytes,1,opt,name=first,proto3" json:"first,omitempty"`
+	// second is the conflicting signed PREPARE message
+	Second *proto.Message `protobuf:"bytes,2,opt,name=second,proto3" json:"second,omitempty"`
+}
+
+func (x *DuplicatePrepare) Reset() {
+	*x = DuplicatePrepare{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_evidence_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DuplicatePrepare) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DuplicatePrepare) ProtoMessage() {}
+
+func (x *DuplicatePrepare) ProtoReflect() protoreflect.Message {
+	mi := &file_evidence_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DuplicatePrepare.ProtoReflect.Descriptor instead.
+func (*DuplicatePrepare) Descriptor() ([]byte, []int) {
+	return file_evidence_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DuplicatePrepare) GetFirst() *proto.Message {
+	if x != nil {
+		return x.First
+	}
+	return nil
+}
+
+func (x *DuplicatePrepare) GetSecond() *proto.Message {
+	if x != nil {
+		return x.Second
+	}
+	return nil
+}
+
+// DuplicateCommit is the evidence of a validator sending
+// COMMIT messages for different proposals in the same view
+type DuplicateCommit struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// first is the signed COMMIT message received first
+	First *proto.Message `protobuf:"bytes,1,opt,name=first,proto3" json:"first,omitempty"`
+	// second is the conflicting signed COMMIT message
+	Second *proto.Message `protobuf:"bytes,2,opt,name=second,proto3" json:"second,omitempty"`
+}
+
+func (x *DuplicateCommit) Reset() {
+	*x = DuplicateCommit{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_evidence_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DuplicateCommit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DuplicateCommit) ProtoMessage() {}
+
+func (x *DuplicateCommit) ProtoReflect() protoreflect.Message {
+	mi := &file_evidence_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DuplicateCommit.ProtoReflect.Descriptor instead.
+func (*DuplicateCommit) Descriptor() ([]byte, []int) {
+	return file_evidence_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DuplicateCommit) GetFirst() *proto.Message {
+	if x != nil {
+		return x.First
+	}
+	return nil
+}
+
+func (x *DuplicateCommit) GetSecond() *proto.Message {
+	if x != nil {
+		return x.Second
+	}
+	return nil
+}
+
+// InvalidCertificate is the evidence of a validator sending
+// a message with a certificate that doesn't hold
+type InvalidCertificate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// message is the signed PREPREPARE message with the RCC,
+	// or the signed ROUND_CHANGE message with the PC
+	Message *proto.Message `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *InvalidCertificate) Reset() {
+	*x = InvalidCertificate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_evidence_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InvalidCertificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvalidCertificate) ProtoMessage() {}
+
+func (x *InvalidCertificate) ProtoReflect() protoreflect.Message {
+	mi := &file_evidence_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvalidCertificate.ProtoReflect.Descriptor instead.
+func (*InvalidCertificate) Descriptor() ([]byte, []int) {
+	return file_evidence_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *InvalidCertificate) GetMessage() *proto.Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+var File_evidence_proto protoreflect.FileDescriptor
+
+var file_evidence_proto_rawDesc = []byte{
+	0x0a, 0x0e, 0x65, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x1a, 0x0e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x22, 0x9c, 0x02, 0x0a, 0x08, 0x45, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x42, 0x0a,
+	0x11, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73,
+	0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x44, 0x75, 0x70, 0x6c, 0x69,
+	0x63, 0x61, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x48, 0x00, 0x52, 0x11,
+	0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61,
+	0x6c, 0x12, 0x3f, 0x0a, 0x10, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72,
+	0x65, 0x70, 0x61, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x44, 0x75,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x48, 0x00,
+	0x52, 0x10, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72, 0x65, 0x70, 0x61,
+	0x72, 0x65, 0x12, 0x3c, 0x0a, 0x0f, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x43,
+	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x44, 0x75,
+	0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x48, 0x00, 0x52,
+	0x0f, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74,
+	0x12, 0x45, 0x0a, 0x12, 0x69, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69,
+	0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x49,
+	0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
+	0x65, 0x48, 0x00, 0x52, 0x12, 0x69, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x43, 0x65, 0x72, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x42, 0x06, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x22,
+	0x55, 0x0a, 0x11, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x50, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x61, 0x6c, 0x12, 0x1e, 0x0a, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x05, 0x66,
+	0x69, 0x72, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x06,
+	0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x22, 0x54, 0x0a, 0x10, 0x44, 0x75, 0x70, 0x6c, 0x69, 0x63,
+	0x61, 0x74, 0x65, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x12, 0x1e, 0x0a, 0x05, 0x66, 0x69,
+	0x72, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x52, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x12, 0x20, 0x0a, 0x06, 0x73, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x52, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x22, 0x53, 0x0a, 0x0f,
+	0x44, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x12,
+	0x1e, 0x0a, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08,
+	0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x12,
+	0x20, 0x0a, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x06, 0x73, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x22, 0x38, 0x0a, 0x12, 0x49, 0x6e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x43, 0x65, 0x72, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x12, 0x22, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x11, 0x5a, 0x0f, 0x2f,
+	0x65, 0x76, 0x69, 0x64, 0x65, 0x6e, 0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_evidence_proto_rawDescOnce sync.Once
+	file_evidence_proto_rawDescData = file_evidence_proto_rawDesc
+)
+
+func file_evidence_proto_rawDescGZIP() []byte {
+	file_evidence_proto_rawDescOnce.Do(func() {
+		file_evidence_proto_rawDescData = protoimpl.X.CompressGZIP(file_evidence_proto_rawDescData)
+	})
+	return file_evidence_proto_rawDescData
+}
+
+var file_evidence_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_evidence_proto_goTypes = []interface{}{
+	(*Evidence)(nil),           // 0: Evidence
+	(*DuplicateProposal)(nil),  // 1: DuplicateProposal
+	(*DuplicatePrepare)(nil),   // 2: DuplicatePrepare
+	(*DuplicateCommit)(nil),    // 3: DuplicateCommit
+	(*InvalidCertificate)(nil), // 4: InvalidCertificate
+	(*proto.Message)(nil),      // 5: Message
+}
+var file_evidence_proto_depIdxs = []int32{
+	1,  // 0: Evidence.duplicateProposal:type_name -> DuplicateProposal
+	2,  // 1: Evidence.duplicatePrepare:type_name -> DuplicatePrepare
+	3,  // 2: Evidence.duplicateCommit:type_name -> DuplicateCommit
+	4,  // 3: Evidence.invalidCertificate:type_name -> InvalidCertificate
+	5,  // 4: DuplicateProposal.first:type_name -> Message
+	5,  // 5: DuplicateProposal.second:type_name -> Message
+	5,  // 6: DuplicatePrepare.first:type_name -> Message
+	5,  // 7: DuplicatePrepare.second:type_name -> Message
+	5,  // 8: DuplicateCommit.first:type_name -> Message
+	5,  // 9: DuplicateCommit.second:type_name -> Message
+	5,  // 10: InvalidCertificate.message:type_name -> Message
+	11, // [11:11] is the sub-list for method output_type
+	11, // [11:11] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_evidence_proto_init() }
+func file_evidence_proto_init() {
+	if File_evidence_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_evidence_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Evidence); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_evidence_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DuplicateProposal); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_evidence_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DuplicatePrepare); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_evidence_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DuplicateCommit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_evidence_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InvalidCertificate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_evidence_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*Evidence_DuplicateProposal)(nil),
+		(*Evidence_DuplicatePrepare)(nil),
+		(*Evidence_DuplicateCommit)(nil),
+		(*Evidence_InvalidCertificate)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_evidence_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_evidence_proto_goTypes,
+		DependencyIndexes: file_evidence_proto_depIdxs,
+		MessageInfos:      file_evidence_proto_msgTypes,
+	}.Build()
+	File_evidence_proto = out.File
+	file_evidence_proto_rawDesc = nil
+	file_evidence_proto_goTypes = nil
+	file_evidence_proto_depIdxs = nil
+}
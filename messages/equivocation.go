@@ -0,0 +1,158 @@
+package messages
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// maxSenderEvidence is the number of conflicts kept for each sender, per height.
+// A single conflict already proves the misbehaviour, so the evidence of a sender
+// that keeps equivocating at higher rounds doesn't grow without bound
+const maxSenderEvidence = 8
+
+// EquivocationEvidence is the proof that a sender sent two conflicting
+// messages of the same type, for the same view
+type EquivocationEvidence struct {
+	// First is the message received first, that is kept in the store
+	First *proto.Message
+
+	// Second is the conflicting message, that is not stored
+	Second *proto.Message
+}
+
+// isEquivocation checks if the messages of the same
+// sender, type and view are for different proposals.
+// Round change messages are never considered conflicting
+func isEquivocation(first, second *proto.Message) bool {
+	switch second.Type {
+	case proto.MessageType_PREPREPARE:
+		return !bytes.Equal(
+			first.GetPreprepareData().GetProposalHash(),
+			second.GetPreprepareData().GetProposalHash(),
+		)
+	case proto.MessageType_PREPARE:
+		return !bytes.Equal(
+			first.GetPrepareData().GetProposalHash(),
+			second.GetPrepareData().GetProposalHash(),
+		)
+	case proto.MessageType_COMMIT:
+		return !bytes.Equal(
+			first.GetCommitData().GetProposalHash(),
+			second.GetCommitData().GetProposalHash(),
+		)
+	default:
+		return false
+	}
+}
+
+// evidenceKey identifies the sender, type and round of conflicting messages
+type evidenceKey struct {
+	from        string
+	round       uint64
+	messageType proto.MessageType
+}
+
+// evidenceStore keeps the equivocation evidence per height.
+// Only the first conflict is kept for each sender, type and view,
+// and only the first maxSenderEvidence conflicts for each sender
+type evidenceStore struct {
+	// evidence maps the height -> conflict -> evidence
+	evidence map[uint64]map[evidenceKey]*EquivocationEvidence
+
+	// senders maps the height -> sender -> number of conflicts kept
+	senders map[uint64]map[string]int
+
+	mux sync.Mutex
+}
+
+// newEvidenceStore creates a new empty evidence store
+func newEvidenceStore() *evidenceStore {
+	return &evidenceStore{
+		evidence: make(map[uint64]map[evidenceKey]*EquivocationEvidence),
+		senders:  make(map[uint64]map[string]int),
+	}
+}
+
+// add notes the evidence, and returns false if the conflict
+// was already noted, or the sender is over its evidence limit
+func (s *evidenceStore) add(evidence *EquivocationEvidence) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	height := evidence.First.View.Height
+
+	heightEvidence, ok := s.evidence[height]
+	if !ok {
+		heightEvidence = make(map[evidenceKey]*EquivocationEvidence)
+		s.evidence[height] = heightEvidence
+	}
+
+	key := evidenceKey{
+		from:        string(evidence.First.From),
+		round:       evidence.First.View.Round,
+		messageType: evidence.First.Type,
+	}
+
+	if _, ok := heightEvidence[key]; ok {
+		return false
+	}
+
+	heightSenders, ok := s.senders[height]
+	if !ok {
+		heightSenders = make(map[string]int)
+		s.senders[height] = heightSenders
+	}
+
+	if heightSenders[key.from] >= maxSenderEvidence {
+		return false
+	}
+
+	heightEvidence[key] = evidence
+	heightSenders[key.from]++
+
+	return true
+}
+
+// get returns the evidence for the height, ordered
+// by round, message type and sender
+func (s *evidenceStore) get(height uint64) []*EquivocationEvidence {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	evidence := make([]*EquivocationEvidence, 0, len(s.evidence[height]))
+	for _, item := range s.evidence[height] {
+		evidence = append(evidence, item)
+	}
+
+	sort.Slice(evidence, func(i, j int) bool {
+		first, second := evidence[i].First, evidence[j].First
+
+		if first.View.Round != second.View.Round {
+			return first.View.Round < second.View.Round
+		}
+
+		if first.Type != second.Type {
+			return first.Type < second.Type
+		}
+
+		return bytes.Compare(first.From, second.From) < 0
+	})
+
+	return evidence
+}
+
+// prune removes the evidence for heights below the specified one
+func (s *evidenceStore) prune(height uint64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for evidenceHeight := range s.evidence {
+		if evidenceHeight < height {
+			delete(s.evidence, evidenceHeight)
+			delete(s.senders, evidenceHeight)
+		}
+	}
+}
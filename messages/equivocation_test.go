@@ -0,0 +1,233 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildHashMessage builds a message of the specified type
+// from the sender for the view, and the proposal hash
+func buildHashMessage(
+	messageType proto.MessageType,
+	from string,
+	view *proto.View,
+	proposalHash string,
+) *proto.Message {
+	message := &proto.Message{
+		View: view,
+		From: []byte(from),
+		Type: messageType,
+	}
+
+	switch messageType {
+	case proto.MessageType_PREPREPARE:
+		message.Payload = &proto.Message_PreprepareData{
+			PreprepareData: &proto.PrePrepareMessage{
+				Proposal:     []byte("proposal"),
+				ProposalHash: []byte(proposalHash),
+			},
+		}
+	case proto.MessageType_PREPARE:
+		message.Payload = &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{
+				ProposalHash: []byte(proposalHash),
+			},
+		}
+	case proto.MessageType_COMMIT:
+		message.Payload = &proto.Message_CommitData{
+			CommitData: &proto.CommitMessage{
+				ProposalHash:  []byte(proposalHash),
+				CommittedSeal: []byte(from + " seal"),
+			},
+		}
+	case proto.MessageType_ROUND_CHANGE:
+		message.Payload = &proto.Message_RoundChangeData{
+			RoundChangeData: &proto.RoundChangeMessage{
+				LastPreparedProposedBlock: []byte(proposalHash),
+			},
+		}
+	}
+
+	return message
+}
+
+// TestIsEquivocation makes sure only messages
+// for different proposals are conflicting
+func TestIsEquivocation(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{Height: 1, Round: 0}
+
+	testTable := []struct {
+		name        string
+		messageType proto.MessageType
+		secondHash  string
+		conflicting bool
+	}{
+		{"same PREPREPARE", proto.MessageType_PREPREPARE, "hash 1", false},
+		{"conflicting PREPREPARE", proto.MessageType_PREPREPARE, "hash 2", true},
+		{"same PREPARE", proto.MessageType_PREPARE, "hash 1", false},
+		{"conflicting PREPARE", proto.MessageType_PREPARE, "hash 2", true},
+		{"same COMMIT", proto.MessageType_COMMIT, "hash 1", false},
+		{"conflicting COMMIT", proto.MessageType_COMMIT, "hash 2", true},
+		{"different ROUND_CHANGE", proto.MessageType_ROUND_CHANGE, "hash 2", false},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(
+				t,
+				testCase.conflicting,
+				isEquivocation(
+					buildHashMessage(testCase.messageType, "node 0", view, "hash 1"),
+					buildHashMessage(testCase.messageType, "node 0", view, testCase.secondHash),
+				),
+			)
+		})
+	}
+}
+
+// TestMessages_Equivocation makes sure conflicting messages are kept
+// as evidence, and the original message is the one that is stored
+func TestMessages_Equivocation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		notified = make([]*EquivocationEvidence, 0)
+	)
+
+	messages := NewMessages(WithEquivocationHandler(func(evidence *EquivocationEvidence) {
+		notified = append(notified, evidence)
+	}))
+	defer messages.Close()
+
+	var (
+		first       = buildHashMessage(proto.MessageType_PREPARE, "node 0", view, "hash 1")
+		conflicting = buildHashMessage(proto.MessageType_PREPARE, "node 0", view, "hash 2")
+		third       = buildHashMessage(proto.MessageType_PREPARE, "node 0", view, "hash 3")
+		commit      = buildHashMessage(proto.MessageType_COMMIT, "node 1", view, "hash 1")
+		commitOther = buildHashMessage(proto.MessageType_COMMIT, "node 1", view, "hash 2")
+		duplicate   = buildHashMessage(proto.MessageType_PREPARE, "node 0", view, "hash 1")
+	)
+
+	messages.AddMessage(first)
+	messages.AddMessage(conflicting)
+	messages.AddMessage(third)
+	messages.AddMessage(commit)
+	messages.AddMessage(commitOther)
+
+	// Make sure the original messages are the ones stored
+	assert.Equal(
+		t,
+		[]*proto.Message{first},
+		messages.GetValidMessages(view, proto.MessageType_PREPARE, func(*proto.Message) bool { return true }),
+	)
+	assert.Equal(
+		t,
+		[]*proto.Message{commit},
+		messages.GetValidMessages(view, proto.MessageType_COMMIT, func(*proto.Message) bool { return true }),
+	)
+
+	// Make sure only the first conflict for the view is kept, and notified
+	expected := []*EquivocationEvidence{
+		{First: first, Second: conflicting},
+		{First: commit, Second: commitOther},
+	}
+
+	assert.Equal(t, expected, notified)
+	assert.Equal(t, expected, messages.GetEquivocationEvidence(view.Height))
+
+	// Make sure non-conflicting duplicates are not evidence,
+	// and the stored message is kept
+	messages.AddMessage(duplicate)
+
+	assert.Len(t, notified, 2)
+	assert.Equal(
+		t,
+		[]*proto.Message{first},
+		messages.GetValidMessages(view, proto.MessageType_PREPARE, func(*proto.Message) bool { return true }),
+	)
+
+	// Make sure the evidence is pruned with the height
+	messages.PruneByHeight(view.Height + 1)
+
+	assert.Empty(t, messages.GetEquivocationEvidence(view.Height))
+}
+
+// TestMessages_EquivocationProposal makes sure conflicting
+// proposals from the proposer are kept as evidence
+func TestMessages_EquivocationProposal(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{Height: 1, Round: 0}
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	messages.AddMessage(buildHashMessage(proto.MessageType_PREPREPARE, "node 0", view, "hash 1"))
+	messages.AddMessage(buildHashMessage(proto.MessageType_PREPREPARE, "node 0", view, "hash 2"))
+
+	require.Equal(t, 1, messages.NumMessages(view, proto.MessageType_PREPREPARE))
+
+	evidence := messages.GetEquivocationEvidence(view.Height)
+
+	require.Len(t, evidence, 1)
+	assert.Equal(t, []byte("hash 1"), evidence[0].First.GetPreprepareData().ProposalHash)
+	assert.Equal(t, []byte("hash 2"), evidence[0].Second.GetPreprepareData().ProposalHash)
+}
+
+// TestMessages_EquivocationLimit makes sure only the first conflicts
+// of each sender are kept as evidence, per height
+func TestMessages_EquivocationLimit(t *testing.T) {
+	t.Parallel()
+
+	var (
+		height   = uint64(1)
+		notified = 0
+	)
+
+	messages := NewMessages(WithEquivocationHandler(func(*EquivocationEvidence) {
+		notified++
+	}))
+	defer messages.Close()
+
+	equivocate := func(from string, round uint64) {
+		view := &proto.View{Height: height, Round: round}
+
+		messages.AddMessage(buildHashMessage(proto.MessageType_PREPREPARE, from, view, "hash 1"))
+		messages.AddMessage(buildHashMessage(proto.MessageType_PREPREPARE, from, view, "hash 2"))
+	}
+
+	for round := uint64(0); round < 2*maxSenderEvidence; round++ {
+		equivocate("node 0", round)
+	}
+
+	evidence := messages.GetEquivocationEvidence(height)
+
+	require.Len(t, evidence, maxSenderEvidence)
+	assert.Equal(t, maxSenderEvidence, notified)
+
+	// The first conflicts are the ones kept
+	assert.Equal(t, uint64(maxSenderEvidence-1), evidence[maxSenderEvidence-1].First.View.Round)
+
+	// Other senders are not affected
+	equivocate("node 1", 0)
+
+	assert.Len(t, messages.GetEquivocationEvidence(height), maxSenderEvidence+1)
+
+	// The limit is released with the height
+	messages.PruneByHeight(height + 1)
+	height++
+
+	equivocate("node 0", 0)
+
+	assert.Len(t, messages.GetEquivocationEvidence(height), 1)
+}
@@ -4,24 +4,42 @@ import (
 	"sync"
 	"sync/atomic"
 
-	"github.com/google/uuid"
 	"github.com/madz-lab/go-ibft/messages/proto"
 )
 
 type eventManager struct {
-	subscriptions     map[SubscriptionID]*eventSubscription
+	// subscriptions maps the subscription ID -> subscription
+	subscriptions map[SubscriptionID]*eventSubscription
+
+	// index maps the message type and height -> subscriptions,
+	// so only the relevant subscriptions are signaled
+	index map[subscriptionKey]map[SubscriptionID]*eventSubscription
+
 	subscriptionsLock sync.RWMutex
 	numSubscriptions  int64
+
+	// lastID is the ID of the latest subscription.
+	// IDs are monotonic, and never reused
+	lastID SubscriptionID
+}
+
+// subscriptionKey identifies the subscriptions
+// for a message type at a specific height
+type subscriptionKey struct {
+	messageType proto.MessageType
+	height      uint64
 }
 
 func newEventManager() *eventManager {
 	return &eventManager{
 		subscriptions:    make(map[SubscriptionID]*eventSubscription),
+		index:            make(map[subscriptionKey]map[SubscriptionID]*eventSubscription),
 		numSubscriptions: 0,
 	}
 }
 
-type SubscriptionID int32
+// SubscriptionID is the unique identifier of a subscription
+type SubscriptionID uint64
 
 // Subscription is the subscription
 // returned to the user
@@ -52,6 +70,14 @@ type SubscriptionDetails struct {
 	// HasMinRound is the flag indicating if the
 	// round number is a lower bound
 	HasMinRound bool
+
+	// MinVotingPower is the threshold of accumulated voting power
+	// of the message senders being subscribed to. Zero disables the check
+	MinVotingPower uint64
+
+	// VotingPower returns the voting power of the message sender.
+	// If not set, each sender has a voting power of 1
+	VotingPower func(from []byte) uint64
 }
 
 // subscribe registers a new listener for message events
@@ -59,22 +85,31 @@ func (em *eventManager) subscribe(details SubscriptionDetails) *Subscription {
 	em.subscriptionsLock.Lock()
 	defer em.subscriptionsLock.Unlock()
 
-	id := uuid.New().ID()
-	subscription := &eventSubscription{
-		details:  details,
-		outputCh: make(chan uint64, 1),
-		doneCh:   make(chan struct{}),
-		notifyCh: make(chan uint64, 1),
-	}
+	em.lastID++
+
+	var (
+		id           = em.lastID
+		subscription = newEventSubscription(details)
+		key          = subscriptionKey{
+			messageType: details.MessageType,
+			height:      details.View.Height,
+		}
+	)
 
-	em.subscriptions[SubscriptionID(id)] = subscription
+	em.subscriptions[id] = subscription
 
-	go subscription.runLoop()
+	indexed, ok := em.index[key]
+	if !ok {
+		indexed = make(map[SubscriptionID]*eventSubscription)
+		em.index[key] = indexed
+	}
+
+	indexed[id] = subscription
 
 	atomic.AddInt64(&em.numSubscriptions, 1)
 
 	return &Subscription{
-		ID:    SubscriptionID(id),
+		ID:    id,
 		SubCh: subscription.outputCh,
 	}
 }
@@ -84,11 +119,26 @@ func (em *eventManager) cancelSubscription(id SubscriptionID) {
 	em.subscriptionsLock.Lock()
 	defer em.subscriptionsLock.Unlock()
 
-	if subscription, ok := em.subscriptions[id]; ok {
-		subscription.close()
-		delete(em.subscriptions, id)
-		atomic.AddInt64(&em.numSubscriptions, -1)
+	subscription, ok := em.subscriptions[id]
+	if !ok {
+		return
+	}
+
+	subscription.close()
+	delete(em.subscriptions, id)
+
+	key := subscriptionKey{
+		messageType: subscription.details.MessageType,
+		height:      subscription.details.View.Height,
+	}
+
+	delete(em.index[key], id)
+
+	if len(em.index[key]) == 0 {
+		delete(em.index, key)
 	}
+
+	atomic.AddInt64(&em.numSubscriptions, -1)
 }
 
 // close stops the event manager, effectively cancelling all subscriptions
@@ -100,6 +150,9 @@ func (em *eventManager) close() {
 		subscription.close()
 	}
 
+	em.subscriptions = make(map[SubscriptionID]*eventSubscription)
+	em.index = make(map[subscriptionKey]map[SubscriptionID]*eventSubscription)
+
 	atomic.StoreInt64(&em.numSubscriptions, 0)
 }
 
@@ -107,7 +160,7 @@ func (em *eventManager) close() {
 func (em *eventManager) signalEvent(
 	messageType proto.MessageType,
 	view *proto.View,
-	totalMessages int,
+	messages protoMessages,
 ) {
 	if atomic.LoadInt64(&em.numSubscriptions) == 0 {
 		// No reason to lock the subscriptions map
@@ -118,11 +171,17 @@ func (em *eventManager) signalEvent(
 	em.subscriptionsLock.RLock()
 	defer em.subscriptionsLock.RUnlock()
 
-	for _, subscription := range em.subscriptions {
+	// Only the subscriptions for the message type and height are signaled
+	key := subscriptionKey{
+		messageType: messageType,
+		height:      view.Height,
+	}
+
+	for _, subscription := range em.index[key] {
 		subscription.pushEvent(
 			messageType,
 			view,
-			totalMessages,
+			messages,
 		)
 	}
 }
@@ -46,9 +46,12 @@ func TestEventManager_SubscribeCancel(t *testing.T) {
 		quitCh <- struct{}{}
 	}()
 
+	// Messages that satisfy the subscription threshold
+	messages := protoMessages{"node 0": &proto.Message{}}
+
 	go func() {
 		for {
-			em.signalEvent(baseDetails.MessageType, baseDetails.View, baseDetails.MinNumMessages)
+			em.signalEvent(baseDetails.MessageType, baseDetails.View, messages)
 
 			select {
 			case <-quitCh:
@@ -102,3 +105,112 @@ func TestEventManager_SubscribeClose(t *testing.T) {
 		}
 	}
 }
+
+func TestEventManager_MonotonicIDs(t *testing.T) {
+	t.Parallel()
+
+	details := SubscriptionDetails{
+		MessageType: proto.MessageType_PREPARE,
+		View:        &proto.View{Height: 1},
+	}
+
+	em := newEventManager()
+	defer em.close()
+
+	var lastID SubscriptionID
+
+	for i := 0; i < 10; i++ {
+		subscription := em.subscribe(details)
+
+		// IDs are never reused, even after cancellation
+		assert.Greater(t, subscription.ID, lastID)
+
+		lastID = subscription.ID
+
+		em.cancelSubscription(subscription.ID)
+	}
+}
+
+func TestEventManager_SignalIndexed(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 2}
+		messages = protoMessages{"node 0": &proto.Message{}}
+	)
+
+	em := newEventManager()
+	defer em.close()
+
+	subscribe := func(messageType proto.MessageType, height uint64) *Subscription {
+		return em.subscribe(SubscriptionDetails{
+			MessageType: messageType,
+			View:        &proto.View{Height: height, Round: view.Round},
+		})
+	}
+
+	var (
+		signaled     = subscribe(proto.MessageType_PREPARE, view.Height)
+		otherHeight  = subscribe(proto.MessageType_PREPARE, view.Height+1)
+		otherType    = subscribe(proto.MessageType_COMMIT, view.Height)
+		alsoSignaled = subscribe(proto.MessageType_PREPARE, view.Height)
+	)
+
+	assert.Len(t, em.index, 3)
+
+	em.signalEvent(proto.MessageType_PREPARE, view, messages)
+
+	for _, subscription := range []*Subscription{signaled, alsoSignaled} {
+		assert.Equal(t, view.Round, <-subscription.SubCh)
+	}
+
+	assert.Empty(t, otherHeight.SubCh)
+	assert.Empty(t, otherType.SubCh)
+
+	// The index is cleaned up on cancellation
+	em.cancelSubscription(otherHeight.ID)
+	em.cancelSubscription(otherType.ID)
+
+	assert.Len(t, em.index, 1)
+
+	// Unknown and already cancelled subscriptions are ignored
+	em.cancelSubscription(otherHeight.ID)
+	em.cancelSubscription(SubscriptionID(1000))
+
+	assert.Equal(t, int64(2), em.numSubscriptions)
+}
+
+func BenchmarkEventManager_SignalEvent(b *testing.B) {
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		messages = protoMessages{"node 0": &proto.Message{}}
+	)
+
+	em := newEventManager()
+	defer em.close()
+
+	// Subscriptions for many other heights and message types
+	for height := uint64(0); height < 250; height++ {
+		for _, messageType := range messageTypes {
+			em.subscribe(SubscriptionDetails{
+				MessageType:    messageType,
+				View:           &proto.View{Height: height + 2},
+				MinNumMessages: 1,
+			})
+		}
+	}
+
+	sub := em.subscribe(SubscriptionDetails{
+		MessageType:    proto.MessageType_PREPARE,
+		View:           view,
+		MinNumMessages: 1,
+	})
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		em.signalEvent(proto.MessageType_PREPARE, view, messages)
+
+		<-sub.SubCh
+	}
+}
@@ -1,44 +1,74 @@
 package messages
 
 import (
+	"sync"
+
 	"github.com/madz-lab/go-ibft/messages/proto"
 )
 
 type eventSubscription struct {
-	// outputCh is the update channel for the subscriber
+	// outputCh is the update channel for the subscriber.
+	// It holds at most a single pending notification
 	outputCh chan uint64
 
-	// doneCh is the channel for handling stop signals
-	doneCh chan struct{}
+	// mux serializes the notifications and the close signal
+	mux sync.Mutex
 
-	// notifyCh is the channel for receiving event requests
-	notifyCh chan uint64
+	// closed is the flag indicating if the subscription is closed
+	closed bool
 
 	// details contains the details of the event subscription
 	details SubscriptionDetails
 }
 
-// close stops the event subscription
+// newEventSubscription creates a new event subscription
+func newEventSubscription(details SubscriptionDetails) *eventSubscription {
+	return &eventSubscription{
+		details:  details,
+		outputCh: make(chan uint64, 1),
+	}
+}
+
+// close stops the event subscription, discarding the pending notification
 func (es *eventSubscription) close() {
-	close(es.doneCh)
+	es.mux.Lock()
+	defer es.mux.Unlock()
+
+	if es.closed {
+		return
+	}
+
+	es.closed = true
+
+	select {
+	case <-es.outputCh:
+	default:
+	}
+
+	close(es.outputCh)
 }
 
-// runLoop is the main loop that listens for notifications and handles the event / close signals
-func (es *eventSubscription) runLoop() {
-	defer close(es.outputCh)
-
-	for {
-		select {
-		case <-es.doneCh: // Break if a close signal has been received
-			return
-		case round := <-es.notifyCh: // Listen for new events to appear
-			select {
-			case <-es.doneCh: // Break if a close signal has been received
-				return
-			case es.outputCh <- round: // Pass the event to the output
-			}
+// notify passes the round to the subscriber. [NON-BLOCKING]
+// If a notification is already pending, the higher round of the two is kept
+func (es *eventSubscription) notify(round uint64) {
+	es.mux.Lock()
+	defer es.mux.Unlock()
+
+	if es.closed {
+		return
+	}
+
+	select {
+	case pending := <-es.outputCh:
+		if pending > round {
+			round = pending
 		}
+	default:
 	}
+
+	// The notifications are serialized,
+	// so there is room in the channel
+	es.outputCh <- round
 }
 
 // eventSupported checks if any notification event needs to be triggered
@@ -75,18 +105,39 @@ func (es *eventSubscription) eventSupported(
 	return totalMessages >= es.details.MinNumMessages
 }
 
+// hasVotingPower checks if the accumulated voting power
+// of the message senders reaches the subscription threshold
+func (es *eventSubscription) hasVotingPower(messages protoMessages) bool {
+	if es.details.MinVotingPower == 0 {
+		return true
+	}
+
+	if es.details.VotingPower == nil {
+		return uint64(len(messages)) >= es.details.MinVotingPower
+	}
+
+	var power uint64
+
+	for from := range messages {
+		power += es.details.VotingPower([]byte(from))
+
+		if power >= es.details.MinVotingPower {
+			return true
+		}
+	}
+
+	return false
+}
+
 // pushEvent sends the event off for processing by the subscription. [NON-BLOCKING]
 func (es *eventSubscription) pushEvent(
 	messageType proto.MessageType,
 	view *proto.View,
-	totalMessages int,
+	messages protoMessages,
 ) {
-	if !es.eventSupported(messageType, view, totalMessages) {
+	if !es.eventSupported(messageType, view, len(messages)) || !es.hasVotingPower(messages) {
 		return
 	}
 
-	select {
-	case es.notifyCh <- view.Round: // Notify the worker thread
-	default:
-	}
+	es.notify(view.Round)
 }
@@ -149,12 +149,7 @@ func TestEventSubscription_EventSupported(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 			t.Parallel()
 
-			subscription := &eventSubscription{
-				details:  testCase.subscriptionDetails,
-				outputCh: make(chan uint64, 1),
-				notifyCh: make(chan uint64, 1),
-				doneCh:   make(chan struct{}),
-			}
+			subscription := newEventSubscription(testCase.subscriptionDetails)
 
 			t.Cleanup(func() {
 				subscription.close()
@@ -174,3 +169,115 @@ func TestEventSubscription_EventSupported(t *testing.T) {
 		})
 	}
 }
+
+func TestEventSubscription_HasVotingPower(t *testing.T) {
+	t.Parallel()
+
+	var (
+		messages = protoMessages{
+			"node 0": &proto.Message{},
+			"node 1": &proto.Message{},
+			"node 2": &proto.Message{},
+		}
+
+		// votingPower assigns each sender a voting power of 10,
+		// apart from node 0 which has a voting power of 50
+		votingPower = func(from []byte) uint64 {
+			if string(from) == "node 0" {
+				return 50
+			}
+
+			return 10
+		}
+	)
+
+	testTable := []struct {
+		name           string
+		votingPower    func([]byte) uint64
+		minVotingPower uint64
+		shouldSupport  bool
+	}{
+		{
+			"threshold not set",
+			votingPower,
+			0,
+			true,
+		},
+		{
+			"equal voting power reached",
+			nil,
+			3,
+			true,
+		},
+		{
+			"equal voting power not reached",
+			nil,
+			4,
+			false,
+		},
+		{
+			"weighted voting power reached",
+			votingPower,
+			70,
+			true,
+		},
+		{
+			"weighted voting power not reached",
+			votingPower,
+			71,
+			false,
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			subscription := &eventSubscription{
+				details: SubscriptionDetails{
+					MinVotingPower: testCase.minVotingPower,
+					VotingPower:    testCase.votingPower,
+				},
+			}
+
+			assert.Equal(t, testCase.shouldSupport, subscription.hasVotingPower(messages))
+		})
+	}
+}
+
+func TestEventSubscription_Notify(t *testing.T) {
+	t.Parallel()
+
+	t.Run("pending notification keeps the highest round", func(t *testing.T) {
+		t.Parallel()
+
+		subscription := newEventSubscription(SubscriptionDetails{})
+		defer subscription.close()
+
+		subscription.notify(2)
+		subscription.notify(5)
+		subscription.notify(3)
+
+		assert.Equal(t, uint64(5), <-subscription.outputCh)
+		assert.Empty(t, subscription.outputCh)
+	})
+
+	t.Run("close discards the pending notification", func(t *testing.T) {
+		t.Parallel()
+
+		subscription := newEventSubscription(SubscriptionDetails{})
+
+		subscription.notify(1)
+		subscription.close()
+
+		_, more := <-subscription.outputCh
+		assert.False(t, more)
+
+		// Notifications and close signals after
+		// the subscription is closed are ignored
+		subscription.notify(2)
+		subscription.close()
+	})
+}
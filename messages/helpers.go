@@ -11,6 +11,17 @@ type CommittedSeal struct {
 	Signature []byte
 }
 
+// AggregatedSeal is the combination of multiple committed seals
+// into a single seal (for example, an aggregated BLS signature)
+type AggregatedSeal struct {
+	// Bitmap marks the validators whose
+	// seals are part of the aggregate
+	Bitmap []byte
+
+	// Signature is the aggregated signature
+	Signature []byte
+}
+
 // ExtractCommittedSeals extracts the committed seals from the passed in messages
 func ExtractCommittedSeals(commitMessages []*proto.Message) []*CommittedSeal {
 	committedSeals := make([]*CommittedSeal, 0)
@@ -0,0 +1,189 @@
+package messages
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+var (
+	// ErrRateLimited is returned for messages from
+	// senders over their allowed message rate
+	ErrRateLimited = errors.New("sender message rate exceeded")
+
+	// ErrViewQuotaExceeded is returned for messages from senders
+	// over their quota of distinct views for the height
+	ErrViewQuotaExceeded = errors.New("sender view quota exceeded")
+)
+
+// Limiter is the policy that decides whether messages are within
+// the quota of their sender, before they are added to the store
+type Limiter interface {
+	// Allow returns an error if the message is over the quota of its sender.
+	// It's called for every added message, and can be called concurrently
+	Allow(message *proto.Message) error
+
+	// PruneByHeight drops the quotas tracked for heights below the specified one
+	PruneByHeight(height uint64)
+}
+
+// ViewQuota is the Limiter that bounds the number of distinct
+// views (rounds) each sender can have messages for, per height
+type ViewQuota struct {
+	// maxViews is the number of distinct views allowed per sender, per height
+	maxViews int
+
+	// views maps the height -> sender -> rounds of their messages
+	views map[uint64]map[string]map[uint64]struct{}
+
+	mux sync.Mutex
+}
+
+// NewViewQuota creates a new view quota that allows each
+// sender messages for up to maxViews distinct rounds per height
+func NewViewQuota(maxViews int) *ViewQuota {
+	return &ViewQuota{
+		maxViews: maxViews,
+		views:    make(map[uint64]map[string]map[uint64]struct{}),
+	}
+}
+
+// Allow makes sure the message view is either already known for the
+// sender, or the sender has room for another view at the height
+func (q *ViewQuota) Allow(message *proto.Message) error {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	senders, ok := q.views[message.View.Height]
+	if !ok {
+		senders = make(map[string]map[uint64]struct{})
+		q.views[message.View.Height] = senders
+	}
+
+	rounds, ok := senders[string(message.From)]
+	if !ok {
+		rounds = make(map[uint64]struct{})
+		senders[string(message.From)] = rounds
+	}
+
+	if _, ok := rounds[message.View.Round]; ok {
+		return nil
+	}
+
+	if len(rounds) >= q.maxViews {
+		return fmt.Errorf(
+			"%w: %d views at height %d",
+			ErrViewQuotaExceeded,
+			len(rounds),
+			message.View.Height,
+		)
+	}
+
+	rounds[message.View.Round] = struct{}{}
+
+	return nil
+}
+
+// PruneByHeight drops the views tracked for heights below the specified one
+func (q *ViewQuota) PruneByHeight(height uint64) {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+
+	for viewHeight := range q.views {
+		if viewHeight < height {
+			delete(q.views, viewHeight)
+		}
+	}
+}
+
+// RateLimiter is the Limiter that bounds the rate of messages
+// of each sender, using a token bucket per sender
+type RateLimiter struct {
+	// rate is the number of messages allowed per second
+	rate float64
+
+	// burst is the number of messages allowed at once
+	burst float64
+
+	// buckets maps the sender -> their token bucket
+	buckets map[string]*tokenBucket
+
+	// now is the time source
+	now func() time.Time
+
+	mux sync.Mutex
+}
+
+// tokenBucket holds the tokens available to a single sender
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter creates a new rate limiter that allows each sender
+// rate messages per second on average, and up to burst messages at once
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the sender,
+// after refilling it for the time passed since the last message
+func (l *RateLimiter) Allow(message *proto.Message) error {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	now := l.now()
+
+	bucket, ok := l.buckets[string(message.From)]
+	if !ok {
+		bucket = &tokenBucket{
+			tokens:  l.burst,
+			updated: now,
+		}
+
+		l.buckets[string(message.From)] = bucket
+	}
+
+	l.refill(bucket, now)
+
+	if bucket.tokens < 1 {
+		return fmt.Errorf("%w: %.2f messages per second", ErrRateLimited, l.rate)
+	}
+
+	bucket.tokens--
+
+	return nil
+}
+
+// PruneByHeight drops the buckets of senders that were idle long enough
+// to refill them, since they're equivalent to new buckets
+func (l *RateLimiter) PruneByHeight(uint64) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	now := l.now()
+
+	for from, bucket := range l.buckets {
+		if l.refill(bucket, now); bucket.tokens >= l.burst {
+			delete(l.buckets, from)
+		}
+	}
+}
+
+// refill adds the tokens accumulated since the last
+// update to the bucket, up to the burst size
+func (l *RateLimiter) refill(bucket *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens = math.Min(l.burst, bucket.tokens+elapsed.Seconds()*l.rate)
+		bucket.updated = now
+	}
+}
@@ -0,0 +1,204 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// buildLimiterMessage builds a PREPARE message from the sender for the view
+func buildLimiterMessage(from string, height, round uint64) *proto.Message {
+	return &proto.Message{
+		View: &proto.View{Height: height, Round: round},
+		From: []byte(from),
+		Type: proto.MessageType_PREPARE,
+	}
+}
+
+// TestViewQuota makes sure senders are limited
+// to the number of distinct views per height
+func TestViewQuota(t *testing.T) {
+	t.Parallel()
+
+	quota := NewViewQuota(2)
+
+	assert.NoError(t, quota.Allow(buildLimiterMessage("node 0", 1, 0)))
+	assert.NoError(t, quota.Allow(buildLimiterMessage("node 0", 1, 5)))
+
+	// Known views are always allowed
+	assert.NoError(t, quota.Allow(buildLimiterMessage("node 0", 1, 0)))
+
+	// Make sure the quota is per sender, and per height
+	assert.ErrorIs(t, quota.Allow(buildLimiterMessage("node 0", 1, 6)), ErrViewQuotaExceeded)
+	assert.NoError(t, quota.Allow(buildLimiterMessage("node 1", 1, 6)))
+	assert.NoError(t, quota.Allow(buildLimiterMessage("node 0", 2, 6)))
+
+	// Make sure pruned heights are released
+	quota.PruneByHeight(2)
+
+	assert.Len(t, quota.views, 1)
+	assert.NoError(t, quota.Allow(buildLimiterMessage("node 0", 1, 6)))
+}
+
+// TestRateLimiter makes sure senders are limited
+// to the message rate, with bursts
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		now     = time.Unix(0, 0)
+		limiter = NewRateLimiter(2, 3)
+	)
+
+	limiter.now = func() time.Time {
+		return now
+	}
+
+	// The burst is allowed at once
+	for round := uint64(0); round < 3; round++ {
+		assert.NoError(t, limiter.Allow(buildLimiterMessage("node 0", 1, round)))
+	}
+
+	assert.ErrorIs(t, limiter.Allow(buildLimiterMessage("node 0", 1, 3)), ErrRateLimited)
+
+	// Make sure the limit is per sender
+	assert.NoError(t, limiter.Allow(buildLimiterMessage("node 1", 1, 0)))
+
+	// A single message is allowed after half a second
+	now = now.Add(500 * time.Millisecond)
+
+	assert.NoError(t, limiter.Allow(buildLimiterMessage("node 0", 1, 3)))
+	assert.ErrorIs(t, limiter.Allow(buildLimiterMessage("node 0", 1, 4)), ErrRateLimited)
+
+	// Make sure the buckets are capped at the burst size
+	now = now.Add(time.Hour)
+
+	for round := uint64(4); round < 7; round++ {
+		assert.NoError(t, limiter.Allow(buildLimiterMessage("node 0", 1, round)))
+	}
+
+	assert.ErrorIs(t, limiter.Allow(buildLimiterMessage("node 0", 1, 7)), ErrRateLimited)
+
+	// Make sure only the buckets of idle senders are pruned
+	limiter.PruneByHeight(2)
+
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "node 0")
+}
+
+// TestMessages_Limiter makes sure messages over the quota of their
+// sender are discarded, and reported to the handler
+func TestMessages_Limiter(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		rejected = make([]error, 0)
+
+		now         = time.Unix(0, 0)
+		rateLimiter = NewRateLimiter(1, 1)
+	)
+
+	rateLimiter.now = func() time.Time {
+		return now
+	}
+
+	messages := NewMessages(
+		WithLimiter(NewViewQuota(1)),
+		WithLimiter(rateLimiter),
+		WithQuotaExceededHandler(func(message *proto.Message, err error) {
+			assert.Equal(t, []byte("node 0"), message.From)
+
+			rejected = append(rejected, err)
+		}),
+	)
+	defer messages.Close()
+
+	subscription := messages.Subscribe(SubscriptionDetails{
+		MessageType:    proto.MessageType_PREPARE,
+		View:           &proto.View{Height: 1, Round: 1},
+		MinNumMessages: 1,
+	})
+
+	defer messages.Unsubscribe(subscription.ID)
+
+	messages.AddMessage(buildLimiterMessage("node 0", 1, 0))
+
+	// Over the view quota
+	messages.AddMessage(buildLimiterMessage("node 0", 1, 1))
+
+	// Over the message rate
+	resigned := buildLimiterMessage("node 0", 1, 0)
+	resigned.Signature = []byte("signature")
+
+	messages.AddMessage(resigned)
+
+	assert.Equal(t, 1, messages.NumMessages(view, proto.MessageType_PREPARE))
+	assert.Equal(t, 0, messages.NumMessages(&proto.View{Height: 1, Round: 1}, proto.MessageType_PREPARE))
+
+	if assert.Len(t, rejected, 2) {
+		assert.ErrorIs(t, rejected[0], ErrViewQuotaExceeded)
+		assert.ErrorIs(t, rejected[1], ErrRateLimited)
+	}
+
+	// Make sure rejected messages don't trigger subscriptions
+	select {
+	case <-subscription.SubCh:
+		t.Fatal("subscription triggered by a rejected message")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Make sure pruning releases the quotas
+	now = now.Add(time.Second)
+
+	messages.PruneByHeight(2)
+	messages.AddMessage(buildLimiterMessage("node 0", 2, 0))
+
+	assert.Equal(t, 1, messages.NumMessages(&proto.View{Height: 2, Round: 0}, proto.MessageType_PREPARE))
+}
+
+// TestMessages_Limiter_Duplicates makes sure exact duplicates,
+// like retransmissions, don't use up the quota of their sender
+func TestMessages_Limiter_Duplicates(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view     = &proto.View{Height: 1, Round: 0}
+		rejected = make([]error, 0)
+
+		rateLimiter = NewRateLimiter(1, 1)
+	)
+
+	rateLimiter.now = func() time.Time {
+		return time.Unix(0, 0)
+	}
+
+	messages := NewMessages(
+		WithLimiter(rateLimiter),
+		WithQuotaExceededHandler(func(_ *proto.Message, err error) {
+			rejected = append(rejected, err)
+		}),
+	)
+	defer messages.Close()
+
+	messages.AddMessage(buildLimiterMessage("node 0", 1, 0))
+
+	for retransmission := 0; retransmission < 3; retransmission++ {
+		messages.AddMessage(buildLimiterMessage("node 0", 1, 0))
+	}
+
+	assert.Empty(t, rejected)
+	assert.Equal(t, 1, messages.NumMessages(view, proto.MessageType_PREPARE))
+
+	// A message that differs from the stored one still counts
+	resigned := buildLimiterMessage("node 0", 1, 0)
+	resigned.Signature = []byte("signature")
+
+	messages.AddMessage(resigned)
+
+	if assert.Len(t, rejected, 1) {
+		assert.ErrorIs(t, rejected[0], ErrRateLimited)
+	}
+}
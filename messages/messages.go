@@ -2,23 +2,70 @@ package messages
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/madz-lab/go-ibft/messages/proto"
+	protobuf "google.golang.org/protobuf/proto"
 )
 
+// DefaultMaxSize is the default limit of the accumulated
+// encoded size of the stored messages, in bytes
+const DefaultMaxSize = 256 * 1024 * 1024
+
+// messageTypes are all the message types, in the order
+// their index locks are acquired when all of them are needed
+var messageTypes = []proto.MessageType{
+	proto.MessageType_PREPREPARE,
+	proto.MessageType_PREPARE,
+	proto.MessageType_COMMIT,
+	proto.MessageType_ROUND_CHANGE,
+}
+
 // Messages contains the relevant messages for each view (height, round)
 type Messages struct {
 	// manager for incoming message events
 	eventManager *eventManager
 
-	// mutex map that protects different message type queues
-	muxMap map[proto.MessageType]*sync.RWMutex
+	// metrics is the sink for message metrics
+	metrics Metrics
+
+	// typeMessages are the messages of each type, sharded by view
+	typeMessages map[proto.MessageType]*typeMessages
+
+	// maxSize is the limit of the accumulated encoded
+	// size of the stored messages, in bytes. Zero means there is no limit
+	maxSize int64
+
+	// size is the accumulated encoded size of the stored messages, in bytes
+	size atomic.Int64
+
+	// lowestHeight is the height the messages were last pruned by.
+	// Views at this height are only evicted above the current round
+	lowestHeight atomic.Uint64
+
+	// currentRound is the round the node is at, at the lowest height
+	currentRound atomic.Uint64
+
+	// evictLock makes sure only a single eviction runs at a time
+	evictLock sync.Mutex
+
+	// limiters are the policies that decide whether
+	// messages are within the quota of their sender
+	limiters []Limiter
+
+	// quotaExceededHandler is the optional handler that is
+	// notified of messages over the quota of their sender
+	quotaExceededHandler func(message *proto.Message, err error)
+
+	// evidence keeps the proof of conflicting messages from the same sender
+	evidence *evidenceStore
+
+	// equivocationHandler is the optional handler
+	// that is notified of new equivocation evidence
+	equivocationHandler func(evidence *EquivocationEvidence)
 
-	// message maps for different message types
-	preprepareMessages,
-	prepareMessages,
-	commitMessages,
-	roundChangeMessages heightMessageMap
+	// verdicts memoizes the validation verdicts of the stored messages
+	verdicts *verdictCache
 }
 
 // Subscribe creates a new message type subscription
@@ -27,14 +74,27 @@ func (ms *Messages) Subscribe(details SubscriptionDetails) *Subscription {
 	subscription := ms.eventManager.subscribe(details)
 
 	// Check if any condition is already met
-	if numMessages := ms.numMessages(
-		details.View,
-		details.MessageType,
-	); numMessages >= details.MinNumMessages {
-		// Conditions are already met, alert the event manager
-		ms.eventManager.signalEvent(details.MessageType, details.View, numMessages)
+	typeMessages := ms.getTypeMessages(details.MessageType)
+	typeMessages.mux.RLock()
+	defer typeMessages.mux.RUnlock()
+
+	var messages protoMessages
+
+	if viewMessages := typeMessages.getView(details.View); viewMessages != nil {
+		viewMessages.mux.RLock()
+		defer viewMessages.mux.RUnlock()
+
+		messages = viewMessages.messages
 	}
 
+	// The subscription filters out the event
+	// if the conditions are not met
+	ms.eventManager.signalEvent(
+		details.MessageType,
+		details.View,
+		messages,
+	)
+
 	return subscription
 }
 
@@ -44,36 +104,129 @@ func (ms *Messages) Unsubscribe(id SubscriptionID) {
 }
 
 // NewMessages returns a new Messages wrapper
-func NewMessages() *Messages {
-	return &Messages{
-		preprepareMessages:  make(heightMessageMap),
-		prepareMessages:     make(heightMessageMap),
-		commitMessages:      make(heightMessageMap),
-		roundChangeMessages: make(heightMessageMap),
+func NewMessages(opts ...Option) *Messages {
+	ms := &Messages{
+		metrics: NoopMetrics{},
+		maxSize: DefaultMaxSize,
 
 		eventManager: newEventManager(),
+		evidence:     newEvidenceStore(),
+		verdicts:     newVerdictCache(),
 
-		muxMap: map[proto.MessageType]*sync.RWMutex{
-			proto.MessageType_PREPREPARE:   {},
-			proto.MessageType_PREPARE:      {},
-			proto.MessageType_COMMIT:       {},
-			proto.MessageType_ROUND_CHANGE: {},
-		},
+		typeMessages: make(map[proto.MessageType]*typeMessages, len(messageTypes)),
+	}
+
+	for _, messageType := range messageTypes {
+		ms.typeMessages[messageType] = newTypeMessages()
+	}
+
+	for _, opt := range opts {
+		opt(ms)
 	}
+
+	return ms
 }
 
-// AddMessage adds a new message to the message queue
+// AddMessage adds a new message to the message queue.
+// Exact duplicates of stored messages, like retransmissions, are dropped
+// before the quota is checked, so they don't use it up.
+// Messages over the quota of their sender are discarded.
+// A message that conflicts with the one already stored for the same
+// sender, type and view is not stored, and both are kept as equivocation
+// evidence. If the size limit is exceeded, the farthest views are evicted
 func (ms *Messages) AddMessage(message *proto.Message) {
-	mux := ms.muxMap[message.Type]
-	mux.Lock()
-	defer mux.Unlock()
+	if ms.isDuplicate(message) {
+		return
+	}
+
+	if err := ms.checkQuota(message); err != nil {
+		ms.metrics.IncRejected(message.Type)
+
+		if ms.quotaExceededHandler != nil {
+			ms.quotaExceededHandler(message, err)
+		}
+
+		return
+	}
+
+	if evidence := ms.addMessage(message); evidence != nil {
+		if ms.evidence.add(evidence) && ms.equivocationHandler != nil {
+			ms.equivocationHandler(evidence)
+		}
 
-	// Get the corresponding height map
-	heightMsgMap := ms.getMessageMap(message.Type)
+		return
+	}
+
+	// The eviction acquires the index locks of all message types,
+	// so it runs only after the view lock is released
+	ms.evict()
+}
+
+// isDuplicate checks if the exact same message is already
+// stored for the sender, type and view
+func (ms *Messages) isDuplicate(message *proto.Message) bool {
+	typeMessages := ms.getTypeMessages(message.Type)
+	typeMessages.mux.RLock()
+	defer typeMessages.mux.RUnlock()
+
+	viewMessages := typeMessages.getView(message.View)
+	if viewMessages == nil {
+		return false
+	}
+
+	viewMessages.mux.RLock()
+	defer viewMessages.mux.RUnlock()
+
+	previous, ok := viewMessages.messages[string(message.From)]
+
+	return ok && (previous == message || protobuf.Equal(previous, message))
+}
+
+// checkQuota makes sure the message is within the quota of its sender,
+// for all limiters. The first limiter to reject the message stops the check
+func (ms *Messages) checkQuota(message *proto.Message) error {
+	for _, limiter := range ms.limiters {
+		if err := limiter.Allow(message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addMessage adds a new message to the message queue, and signals the subscribers.
+// If the message conflicts with the stored one from the same sender,
+// it's not added, and the equivocation evidence is returned instead
+func (ms *Messages) addMessage(message *proto.Message) *EquivocationEvidence {
+	typeMessages := ms.getTypeMessages(message.Type)
+
+	// Only the view of the message is locked for writing,
+	// so messages for other views are added concurrently
+	viewMessages := typeMessages.acquireView(message.View)
+	defer typeMessages.mux.RUnlock()
+
+	viewMessages.mux.Lock()
+	defer viewMessages.mux.Unlock()
+
+	messages := viewMessages.messages
+
+	if previous, ok := messages[string(message.From)]; ok {
+		// The original message stays the one counted toward quorum
+		if isEquivocation(previous, message) {
+			return &EquivocationEvidence{
+				First:  previous,
+				Second: message,
+			}
+		}
+
+		ms.release(previous)
+	}
 
-	// Append the message to the appropriate queue
-	messages := heightMsgMap.getViewMessages(message.View)
 	messages[string(message.From)] = message
+	ms.size.Add(int64(protobuf.Size(message)))
+
+	ms.metrics.IncMessage(message.Type)
+	ms.metrics.SetSize(ms.Size())
 
 	ms.eventManager.signalEvent(
 		message.Type,
@@ -81,121 +234,282 @@ func (ms *Messages) AddMessage(message *proto.Message) {
 			Height: message.View.Height,
 			Round:  message.View.Round,
 		},
-		len(messages),
+		messages,
 	)
+
+	return nil
 }
 
 func (ms *Messages) Close() {
 	ms.eventManager.close()
 }
 
-// getMessageMap fetches the corresponding message map by type
-func (ms *Messages) getMessageMap(messageType proto.MessageType) heightMessageMap {
-	switch messageType {
-	case proto.MessageType_PREPREPARE:
-		return ms.preprepareMessages
-	case proto.MessageType_PREPARE:
-		return ms.prepareMessages
-	case proto.MessageType_COMMIT:
-		return ms.commitMessages
-	case proto.MessageType_ROUND_CHANGE:
-		return ms.roundChangeMessages
+// GetEquivocationEvidence returns the evidence of conflicting messages
+// received for the height, ordered by round, message type and sender.
+// Only the first conflict is kept for each sender, type and view
+func (ms *Messages) GetEquivocationEvidence(height uint64) []*EquivocationEvidence {
+	return ms.evidence.get(height)
+}
+
+// Size returns the accumulated encoded size of the stored messages, in bytes
+func (ms *Messages) Size() int {
+	return int(ms.size.Load())
+}
+
+// release accounts for the removal of the message from the store
+func (ms *Messages) release(message *proto.Message) {
+	ms.size.Add(-int64(protobuf.Size(message)))
+	ms.verdicts.drop(message)
+}
+
+// evict removes the views farthest from the lowest height (the highest height,
+// and the highest round within it) until the stored messages fit the size limit.
+// Views at the lowest height are only evicted above the current round (see SetRound),
+// so the current sequence can progress
+func (ms *Messages) evict() {
+	if ms.maxSize <= 0 || ms.size.Load() <= ms.maxSize {
+		return
 	}
 
-	return nil
+	ms.evictLock.Lock()
+	defer ms.evictLock.Unlock()
+
+	for _, messageType := range messageTypes {
+		typeMessages := ms.getTypeMessages(messageType)
+		typeMessages.mux.Lock()
+
+		defer typeMessages.mux.Unlock()
+	}
+
+	for ms.size.Load() > ms.maxSize {
+		view, found := ms.farthestView()
+		if !found {
+			break
+		}
+
+		ms.evictView(view)
+	}
+
+	ms.metrics.SetSize(ms.Size())
 }
 
-// numMessages returns the number of messages received for the specific type
-func (ms *Messages) numMessages(
-	view *proto.View,
-	messageType proto.MessageType,
-) int {
-	mux := ms.muxMap[messageType]
-	mux.RLock()
-	defer mux.RUnlock()
+// farthestView finds the evictable view with the highest height, and the
+// highest round within it, across all message types.
+// The index locks of all message types need to be held for writing
+func (ms *Messages) farthestView() (*proto.View, bool) {
+	var (
+		lowestHeight = ms.lowestHeight.Load()
+		currentRound = ms.currentRound.Load()
+		farthest     *proto.View
+	)
 
-	heightMsgMap := ms.getMessageMap(messageType)
+	for _, messageType := range messageTypes {
+		for height, roundMsgMap := range ms.getTypeMessages(messageType).heights {
+			if height < lowestHeight {
+				continue
+			}
 
-	// Check if the round map is present
-	roundMsgMap, found := heightMsgMap[view.Height]
-	if !found {
-		return 0
+			for round := range roundMsgMap {
+				if height == lowestHeight && round <= currentRound {
+					// The views the node is at are never evicted
+					continue
+				}
+
+				if farthest == nil ||
+					height > farthest.Height ||
+					(height == farthest.Height && round > farthest.Round) {
+					farthest = &proto.View{Height: height, Round: round}
+				}
+			}
+		}
 	}
 
-	// Check if the messages array is present
-	messages, found := roundMsgMap[view.Round]
-	if !found {
+	return farthest, farthest != nil
+}
+
+// evictView removes the messages of all types for the view.
+// The index locks of all message types need to be held for writing
+func (ms *Messages) evictView(view *proto.View) {
+	for _, messageType := range messageTypes {
+		heightMsgMap := ms.getTypeMessages(messageType).heights
+
+		roundMsgMap, found := heightMsgMap[view.Height]
+		if !found {
+			continue
+		}
+
+		if viewMessages, found := roundMsgMap[view.Round]; found {
+			for _, message := range viewMessages.messages {
+				ms.release(message)
+				ms.metrics.IncEvicted(messageType)
+			}
+		}
+
+		delete(roundMsgMap, view.Round)
+
+		if len(roundMsgMap) == 0 {
+			delete(heightMsgMap, view.Height)
+		}
+	}
+}
+
+// getTypeMessages fetches the corresponding messages by type
+func (ms *Messages) getTypeMessages(messageType proto.MessageType) *typeMessages {
+	return ms.typeMessages[messageType]
+}
+
+// NumMessages returns the number of messages received for the specific type and view
+func (ms *Messages) NumMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+) int {
+	typeMessages := ms.getTypeMessages(messageType)
+	typeMessages.mux.RLock()
+	defer typeMessages.mux.RUnlock()
+
+	// Check if the messages are present
+	viewMessages := typeMessages.getView(view)
+	if viewMessages == nil {
 		return 0
 	}
 
-	return len(messages)
+	return viewMessages.len()
 }
 
 // PruneByHeight prunes out all old messages from the message queues
 // by the specified height in the view
 func (ms *Messages) PruneByHeight(height uint64) {
-	possibleMaps := []proto.MessageType{
-		proto.MessageType_PREPREPARE,
-		proto.MessageType_PREPARE,
-		proto.MessageType_COMMIT,
-		proto.MessageType_ROUND_CHANGE,
+	ms.evidence.prune(height)
+
+	for _, limiter := range ms.limiters {
+		limiter.PruneByHeight(height)
+	}
+
+	// Views at the pruned height are no longer evictable,
+	// up until the current round at that height
+	for {
+		lowestHeight := ms.lowestHeight.Load()
+		if lowestHeight >= height {
+			break
+		}
+
+		if ms.lowestHeight.CompareAndSwap(lowestHeight, height) {
+			ms.currentRound.Store(0)
+
+			break
+		}
 	}
 
 	// Prune out the views from all possible message types
-	for _, messageType := range possibleMaps {
-		mux := ms.muxMap[messageType]
-		mux.Lock()
+	for _, messageType := range messageTypes {
+		typeMessages := ms.getTypeMessages(messageType)
+		typeMessages.mux.Lock()
 
-		messageMap := ms.getMessageMap(messageType)
+		messageMap := typeMessages.heights
 
 		// Delete all height maps up until the specified
 		// view height
-		for msgHeight := range messageMap {
-			if msgHeight < height {
-				delete(messageMap, msgHeight)
+		for msgHeight, roundMsgMap := range messageMap {
+			if msgHeight >= height {
+				continue
+			}
+
+			for _, viewMessages := range roundMsgMap {
+				for _, message := range viewMessages.messages {
+					ms.release(message)
+				}
 			}
+
+			delete(messageMap, msgHeight)
 		}
 
-		mux.Unlock()
+		typeMessages.mux.Unlock()
 	}
+
+	ms.metrics.SetSize(ms.Size())
 }
 
-// getProtoMessages fetches the underlying proto messages for the specified view
-// and message type
-func (ms *Messages) getProtoMessages(
+// SetRound notes the round the node is at, at the height the messages were
+// last pruned by. The views at that height above the round are evictable,
+// so messages for rounds far ahead can't exhaust the size limit
+func (ms *Messages) SetRound(round uint64) {
+	ms.currentRound.Store(round)
+}
+
+// snapshotMessages returns a copy of the messages
+// for the specified view and message type
+func (ms *Messages) snapshotMessages(
 	view *proto.View,
 	messageType proto.MessageType,
 ) protoMessages {
-	heightMsgMap := ms.getMessageMap(messageType)
+	typeMessages := ms.getTypeMessages(messageType)
+	typeMessages.mux.RLock()
+	defer typeMessages.mux.RUnlock()
 
-	// Check if the round map is present
-	roundMsgMap, found := heightMsgMap[view.Height]
-	if !found {
+	viewMessages := typeMessages.getView(view)
+	if viewMessages == nil {
 		return nil
 	}
 
-	return roundMsgMap[view.Round]
+	return viewMessages.snapshot()
+}
+
+// settleMessages calls settle for each of the validated messages
+// of the view that is still stored, while holding the view lock.
+// Messages replaced or removed during validation are skipped
+func (ms *Messages) settleMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+	validated protoMessages,
+	settle func(messages protoMessages, key string),
+) {
+	if len(validated) == 0 {
+		return
+	}
+
+	typeMessages := ms.getTypeMessages(messageType)
+	typeMessages.mux.RLock()
+	defer typeMessages.mux.RUnlock()
+
+	viewMessages := typeMessages.getView(view)
+	if viewMessages == nil {
+		return
+	}
+
+	viewMessages.mux.Lock()
+	defer viewMessages.mux.Unlock()
+
+	for key, message := range validated {
+		if viewMessages.messages[key] != message {
+			continue
+		}
+
+		settle(viewMessages.messages, key)
+	}
+}
+
+// pruneMessage removes the invalid message from the view messages
+func (ms *Messages) pruneMessage(messages protoMessages, key string) {
+	ms.release(messages[key])
+	delete(messages, key)
 }
 
 // GetValidMessages fetches all messages of a specific type for the specified view,
-// that pass the validity check; invalid messages are pruned out
+// that pass the validity check; invalid messages are pruned out.
+// The validation runs without holding any locks, so messages are added concurrently
 func (ms *Messages) GetValidMessages(
 	view *proto.View,
 	messageType proto.MessageType,
 	isValid func(message *proto.Message) bool,
 ) []*proto.Message {
-	mux := ms.muxMap[messageType]
-	mux.Lock()
-	defer mux.Unlock()
-
-	validMessages := make([]*proto.Message, 0)
-
-	invalidMessageKeys := make([]string, 0)
-	messages := ms.getProtoMessages(view, messageType)
+	var (
+		validMessages   = make([]*proto.Message, 0)
+		invalidMessages = make(protoMessages)
+	)
 
-	for key, message := range messages {
+	for key, message := range ms.snapshotMessages(view, messageType) {
 		if !isValid(message) {
-			invalidMessageKeys = append(invalidMessageKeys, key)
+			invalidMessages[key] = message
 
 			continue
 		}
@@ -204,23 +518,89 @@ func (ms *Messages) GetValidMessages(
 	}
 
 	// Prune out invalid messages
-	for _, key := range invalidMessageKeys {
-		delete(messages, key)
+	ms.settleMessages(view, messageType, invalidMessages, ms.pruneMessage)
+
+	return validMessages
+}
+
+// GetCachedValidMessages fetches all messages of a specific type for the specified view,
+// that pass the validity check; invalid messages are pruned out.
+// The verdicts are memoized per message and validation context, so isValid
+// is called only with the messages not yet found valid in the context, in a single batch,
+// and returns the verdict for each of them. The context must capture the state the
+// validity depends on, apart from the message itself (the view is part of the message).
+// The verdicts of a message are dropped once it is replaced, pruned or evicted.
+// The validation runs without holding any locks, so messages are added concurrently
+func (ms *Messages) GetCachedValidMessages(
+	view *proto.View,
+	messageType proto.MessageType,
+	context string,
+	isValid func(messages []*proto.Message) []bool,
+) []*proto.Message {
+	var (
+		validMessages = make([]*proto.Message, 0)
+
+		pendingKeys     = make([]string, 0)
+		pendingMessages = make([]*proto.Message, 0)
+	)
+
+	for key, message := range ms.snapshotMessages(view, messageType) {
+		if ms.verdicts.isValid(message, context) {
+			validMessages = append(validMessages, message)
+
+			continue
+		}
+
+		pendingKeys = append(pendingKeys, key)
+		pendingMessages = append(pendingMessages, message)
+	}
+
+	if len(pendingMessages) == 0 {
+		return validMessages
 	}
 
+	var (
+		verdicts = isValid(pendingMessages)
+
+		validated = make(protoMessages, len(pendingMessages))
+		valid     = make(map[string]bool, len(pendingMessages))
+	)
+
+	for index, message := range pendingMessages {
+		validated[pendingKeys[index]] = message
+
+		// Messages without a verdict are considered invalid
+		if index >= len(verdicts) || !verdicts[index] {
+			continue
+		}
+
+		valid[pendingKeys[index]] = true
+
+		validMessages = append(validMessages, message)
+	}
+
+	// Prune out invalid messages, and memoize the verdicts of valid ones
+	ms.settleMessages(view, messageType, validated, func(messages protoMessages, key string) {
+		if !valid[key] {
+			ms.pruneMessage(messages, key)
+
+			return
+		}
+
+		ms.verdicts.setValid(messages[key], context)
+	})
+
 	return validMessages
 }
 
 // GetMostRoundChangeMessages fetches most round change messages
 // for the minimum round and above
 func (ms *Messages) GetMostRoundChangeMessages(minRound, height uint64) []*proto.Message {
-	messageType := proto.MessageType_ROUND_CHANGE
-
-	mux := ms.muxMap[messageType]
-	mux.RLock()
-	defer mux.RUnlock()
+	typeMessages := ms.getTypeMessages(proto.MessageType_ROUND_CHANGE)
+	typeMessages.mux.RLock()
+	defer typeMessages.mux.RUnlock()
 
-	roundMessageMap := ms.getMessageMap(messageType)[height]
+	roundMessageMap := typeMessages.heights[height]
 
 	var (
 		bestRound              = uint64(0)
@@ -232,7 +612,7 @@ func (ms *Messages) GetMostRoundChangeMessages(minRound, height uint64) []*proto
 			continue
 		}
 
-		size := len(msgs)
+		size := msgs.len()
 		if size > bestRoundMessagesCount {
 			bestRound = round
 			bestRoundMessagesCount = size
@@ -244,46 +624,24 @@ func (ms *Messages) GetMostRoundChangeMessages(minRound, height uint64) []*proto
 		return nil
 	}
 
-	messages := make([]*proto.Message, 0, bestRoundMessagesCount)
-	for _, msg := range roundMessageMap[bestRound] {
-		messages = append(messages, msg)
-	}
-
-	return messages
+	return roundMessageMap[bestRound].list()
 }
 
-// heightMessageMap maps the height number -> round message map
-type heightMessageMap map[uint64]roundMessageMap
-
-// roundMessageMap maps the round number -> messages
-type roundMessageMap map[uint64]protoMessages
-
-// protoMessages is the set of messages that circulate.
-// It contains a mapping between the sender and their messages to avoid duplicates
-type protoMessages map[string]*proto.Message
-
-// getViewMessages fetches the message queue for the specified view (height + round).
-// It will initialize a new message array if it's not found
-func (m heightMessageMap) getViewMessages(view *proto.View) protoMessages {
-	var (
-		height = view.Height
-		round  = view.Round
-	)
-
-	// Check if the height is present
-	roundMessages, exists := m[height]
-	if !exists {
-		roundMessages = roundMessageMap{}
+// GetRoundChangeMessages fetches all round change messages
+// for the minimum round and above
+func (ms *Messages) GetRoundChangeMessages(minRound, height uint64) []*proto.Message {
+	typeMessages := ms.getTypeMessages(proto.MessageType_ROUND_CHANGE)
+	typeMessages.mux.RLock()
+	defer typeMessages.mux.RUnlock()
 
-		m[height] = roundMessages
-	}
+	messages := make([]*proto.Message, 0)
 
-	// Check if the round is present
-	messages, exists := roundMessages[round]
-	if !exists {
-		messages = protoMessages{}
+	for round, msgs := range typeMessages.heights[height] {
+		if round < minRound {
+			continue
+		}
 
-		roundMessages[round] = messages
+		messages = append(messages, msgs.list()...)
 	}
 
 	return messages
@@ -7,6 +7,7 @@ import (
 
 	"github.com/madz-lab/go-ibft/messages/proto"
 	"github.com/stretchr/testify/assert"
+	protobuf "google.golang.org/protobuf/proto"
 )
 
 // generateRandomMessages generates random messages for the
@@ -82,9 +83,9 @@ func TestMessages_AddMessage(t *testing.T) {
 	}
 
 	// Make sure that the messages are present
-	assert.Equal(t, numMessages, messages.numMessages(initialView, proto.MessageType_PREPARE))
-	assert.Equal(t, numMessages, messages.numMessages(initialView, proto.MessageType_COMMIT))
-	assert.Equal(t, numMessages, messages.numMessages(initialView, proto.MessageType_ROUND_CHANGE))
+	assert.Equal(t, numMessages, messages.NumMessages(initialView, proto.MessageType_PREPARE))
+	assert.Equal(t, numMessages, messages.NumMessages(initialView, proto.MessageType_COMMIT))
+	assert.Equal(t, numMessages, messages.NumMessages(initialView, proto.MessageType_ROUND_CHANGE))
 }
 
 // TestMessages_AddDuplicates tests that no duplicates
@@ -118,7 +119,56 @@ func TestMessages_AddDuplicates(t *testing.T) {
 	}
 
 	// Check that only 1 message has been added
-	assert.Equal(t, 1, messages.numMessages(initialView, commonType))
+	assert.Equal(t, 1, messages.NumMessages(initialView, commonType))
+}
+
+// mockMetrics is the message metrics sink that counts messages per type
+type mockMetrics map[proto.MessageType]int
+
+func (m mockMetrics) IncMessage(messageType proto.MessageType) {
+	m[messageType]++
+}
+
+func (m mockMetrics) IncEvicted(proto.MessageType)  {}
+func (m mockMetrics) IncRejected(proto.MessageType) {}
+func (m mockMetrics) SetSize(int)                   {}
+
+// TestMessages_Metrics makes sure added messages
+// are reported to the metrics sink
+func TestMessages_Metrics(t *testing.T) {
+	t.Parallel()
+
+	var (
+		numMessages = 5
+		metrics     = make(mockMetrics)
+		initialView = &proto.View{
+			Height: 1,
+			Round:  1,
+		}
+	)
+
+	messages := NewMessages(WithMetrics(metrics))
+	defer messages.Close()
+
+	randomMessages := generateRandomMessages(
+		numMessages,
+		initialView,
+		proto.MessageType_PREPARE,
+		proto.MessageType_COMMIT,
+	)
+
+	for _, message := range randomMessages {
+		messages.AddMessage(message)
+	}
+
+	assert.Equal(
+		t,
+		mockMetrics{
+			proto.MessageType_PREPARE: numMessages,
+			proto.MessageType_COMMIT:  numMessages,
+		},
+		metrics,
+	)
 }
 
 // TestMessages_Prune tests if pruning of certain messages works
@@ -162,13 +212,13 @@ func TestMessages_Prune(t *testing.T) {
 	messages.PruneByHeight(views[1].Height + 1)
 
 	// Make sure the round 1 messages are pruned out
-	assert.Equal(t, 0, messages.numMessages(views[0], messageType))
+	assert.Equal(t, 0, messages.NumMessages(views[0], messageType))
 
 	// Make sure the round 2 messages are pruned out
-	assert.Equal(t, 0, messages.numMessages(views[1], messageType))
+	assert.Equal(t, 0, messages.NumMessages(views[1], messageType))
 
 	// Make sure the round 3 messages are pruned out
-	assert.Equal(t, 0, messages.numMessages(views[2], messageType))
+	assert.Equal(t, 0, messages.NumMessages(views[2], messageType))
 }
 
 // TestMessages_GetMessage makes sure
@@ -235,7 +285,7 @@ func TestMessages_GetValidMessagesMessage(t *testing.T) {
 			assert.Equal(
 				t,
 				numMessages,
-				messages.numMessages(defaultView, testCase.messageType),
+				messages.NumMessages(defaultView, testCase.messageType),
 			)
 
 			// Start fetching messages and making sure they're not cleared
@@ -253,7 +303,7 @@ func TestMessages_GetValidMessagesMessage(t *testing.T) {
 			assert.Equal(
 				t,
 				0,
-				messages.numMessages(defaultView, testCase.messageType),
+				messages.NumMessages(defaultView, testCase.messageType),
 			)
 		})
 	}
@@ -303,6 +353,112 @@ func TestMessages_GetMostRoundChangeMessages(t *testing.T) {
 	assert.Equal(t, mostMessagesRound, roundChangeMessages[0].View.Round)
 }
 
+// TestMessages_SubscribeVotingPower makes sure subscriptions
+// can wait on the accumulated voting power of the senders
+func TestMessages_SubscribeVotingPower(t *testing.T) {
+	t.Parallel()
+
+	var (
+		messageType = proto.MessageType_COMMIT
+		view        = &proto.View{Height: 1, Round: 0}
+
+		heavy = &proto.Message{View: view, From: []byte("heavy"), Type: messageType}
+		light = &proto.Message{View: view, From: []byte("light"), Type: messageType}
+	)
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	subscription := messages.Subscribe(SubscriptionDetails{
+		MessageType:    messageType,
+		View:           view,
+		MinNumMessages: 1,
+		MinVotingPower: 10,
+		VotingPower: func(from []byte) uint64 {
+			if string(from) == "heavy" {
+				return 9
+			}
+
+			return 1
+		},
+	})
+
+	defer messages.Unsubscribe(subscription.ID)
+
+	// The heavy sender alone doesn't reach the threshold
+	messages.AddMessage(heavy)
+
+	select {
+	case <-subscription.SubCh:
+		t.Fatal("subscription triggered below the voting power threshold")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Together with the light sender, the threshold is reached
+	messages.AddMessage(light)
+
+	select {
+	case round := <-subscription.SubCh:
+		assert.Equal(t, view.Round, round)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not triggered")
+	}
+
+	// Make sure the threshold is checked on subscription as well
+	existing := messages.Subscribe(SubscriptionDetails{
+		MessageType:    messageType,
+		View:           view,
+		MinVotingPower: 2,
+	})
+
+	defer messages.Unsubscribe(existing.ID)
+
+	select {
+	case <-existing.SubCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription not triggered")
+	}
+}
+
+// TestMessages_GetRoundChangeMessages makes sure
+// all round change messages from the minimum round are fetched
+func TestMessages_GetRoundChangeMessages(t *testing.T) {
+	t.Parallel()
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	for round := uint64(0); round < 4; round++ {
+		randomMessages := generateRandomMessages(
+			2,
+			&proto.View{Height: 1, Round: round},
+			proto.MessageType_ROUND_CHANGE,
+		)
+
+		for _, message := range randomMessages {
+			messages.AddMessage(message)
+		}
+	}
+
+	// Messages for a different height
+	messages.AddMessage(generateRandomMessages(
+		1,
+		&proto.View{Height: 2, Round: 3},
+		proto.MessageType_ROUND_CHANGE,
+	)[0])
+
+	roundChangeMessages := messages.GetRoundChangeMessages(2, 1)
+
+	assert.Len(t, roundChangeMessages, 4)
+
+	for _, message := range roundChangeMessages {
+		assert.Equal(t, uint64(1), message.View.Height)
+		assert.GreaterOrEqual(t, message.View.Round, uint64(2))
+	}
+
+	assert.Empty(t, messages.GetRoundChangeMessages(0, 5))
+}
+
 // TestMessages_EventManager checks that the event manager
 // behaves correctly when new messages appear
 func TestMessages_EventManager(t *testing.T) {
@@ -340,5 +496,170 @@ func TestMessages_EventManager(t *testing.T) {
 	}
 
 	// Make sure the number of messages is actually accurate
-	assert.Equal(t, numMessages, messages.numMessages(baseView, messageType))
+	assert.Equal(t, numMessages, messages.NumMessages(baseView, messageType))
+}
+
+// evictionMetrics is the message metrics sink
+// that records evictions and the store size
+type evictionMetrics struct {
+	NoopMetrics
+
+	evicted map[proto.MessageType]int
+	size    int
+}
+
+func (m *evictionMetrics) IncEvicted(messageType proto.MessageType) {
+	m.evicted[messageType]++
+}
+
+func (m *evictionMetrics) SetSize(size int) {
+	m.size = size
+}
+
+// TestMessages_Size makes sure the accumulated size of the
+// stored messages is kept up to date as messages come and go
+func TestMessages_Size(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{Height: 1, Round: 0}
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	randomMessages := generateRandomMessages(3, view, proto.MessageType_PREPARE)
+	for _, message := range randomMessages {
+		messages.AddMessage(message)
+	}
+
+	messageSize := protobuf.Size(randomMessages[0])
+	assert.Equal(t, 3*messageSize, messages.Size())
+
+	// Make sure replaced messages are not counted twice
+	messages.AddMessage(randomMessages[0])
+	assert.Equal(t, 3*messageSize, messages.Size())
+
+	// Make sure pruned invalid messages are released
+	messages.GetValidMessages(view, proto.MessageType_PREPARE, func(message *proto.Message) bool {
+		return string(message.From) != "0"
+	})
+	assert.Equal(t, 2*messageSize, messages.Size())
+
+	// Make sure pruned heights are released
+	messages.PruneByHeight(view.Height + 1)
+	assert.Equal(t, 0, messages.Size())
+}
+
+// TestMessages_Evict makes sure the views farthest ahead are
+// evicted first, once the size limit is exceeded
+func TestMessages_Evict(t *testing.T) {
+	t.Parallel()
+
+	buildMessage := func(height, round uint64, messageType proto.MessageType) *proto.Message {
+		return generateRandomMessages(1, &proto.View{Height: height, Round: round}, messageType)[0]
+	}
+
+	// Non-zero views, so all messages have the same encoded size
+	messageSize := protobuf.Size(buildMessage(1, 1, proto.MessageType_PREPARE))
+
+	t.Run("farthest views are evicted first", func(t *testing.T) {
+		t.Parallel()
+
+		metrics := &evictionMetrics{evicted: make(map[proto.MessageType]int)}
+
+		messages := NewMessages(WithMaxSize(3*messageSize), WithMetrics(metrics))
+		defer messages.Close()
+
+		messages.PruneByHeight(1)
+
+		messages.AddMessage(buildMessage(1, 1, proto.MessageType_PREPARE))
+		messages.AddMessage(buildMessage(2, 2, proto.MessageType_PREPARE))
+		messages.AddMessage(buildMessage(2, 1, proto.MessageType_PREPARE))
+
+		assert.Equal(t, 3*messageSize, messages.Size())
+		assert.Empty(t, metrics.evicted)
+
+		// The highest height is evicted first,
+		// even if it was just added
+		messages.AddMessage(buildMessage(3, 1, proto.MessageType_PREPARE))
+
+		assert.Equal(t, 0, messages.NumMessages(&proto.View{Height: 3, Round: 1}, proto.MessageType_PREPARE))
+
+		// The highest round of the highest height is evicted next,
+		// along with the messages of other types for the view
+		messages.AddMessage(buildMessage(2, 2, proto.MessageType_COMMIT))
+		messages.AddMessage(buildMessage(1, 2, proto.MessageType_PREPARE))
+
+		assert.Equal(t, 0, messages.NumMessages(&proto.View{Height: 2, Round: 2}, proto.MessageType_PREPARE))
+		assert.Equal(t, 0, messages.NumMessages(&proto.View{Height: 2, Round: 2}, proto.MessageType_COMMIT))
+		assert.Equal(t, 1, messages.NumMessages(&proto.View{Height: 2, Round: 1}, proto.MessageType_PREPARE))
+		assert.Equal(t, 1, messages.NumMessages(&proto.View{Height: 1, Round: 2}, proto.MessageType_PREPARE))
+
+		assert.Equal(
+			t,
+			map[proto.MessageType]int{
+				proto.MessageType_PREPARE: 2,
+				proto.MessageType_COMMIT:  1,
+			},
+			metrics.evicted,
+		)
+		assert.Equal(t, 3*messageSize, messages.Size())
+		assert.Equal(t, messages.Size(), metrics.size)
+	})
+
+	t.Run("views at the lowest height are never evicted up to the current round", func(t *testing.T) {
+		t.Parallel()
+
+		messages := NewMessages(WithMaxSize(messageSize))
+		defer messages.Close()
+
+		messages.PruneByHeight(1)
+		messages.SetRound(3)
+
+		for round := uint64(1); round <= 3; round++ {
+			messages.AddMessage(buildMessage(1, round, proto.MessageType_PREPARE))
+		}
+
+		assert.Equal(t, 3*messageSize, messages.Size())
+	})
+
+	t.Run("views at the lowest height are evicted above the current round", func(t *testing.T) {
+		t.Parallel()
+
+		messages := NewMessages(WithMaxSize(2 * messageSize))
+		defer messages.Close()
+
+		messages.PruneByHeight(1)
+		messages.SetRound(1)
+
+		messages.AddMessage(buildMessage(1, 1, proto.MessageType_PREPARE))
+		messages.AddMessage(buildMessage(1, 1000, proto.MessageType_ROUND_CHANGE))
+		messages.AddMessage(buildMessage(1, 2, proto.MessageType_ROUND_CHANGE))
+
+		// The round farthest above the current one is evicted first
+		assert.Equal(t, 0, messages.NumMessages(&proto.View{Height: 1, Round: 1000}, proto.MessageType_ROUND_CHANGE))
+		assert.Equal(t, 1, messages.NumMessages(&proto.View{Height: 1, Round: 2}, proto.MessageType_ROUND_CHANGE))
+		assert.Equal(t, 1, messages.NumMessages(&proto.View{Height: 1, Round: 1}, proto.MessageType_PREPARE))
+
+		// The current round is reset once the next height is pruned by
+		messages.PruneByHeight(2)
+		messages.AddMessage(buildMessage(2, 1, proto.MessageType_PREPARE))
+		messages.AddMessage(buildMessage(2, 2, proto.MessageType_PREPARE))
+		messages.AddMessage(buildMessage(2, 3, proto.MessageType_PREPARE))
+
+		assert.Equal(t, 0, messages.NumMessages(&proto.View{Height: 2, Round: 3}, proto.MessageType_PREPARE))
+		assert.Equal(t, 2*messageSize, messages.Size())
+	})
+
+	t.Run("zero disables the limit", func(t *testing.T) {
+		t.Parallel()
+
+		messages := NewMessages(WithMaxSize(0))
+		defer messages.Close()
+
+		for height := uint64(1); height < 4; height++ {
+			messages.AddMessage(buildMessage(height, 1, proto.MessageType_PREPARE))
+		}
+
+		assert.Equal(t, 3*messageSize, messages.Size())
+	})
 }
@@ -0,0 +1,74 @@
+package messages
+
+import "github.com/madz-lab/go-ibft/messages/proto"
+
+// Metrics defines the sink for message metrics
+type Metrics interface {
+	// IncMessage notes an added message of the specified type
+	IncMessage(messageType proto.MessageType)
+
+	// IncEvicted notes a message of the specified type
+	// evicted from the store to fit the size limit
+	IncEvicted(messageType proto.MessageType)
+
+	// IncRejected notes a message of the specified
+	// type rejected for being over the sender quota
+	IncRejected(messageType proto.MessageType)
+
+	// SetSize notes the accumulated encoded size of the stored messages, in bytes
+	SetSize(size int)
+}
+
+// NoopMetrics is the Metrics implementation that discards all metrics
+type NoopMetrics struct{}
+
+func (NoopMetrics) IncMessage(proto.MessageType)  {}
+func (NoopMetrics) IncEvicted(proto.MessageType)  {}
+func (NoopMetrics) IncRejected(proto.MessageType) {}
+func (NoopMetrics) SetSize(int)                   {}
+
+// Option is a functional option that modifies the message store
+type Option func(*Messages)
+
+// WithMetrics sets the sink for message metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(ms *Messages) {
+		ms.metrics = metrics
+	}
+}
+
+// WithMaxSize sets the limit of the accumulated encoded size of the
+// stored messages, in bytes. Once it's exceeded, the views farthest
+// ahead are evicted first. Zero disables the limit
+func WithMaxSize(size int) Option {
+	return func(ms *Messages) {
+		ms.maxSize = int64(size)
+	}
+}
+
+// WithLimiter adds a policy that decides whether messages are within the
+// quota of their sender. It can be used multiple times to add multiple
+// policies, and a message is only added if all of them allow it
+func WithLimiter(limiter Limiter) Option {
+	return func(ms *Messages) {
+		ms.limiters = append(ms.limiters, limiter)
+	}
+}
+
+// WithQuotaExceededHandler sets the handler that is notified of messages over
+// the quota of their sender, so the sender can be penalized. The handler is
+// invoked synchronously from AddMessage, so it should return quickly
+func WithQuotaExceededHandler(handler func(message *proto.Message, err error)) Option {
+	return func(ms *Messages) {
+		ms.quotaExceededHandler = handler
+	}
+}
+
+// WithEquivocationHandler sets the handler that is notified of new evidence
+// of conflicting messages from the same sender. The handler is invoked
+// synchronously from AddMessage, so it should return quickly
+func WithEquivocationHandler(handler func(evidence *EquivocationEvidence)) Option {
+	return func(ms *Messages) {
+		ms.equivocationHandler = handler
+	}
+}
@@ -0,0 +1,134 @@
+package messages
+
+import (
+	"sync"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// typeMessages holds the messages of a single type, sharded by view.
+// The index lock only protects the view index, while each view has its own lock.
+// View locks are only acquired while holding the index lock (at least for reading),
+// so holding the index lock for writing grants exclusive access to all views
+type typeMessages struct {
+	// mux protects the view index
+	mux sync.RWMutex
+
+	// heights is the view index
+	heights heightMessageMap
+}
+
+// newTypeMessages creates a new empty message type store
+func newTypeMessages() *typeMessages {
+	return &typeMessages{
+		heights: make(heightMessageMap),
+	}
+}
+
+// getView fetches the messages for the view, if present.
+// The index lock needs to be held
+func (tm *typeMessages) getView(view *proto.View) *viewMessages {
+	return tm.heights[view.Height][view.Round]
+}
+
+// acquireView fetches the messages for the view, and initializes them if not found.
+// The index lock is held for reading on return, and needs to be released by the caller
+func (tm *typeMessages) acquireView(view *proto.View) *viewMessages {
+	for {
+		tm.mux.RLock()
+
+		if messages := tm.getView(view); messages != nil {
+			return messages
+		}
+
+		tm.mux.RUnlock()
+
+		// The view could be pruned before the read lock is reacquired,
+		// in which case it's initialized again
+		tm.mux.Lock()
+		tm.heights.getViewMessages(view)
+		tm.mux.Unlock()
+	}
+}
+
+// viewMessages holds the messages of a single type for a view
+type viewMessages struct {
+	// mux protects the messages
+	mux sync.RWMutex
+
+	// messages are the messages of the view, by sender
+	messages protoMessages
+}
+
+// snapshot returns a copy of the messages
+func (vm *viewMessages) snapshot() protoMessages {
+	vm.mux.RLock()
+	defer vm.mux.RUnlock()
+
+	messages := make(protoMessages, len(vm.messages))
+	for key, message := range vm.messages {
+		messages[key] = message
+	}
+
+	return messages
+}
+
+// list returns the messages, in no particular order
+func (vm *viewMessages) list() []*proto.Message {
+	vm.mux.RLock()
+	defer vm.mux.RUnlock()
+
+	messages := make([]*proto.Message, 0, len(vm.messages))
+	for _, message := range vm.messages {
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+// len returns the number of messages
+func (vm *viewMessages) len() int {
+	vm.mux.RLock()
+	defer vm.mux.RUnlock()
+
+	return len(vm.messages)
+}
+
+// heightMessageMap maps the height number -> round message map
+type heightMessageMap map[uint64]roundMessageMap
+
+// roundMessageMap maps the round number -> messages
+type roundMessageMap map[uint64]*viewMessages
+
+// protoMessages is the set of messages that circulate.
+// It contains a mapping between the sender and their messages to avoid duplicates
+type protoMessages map[string]*proto.Message
+
+// getViewMessages fetches the message queue for the specified view (height + round).
+// It will initialize a new message array if it's not found
+func (m heightMessageMap) getViewMessages(view *proto.View) *viewMessages {
+	var (
+		height = view.Height
+		round  = view.Round
+	)
+
+	// Check if the height is present
+	roundMessages, exists := m[height]
+	if !exists {
+		roundMessages = roundMessageMap{}
+
+		m[height] = roundMessages
+	}
+
+	// Check if the round is present
+	messages, exists := roundMessages[round]
+	if !exists {
+		messages = &viewMessages{
+			messages: protoMessages{},
+		}
+
+		roundMessages[round] = messages
+	}
+
+	return messages
+}
@@ -0,0 +1,292 @@
+package messages
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// buildStoreMessage builds a PREPARE message from the sender for the view
+func buildStoreMessage(from string, view *proto.View) *proto.Message {
+	return &proto.Message{
+		From: []byte(from),
+		View: view,
+		Type: proto.MessageType_PREPARE,
+		Payload: &proto.Message_PrepareData{
+			PrepareData: &proto.PrepareMessage{
+				ProposalHash: []byte("hash"),
+			},
+		},
+	}
+}
+
+// TestMessages_ValidationOutsideLocks makes sure messages are added
+// while the messages of the same type are being validated
+func TestMessages_ValidationOutsideLocks(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view       = &proto.View{Height: 1, Round: 1}
+		futureView = &proto.View{Height: 2, Round: 1}
+	)
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	messages.AddMessage(buildStoreMessage("node 0", view))
+
+	added := make(chan struct{})
+
+	go func() {
+		defer close(added)
+
+		messages.AddMessage(buildStoreMessage("node 1", view))
+		messages.AddMessage(buildStoreMessage("node 0", futureView))
+	}()
+
+	valid := messages.GetValidMessages(view, proto.MessageType_PREPARE, func(*proto.Message) bool {
+		select {
+		case <-added:
+			return true
+		case <-time.After(5 * time.Second):
+			t.Error("messages not added during validation")
+
+			return false
+		}
+	})
+
+	// The message added during validation is not part of the result
+	assert.Len(t, valid, 1)
+	assert.Equal(t, 2, messages.NumMessages(view, proto.MessageType_PREPARE))
+	assert.Equal(t, 1, messages.NumMessages(futureView, proto.MessageType_PREPARE))
+}
+
+// TestMessages_ReplacedDuringValidation makes sure the verdicts are not
+// applied to messages replaced while they were being validated
+func TestMessages_ReplacedDuringValidation(t *testing.T) {
+	t.Parallel()
+
+	view := &proto.View{Height: 1, Round: 1}
+
+	t.Run("replaced messages are not pruned", func(t *testing.T) {
+		t.Parallel()
+
+		messages := NewMessages()
+		defer messages.Close()
+
+		original := buildStoreMessage("node 0", view)
+		replacement := protobuf.Clone(original).(*proto.Message)
+		replacement.Signature = []byte("signature")
+
+		messages.AddMessage(original)
+
+		valid := messages.GetValidMessages(view, proto.MessageType_PREPARE, func(*proto.Message) bool {
+			messages.AddMessage(replacement)
+
+			return false
+		})
+
+		assert.Empty(t, valid)
+		assert.Equal(t, 1, messages.NumMessages(view, proto.MessageType_PREPARE))
+		assert.Equal(t, protobuf.Size(replacement), messages.Size())
+	})
+
+	t.Run("verdicts of replaced messages are not memoized", func(t *testing.T) {
+		t.Parallel()
+
+		messages := NewMessages()
+		defer messages.Close()
+
+		original := buildStoreMessage("node 0", view)
+		replacement := protobuf.Clone(original).(*proto.Message)
+		replacement.Signature = []byte("signature")
+
+		messages.AddMessage(original)
+
+		valid := messages.GetCachedValidMessages(
+			view,
+			proto.MessageType_PREPARE,
+			"",
+			func(batch []*proto.Message) []bool {
+				messages.AddMessage(replacement)
+
+				return []bool{true}
+			},
+		)
+
+		assert.Equal(t, []*proto.Message{original}, valid)
+		assert.Equal(t, 0, messages.verdicts.len())
+	})
+}
+
+// TestMessages_ConcurrentAccess makes sure the store
+// is consistent under concurrent access
+func TestMessages_ConcurrentAccess(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numSenders = 10
+		numHeights = 5
+	)
+
+	messages := NewMessages()
+	defer messages.Close()
+
+	var wg sync.WaitGroup
+
+	for sender := 0; sender < numSenders; sender++ {
+		wg.Add(1)
+
+		go func(sender int) {
+			defer wg.Done()
+
+			for height := uint64(1); height <= numHeights; height++ {
+				messages.AddMessage(
+					buildStoreMessage(strconv.Itoa(sender), &proto.View{Height: height, Round: 1}),
+				)
+			}
+		}(sender)
+	}
+
+	for height := uint64(1); height <= numHeights; height++ {
+		wg.Add(1)
+
+		go func(height uint64) {
+			defer wg.Done()
+
+			view := &proto.View{Height: height, Round: 1}
+
+			messages.GetValidMessages(view, proto.MessageType_PREPARE, func(*proto.Message) bool {
+				return true
+			})
+			messages.GetCachedValidMessages(view, proto.MessageType_PREPARE, "", func(batch []*proto.Message) []bool {
+				return make([]bool, len(batch))
+			})
+		}(height)
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		messages.PruneByHeight(numHeights)
+	}()
+
+	wg.Wait()
+
+	// Only the messages at the last height are left, and
+	// the size accounts for all of them
+	messages.PruneByHeight(numHeights)
+
+	size := 0
+
+	for _, message := range messages.snapshotMessages(
+		&proto.View{Height: numHeights, Round: 1},
+		proto.MessageType_PREPARE,
+	) {
+		size += protobuf.Size(message)
+	}
+
+	require.Equal(t, size, messages.Size())
+	assert.Equal(t, 0, messages.verdicts.len())
+}
+
+// BenchmarkMessages_AddMessage_ValidationLoad measures the throughput of
+// adding messages, while the messages of the same type are being validated
+func BenchmarkMessages_AddMessage_ValidationLoad(b *testing.B) {
+	var (
+		view       = &proto.View{Height: 1, Round: 1}
+		futureView = func(n uint64) *proto.View {
+			return &proto.View{Height: 2 + n/100, Round: 1}
+		}
+	)
+
+	benchmarks := []struct {
+		name string
+		view func(n uint64) *proto.View
+	}{
+		{
+			"future views",
+			futureView,
+		},
+		{
+			"validated view",
+			func(uint64) *proto.View {
+				return view
+			},
+		},
+	}
+
+	for _, benchmark := range benchmarks {
+		benchmark := benchmark
+
+		b.Run(benchmark.name, func(b *testing.B) {
+			messages := NewMessages()
+			defer messages.Close()
+
+			for sender := 0; sender < 100; sender++ {
+				messages.AddMessage(buildStoreMessage(strconv.Itoa(sender), view))
+			}
+
+			var (
+				done      = make(chan struct{})
+				validated = make(chan struct{})
+				once      sync.Once
+				wg        sync.WaitGroup
+				counter   atomic.Uint64
+			)
+
+			isValid := func(message *proto.Message) bool {
+				once.Do(func() {
+					close(validated)
+				})
+
+				return expensiveValidation(message)
+			}
+
+			// Keep validating the messages of the view in the background
+			for validator := 0; validator < 2; validator++ {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					for {
+						select {
+						case <-done:
+							return
+						default:
+							messages.GetValidMessages(view, proto.MessageType_PREPARE, isValid)
+						}
+					}
+				}()
+			}
+
+			// Make sure the validation is under way
+			<-validated
+
+			b.ResetTimer()
+
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					n := counter.Add(1)
+
+					messages.AddMessage(buildStoreMessage(strconv.FormatUint(n, 10), benchmark.view(n)))
+				}
+			})
+
+			b.StopTimer()
+
+			close(done)
+			wg.Wait()
+		})
+	}
+}
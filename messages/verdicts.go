@@ -0,0 +1,64 @@
+package messages
+
+import (
+	"sync"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// verdictCache memoizes the validation verdicts of the stored messages.
+// Only the positive verdicts are kept, since invalid messages are pruned
+// out of the store right away
+type verdictCache struct {
+	// verdicts maps the message -> validation contexts it is valid in
+	verdicts map[*proto.Message]map[string]struct{}
+
+	mux sync.Mutex
+}
+
+// newVerdictCache creates a new empty verdict cache
+func newVerdictCache() *verdictCache {
+	return &verdictCache{
+		verdicts: make(map[*proto.Message]map[string]struct{}),
+	}
+}
+
+// isValid checks if the message was found valid in the validation context
+func (c *verdictCache) isValid(message *proto.Message, context string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	_, ok := c.verdicts[message][context]
+
+	return ok
+}
+
+// setValid notes the message is valid in the validation context
+func (c *verdictCache) setValid(message *proto.Message, context string) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	contexts, ok := c.verdicts[message]
+	if !ok {
+		contexts = make(map[string]struct{})
+		c.verdicts[message] = contexts
+	}
+
+	contexts[context] = struct{}{}
+}
+
+// drop removes the verdicts of the message
+func (c *verdictCache) drop(message *proto.Message) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	delete(c.verdicts, message)
+}
+
+// len returns the number of messages with cached verdicts
+func (c *verdictCache) len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return len(c.verdicts)
+}
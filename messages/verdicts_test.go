@@ -0,0 +1,244 @@
+package messages
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+// validationRecorder is a batch validation function
+// that records the batches it is called with
+type validationRecorder struct {
+	batches [][]*proto.Message
+	isValid func(message *proto.Message) bool
+}
+
+func (r *validationRecorder) validate(messages []*proto.Message) []bool {
+	r.batches = append(r.batches, messages)
+
+	verdicts := make([]bool, len(messages))
+	for index, message := range messages {
+		verdicts[index] = r.isValid == nil || r.isValid(message)
+	}
+
+	return verdicts
+}
+
+// validated returns the number of messages validated so far
+func (r *validationRecorder) validated() int {
+	count := 0
+	for _, batch := range r.batches {
+		count += len(batch)
+	}
+
+	return count
+}
+
+// TestMessages_GetCachedValidMessages makes sure the validation
+// verdicts are memoized per message and validation context
+func TestMessages_GetCachedValidMessages(t *testing.T) {
+	t.Parallel()
+
+	var (
+		view        = &proto.View{Height: 1, Round: 1}
+		messageType = proto.MessageType_PREPARE
+		numMessages = 5
+	)
+
+	newStore := func(t *testing.T) (*Messages, []*proto.Message) {
+		t.Helper()
+
+		messages := NewMessages()
+		t.Cleanup(messages.Close)
+
+		generated := generateRandomMessages(numMessages, view, messageType)
+		for _, message := range generated {
+			messages.AddMessage(message)
+		}
+
+		return messages, generated
+	}
+
+	t.Run("verdicts are memoized", func(t *testing.T) {
+		t.Parallel()
+
+		messages, generated := newStore(t)
+		recorder := &validationRecorder{}
+
+		valid := messages.GetCachedValidMessages(view, messageType, "context", recorder.validate)
+		assert.ElementsMatch(t, generated, valid)
+
+		// All messages are validated in a single batch
+		require.Len(t, recorder.batches, 1)
+		assert.ElementsMatch(t, generated, recorder.batches[0])
+
+		valid = messages.GetCachedValidMessages(view, messageType, "context", recorder.validate)
+		assert.ElementsMatch(t, generated, valid)
+
+		// No message is validated again
+		assert.Len(t, recorder.batches, 1)
+
+		// Only the new message is validated
+		added := generateRandomMessages(numMessages+1, view, messageType)[numMessages]
+		messages.AddMessage(added)
+
+		valid = messages.GetCachedValidMessages(view, messageType, "context", recorder.validate)
+		assert.Len(t, valid, numMessages+1)
+
+		require.Len(t, recorder.batches, 2)
+		assert.Equal(t, []*proto.Message{added}, recorder.batches[1])
+	})
+
+	t.Run("verdicts are separate for each context", func(t *testing.T) {
+		t.Parallel()
+
+		messages, generated := newStore(t)
+		recorder := &validationRecorder{}
+
+		messages.GetCachedValidMessages(view, messageType, "first", recorder.validate)
+		messages.GetCachedValidMessages(view, messageType, "second", recorder.validate)
+		messages.GetCachedValidMessages(view, messageType, "first", recorder.validate)
+
+		require.Len(t, recorder.batches, 2)
+		assert.ElementsMatch(t, generated, recorder.batches[1])
+	})
+
+	t.Run("invalid messages are pruned out", func(t *testing.T) {
+		t.Parallel()
+
+		messages, generated := newStore(t)
+		recorder := &validationRecorder{
+			isValid: func(message *proto.Message) bool {
+				return message != generated[0]
+			},
+		}
+
+		valid := messages.GetCachedValidMessages(view, messageType, "context", recorder.validate)
+		assert.ElementsMatch(t, generated[1:], valid)
+
+		assert.Equal(t, numMessages-1, messages.NumMessages(view, messageType))
+		assert.Equal(t, numMessages-1, messages.verdicts.len())
+	})
+
+	t.Run("messages without a verdict are invalid", func(t *testing.T) {
+		t.Parallel()
+
+		messages, _ := newStore(t)
+
+		valid := messages.GetCachedValidMessages(
+			view,
+			messageType,
+			"context",
+			func(messages []*proto.Message) []bool {
+				return []bool{true}
+			},
+		)
+
+		assert.Len(t, valid, 1)
+		assert.Equal(t, 1, messages.NumMessages(view, messageType))
+	})
+
+	t.Run("verdicts are dropped with the messages", func(t *testing.T) {
+		t.Parallel()
+
+		messages, generated := newStore(t)
+		recorder := &validationRecorder{}
+
+		messages.GetCachedValidMessages(view, messageType, "context", recorder.validate)
+		assert.Equal(t, numMessages, messages.verdicts.len())
+
+		// A replaced message is validated again
+		replacement := protobuf.Clone(generated[0]).(*proto.Message)
+		replacement.Signature = []byte("signature")
+		messages.AddMessage(replacement)
+
+		messages.GetCachedValidMessages(view, messageType, "context", recorder.validate)
+
+		require.Len(t, recorder.batches, 2)
+		assert.Equal(t, []*proto.Message{replacement}, recorder.batches[1])
+		assert.Equal(t, numMessages, messages.verdicts.len())
+
+		// Messages of the next round are validated on their own
+		nextView := &proto.View{Height: view.Height, Round: view.Round + 1}
+		for _, message := range generateRandomMessages(numMessages, nextView, messageType) {
+			messages.AddMessage(message)
+		}
+
+		messages.GetCachedValidMessages(nextView, messageType, "context", recorder.validate)
+
+		require.Len(t, recorder.batches, 3)
+		assert.Len(t, recorder.batches[2], numMessages)
+		assert.Equal(t, 2*numMessages, messages.verdicts.len())
+
+		// The verdicts are dropped once the height is pruned
+		messages.PruneByHeight(view.Height + 1)
+
+		assert.Equal(t, 0, messages.verdicts.len())
+	})
+}
+
+// expensiveValidation simulates the cost of message validation,
+// like signature verification or proposal execution
+func expensiveValidation(message *proto.Message) bool {
+	digest := sha256.Sum256(message.From)
+	for round := 0; round < 1000; round++ {
+		digest = sha256.Sum256(digest[:])
+	}
+
+	return digest != [sha256.Size]byte{}
+}
+
+// newBenchmarkStore creates a store with the messages for a single view,
+// which is queried repeatedly, like on each subscription event
+func newBenchmarkStore(b *testing.B, view *proto.View) *Messages {
+	b.Helper()
+
+	messages := NewMessages()
+	b.Cleanup(messages.Close)
+
+	for _, message := range generateRandomMessages(100, view, proto.MessageType_ROUND_CHANGE) {
+		messages.AddMessage(message)
+	}
+
+	return messages
+}
+
+func BenchmarkMessages_GetValidMessages(b *testing.B) {
+	var (
+		view       = &proto.View{Height: 1, Round: 1}
+		messages   = newBenchmarkStore(b, view)
+		validCount = 0
+	)
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		messages.GetValidMessages(view, proto.MessageType_ROUND_CHANGE, func(message *proto.Message) bool {
+			validCount++
+
+			return expensiveValidation(message)
+		})
+	}
+
+	b.ReportMetric(float64(validCount)/float64(b.N), "validations/op")
+}
+
+func BenchmarkMessages_GetCachedValidMessages(b *testing.B) {
+	var (
+		view     = &proto.View{Height: 1, Round: 1}
+		messages = newBenchmarkStore(b, view)
+		recorder = &validationRecorder{isValid: expensiveValidation}
+	)
+
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		messages.GetCachedValidMessages(view, proto.MessageType_ROUND_CHANGE, "", recorder.validate)
+	}
+
+	b.ReportMetric(float64(recorder.validated())/float64(b.N), "validations/op")
+}
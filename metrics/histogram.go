@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the default histogram bucket upper bounds, in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogramValue is the value of a single histogram series
+type histogramValue struct {
+	labelValues []string
+
+	// counts are the non-cumulative observation counts per bucket,
+	// with the last count being the +Inf bucket
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Histogram is a metric that samples observations into buckets
+type Histogram struct {
+	family
+
+	// buckets are the sorted bucket upper bounds, without +Inf
+	buckets []float64
+	series  map[string]*histogramValue
+
+	mux sync.Mutex
+}
+
+// NewHistogram creates and registers a new histogram with the specified
+// bucket upper bounds. If no buckets are set, DefaultBuckets are used.
+// It panics if the name is invalid, or already registered
+func (r *Registry) NewHistogram(
+	name,
+	help string,
+	buckets []float64,
+	labelNames ...string,
+) *Histogram {
+	for _, labelName := range labelNames {
+		if labelName == "le" {
+			panic(fmt.Sprintf("metrics: histogram %q can't use the le label", name))
+		}
+	}
+
+	histogram := &Histogram{
+		family:  newFamily(name, help, "histogram", labelNames),
+		buckets: normalizeBuckets(buckets),
+		series:  make(map[string]*histogramValue),
+	}
+
+	r.register(name, histogram)
+
+	return histogram
+}
+
+// normalizeBuckets sorts the bucket upper bounds,
+// and removes duplicates and the +Inf bound
+func normalizeBuckets(buckets []float64) []float64 {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	normalized := make([]float64, 0, len(sorted))
+
+	for _, bucket := range sorted {
+		if math.IsInf(bucket, 1) || math.IsNaN(bucket) {
+			continue
+		}
+
+		if len(normalized) > 0 && normalized[len(normalized)-1] == bucket {
+			continue
+		}
+
+		normalized = append(normalized, bucket)
+	}
+
+	return normalized
+}
+
+// Observe adds a single observation for the label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	key := h.key(labelValues)
+
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogramValue{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)+1),
+		}
+
+		h.series[key] = series
+	}
+
+	// Find the first bucket the value fits in,
+	// falling back to the +Inf bucket
+	index := sort.SearchFloat64s(h.buckets, value)
+
+	series.counts[index]++
+	series.sum += value
+	series.count++
+}
+
+// ObserveDuration adds a single duration observation,
+// in seconds, for the label values
+func (h *Histogram) ObserveDuration(duration time.Duration, labelValues ...string) {
+	h.Observe(duration.Seconds(), labelValues...)
+}
+
+// Count returns the number of observations for the label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	series, ok := h.series[h.key(labelValues)]
+	if !ok {
+		return 0
+	}
+
+	return series.count
+}
+
+// Sum returns the sum of observations for the label values
+func (h *Histogram) Sum(labelValues ...string) float64 {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	series, ok := h.series[h.key(labelValues)]
+	if !ok {
+		return 0
+	}
+
+	return series.sum
+}
+
+// write renders the metric in the text exposition format
+func (h *Histogram) write(buf *bytes.Buffer) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	h.writeHeader(buf)
+
+	for _, key := range sortedKeys(h.series) {
+		var (
+			series     = h.series[key]
+			cumulative = uint64(0)
+		)
+
+		for index, bucket := range h.buckets {
+			cumulative += series.counts[index]
+
+			h.writeSample(
+				buf,
+				"_bucket",
+				series.labelValues,
+				[]string{"le", formatFloat(bucket)},
+				float64(cumulative),
+			)
+		}
+
+		h.writeSample(
+			buf,
+			"_bucket",
+			series.labelValues,
+			[]string{"le", "+Inf"},
+			float64(series.count),
+		)
+		h.writeSample(buf, "_sum", series.labelValues, nil, series.sum)
+		h.writeSample(buf, "_count", series.labelValues, nil, float64(series.count))
+	}
+}
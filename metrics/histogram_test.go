@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHistogram_Observe makes sure observations
+// are bucketed and rendered correctly
+func TestHistogram_Observe(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+
+	histogram := registry.NewHistogram(
+		"latency_seconds",
+		"Request latency",
+		[]float64{1, 0.5, 1, math.Inf(1)},
+		"path",
+	)
+
+	histogram.Observe(0.5, "/")
+	histogram.Observe(0.75, "/")
+	histogram.ObserveDuration(3*time.Second, "/")
+
+	assert.Equal(t, uint64(3), histogram.Count("/"))
+	assert.Equal(t, 4.25, histogram.Sum("/"))
+	assert.Equal(t, uint64(0), histogram.Count("/other"))
+	assert.Equal(t, 0.0, histogram.Sum("/other"))
+
+	assert.Equal(
+		t,
+		`# HELP latency_seconds Request latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{path="/",le="0.5"} 1
+latency_seconds_bucket{path="/",le="1"} 2
+latency_seconds_bucket{path="/",le="+Inf"} 3
+latency_seconds_sum{path="/"} 4.25
+latency_seconds_count{path="/"} 3
+`,
+		render(t, registry),
+	)
+}
+
+// TestHistogram_DefaultBuckets makes sure the default
+// buckets are used when none are specified
+func TestHistogram_DefaultBuckets(t *testing.T) {
+	t.Parallel()
+
+	histogram := NewRegistry().NewHistogram("name", "", nil)
+
+	assert.Equal(t, DefaultBuckets, histogram.buckets)
+}
+
+// TestHistogram_ReservedLabel makes sure the
+// bucket label can't be used as a metric label
+func TestHistogram_ReservedLabel(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() {
+		NewRegistry().NewHistogram("name", "", nil, "le")
+	})
+}
@@ -0,0 +1,178 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/madz-lab/go-ibft/core"
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+)
+
+// namespace is the prefix of all IBFT metric names
+const namespace = "ibft_"
+
+var (
+	// sequenceBuckets are the bucket upper bounds
+	// for sequence and round durations, in seconds
+	sequenceBuckets = []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 40, 80, 160, 320}
+
+	// roundChangeBuckets are the bucket upper bounds
+	// for the number of round changes per height
+	roundChangeBuckets = []float64{0, 1, 2, 3, 5, 10, 20}
+)
+
+var (
+	_ core.Metrics     = (*ConsensusMetrics)(nil)
+	_ messages.Metrics = (*MessageMetrics)(nil)
+)
+
+// ConsensusMetrics is the core.Metrics implementation backed by a Registry
+type ConsensusMetrics struct {
+	height                *Gauge
+	round                 *Gauge
+	roundDuration         *Histogram
+	roundChanges          *Counter
+	stateDuration         *Histogram
+	sequenceDuration      *Histogram
+	roundChangesPerHeight *Histogram
+	finalizedRound        *Histogram
+	droppedMessages       *Counter
+}
+
+// NewConsensusMetrics registers the consensus metrics with the registry
+func NewConsensusMetrics(registry *Registry) *ConsensusMetrics {
+	return &ConsensusMetrics{
+		height: registry.NewGauge(
+			namespace+"height",
+			"Height of the current sequence",
+		),
+		round: registry.NewGauge(
+			namespace+"round",
+			"Round of the current sequence",
+		),
+		roundDuration: registry.NewHistogram(
+			namespace+"round_duration_seconds",
+			"Duration of a single round",
+			sequenceBuckets,
+		),
+		roundChanges: registry.NewCounter(
+			namespace+"round_changes_total",
+			"Number of round changes, by reason",
+			"reason",
+		),
+		stateDuration: registry.NewHistogram(
+			namespace+"state_duration_seconds",
+			"Time spent in each state of the state machine",
+			DefaultBuckets,
+			"state",
+		),
+		sequenceDuration: registry.NewHistogram(
+			namespace+"sequence_duration_seconds",
+			"Duration of a finalized sequence",
+			sequenceBuckets,
+		),
+		roundChangesPerHeight: registry.NewHistogram(
+			namespace+"round_changes_per_height",
+			"Number of round changes before a height was finalized",
+			roundChangeBuckets,
+		),
+		finalizedRound: registry.NewHistogram(
+			namespace+"finalized_round",
+			"Round in which a height was finalized",
+			roundChangeBuckets,
+		),
+		droppedMessages: registry.NewCounter(
+			namespace+"messages_dropped_total",
+			"Number of incoming messages rejected by AddMessage, by reason",
+			"reason",
+		),
+	}
+}
+
+// SetView notes the view the state machine is currently in
+func (m *ConsensusMetrics) SetView(view *proto.View) {
+	m.height.Set(float64(view.Height))
+	m.round.Set(float64(view.Round))
+}
+
+// ObserveRoundDuration observes the duration of a single round
+func (m *ConsensusMetrics) ObserveRoundDuration(duration time.Duration) {
+	m.roundDuration.ObserveDuration(duration)
+}
+
+// IncRoundChange notes a round change for the specified reason
+func (m *ConsensusMetrics) IncRoundChange(reason core.RoundChangeReason) {
+	m.roundChanges.Inc(reason.String())
+}
+
+// ObserveStateDuration observes the time spent in the specified state
+func (m *ConsensusMetrics) ObserveStateDuration(name core.StateType, duration time.Duration) {
+	m.stateDuration.ObserveDuration(duration, name.String())
+}
+
+// ObserveSequence observes a finalized sequence
+func (m *ConsensusMetrics) ObserveSequence(result *core.SequenceResult) {
+	m.sequenceDuration.ObserveDuration(result.Duration)
+	m.roundChangesPerHeight.Observe(float64(len(result.RoundChanges)))
+	m.finalizedRound.Observe(float64(result.Round))
+}
+
+// IncDroppedMessage notes an incoming message rejected for the specified reason
+func (m *ConsensusMetrics) IncDroppedMessage(reason core.DropReason) {
+	m.droppedMessages.Inc(reason.String())
+}
+
+// MessageMetrics is the messages.Metrics implementation backed by a Registry
+type MessageMetrics struct {
+	messages *Counter
+	evicted  *Counter
+	rejected *Counter
+	size     *Gauge
+}
+
+// NewMessageMetrics registers the message metrics with the registry
+func NewMessageMetrics(registry *Registry) *MessageMetrics {
+	return &MessageMetrics{
+		messages: registry.NewCounter(
+			namespace+"messages_total",
+			"Number of messages added to the message store, by type",
+			"type",
+		),
+		evicted: registry.NewCounter(
+			namespace+"messages_evicted_total",
+			"Number of messages evicted from the message store to fit the size limit, by type",
+			"type",
+		),
+		rejected: registry.NewCounter(
+			namespace+"messages_rejected_total",
+			"Number of messages rejected for being over the sender quota, by type",
+			"type",
+		),
+		size: registry.NewGauge(
+			namespace+"messages_stored_bytes",
+			"Accumulated encoded size of the messages in the message store",
+		),
+	}
+}
+
+// IncMessage notes an added message of the specified type
+func (m *MessageMetrics) IncMessage(messageType proto.MessageType) {
+	m.messages.Inc(messageType.String())
+}
+
+// IncEvicted notes a message of the specified type
+// evicted from the store to fit the size limit
+func (m *MessageMetrics) IncEvicted(messageType proto.MessageType) {
+	m.evicted.Inc(messageType.String())
+}
+
+// IncRejected notes a message of the specified
+// type rejected for being over the sender quota
+func (m *MessageMetrics) IncRejected(messageType proto.MessageType) {
+	m.rejected.Inc(messageType.String())
+}
+
+// SetSize notes the accumulated encoded size of the stored messages, in bytes
+func (m *MessageMetrics) SetSize(size int) {
+	m.size.Set(float64(size))
+}
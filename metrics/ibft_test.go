@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/madz-lab/go-ibft/core"
+	"github.com/madz-lab/go-ibft/messages"
+	"github.com/madz-lab/go-ibft/messages/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConsensusMetrics makes sure the consensus
+// metrics are recorded correctly
+func TestConsensusMetrics(t *testing.T) {
+	t.Parallel()
+
+	var (
+		registry = NewRegistry()
+		metrics  = NewConsensusMetrics(registry)
+	)
+
+	metrics.SetView(&proto.View{Height: 10, Round: 2})
+	metrics.ObserveRoundDuration(time.Second)
+	metrics.IncRoundChange(core.RoundChangeTimeout)
+	metrics.IncRoundChange(core.RoundChangeTimeout)
+	metrics.IncRoundChange(core.RoundChangeFutureRCC)
+	metrics.IncDroppedMessage(core.DropFutureHeight)
+	metrics.ObserveStateDuration(core.StatePrepare, 100*time.Millisecond)
+	metrics.ObserveSequence(&core.SequenceResult{
+		Round: 2,
+		RoundChanges: []core.RoundChange{
+			{Round: 1, Reason: core.RoundChangeTimeout},
+			{Round: 2, Reason: core.RoundChangeFutureRCC},
+		},
+		Duration: 5 * time.Second,
+	})
+
+	assert.Equal(t, 10.0, metrics.height.Value())
+	assert.Equal(t, 2.0, metrics.round.Value())
+	assert.Equal(t, uint64(1), metrics.roundDuration.Count())
+	assert.Equal(t, 2.0, metrics.roundChanges.Value("round timeout"))
+	assert.Equal(t, 1.0, metrics.roundChanges.Value("future RCC"))
+	assert.Equal(t, 0.1, metrics.stateDuration.Sum("prepare"))
+	assert.Equal(t, 5.0, metrics.sequenceDuration.Sum())
+	assert.Equal(t, 2.0, metrics.roundChangesPerHeight.Sum())
+	assert.Equal(t, 2.0, metrics.finalizedRound.Sum())
+	assert.Equal(t, 1.0, metrics.droppedMessages.Value("future height"))
+
+	output := render(t, registry)
+
+	assert.Contains(t, output, `ibft_round_changes_total{reason="round timeout"} 2`)
+	assert.Contains(t, output, "ibft_height 10")
+}
+
+// TestMessageMetrics makes sure the message
+// metrics are fed by the message store
+func TestMessageMetrics(t *testing.T) {
+	t.Parallel()
+
+	var (
+		registry = NewRegistry()
+		metrics  = NewMessageMetrics(registry)
+		store    = messages.NewMessages(messages.WithMetrics(metrics))
+		view     = &proto.View{Height: 1, Round: 0}
+	)
+
+	defer store.Close()
+
+	for _, from := range []string{"node 0", "node 1"} {
+		store.AddMessage(&proto.Message{
+			View: view,
+			From: []byte(from),
+			Type: proto.MessageType_PREPARE,
+		})
+	}
+
+	store.AddMessage(&proto.Message{
+		View: view,
+		From: []byte("node 0"),
+		Type: proto.MessageType_COMMIT,
+	})
+
+	assert.Equal(t, 2.0, metrics.messages.Value("PREPARE"))
+	assert.Equal(t, 1.0, metrics.messages.Value("COMMIT"))
+	assert.Equal(t, float64(store.Size()), metrics.size.Value())
+	assert.Contains(t, render(t, registry), `ibft_messages_total{type="PREPARE"} 2`)
+
+	// Make sure evictions are reported
+	metrics.IncEvicted(proto.MessageType_COMMIT)
+	metrics.IncRejected(proto.MessageType_PREPARE)
+
+	assert.Equal(t, 1.0, metrics.evicted.Value("COMMIT"))
+	assert.Equal(t, 1.0, metrics.rejected.Value("PREPARE"))
+}
@@ -0,0 +1,234 @@
+// Package metrics implements an in-memory metrics registry,
+// that renders to the Prometheus text exposition format
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// ContentType is the content type of the Prometheus text exposition format
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	// labelSeparator separates the label values in series keys
+	labelSeparator = "\xff"
+)
+
+var (
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// collector is a single registered metric
+type collector interface {
+	// write renders the metric in the text exposition format
+	write(buf *bytes.Buffer)
+}
+
+// Registry holds a set of uniquely named metrics
+type Registry struct {
+	collectors map[string]collector
+
+	mux sync.RWMutex
+}
+
+// NewRegistry creates a new empty metrics registry
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]collector),
+	}
+}
+
+// register adds the metric to the registry.
+// It panics if the name is invalid, or already taken
+func (r *Registry) register(name string, c collector) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if _, exists := r.collectors[name]; exists {
+		panic(fmt.Sprintf("metrics: %q is already registered", name))
+	}
+
+	r.collectors[name] = c
+}
+
+// WritePrometheus writes all metrics, sorted by name,
+// in the Prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mux.RLock()
+
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		r.collectors[name].write(&buf)
+	}
+
+	r.mux.RUnlock()
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("unable to write metrics, %w", err)
+	}
+
+	return nil
+}
+
+// ServeHTTP serves all metrics in the Prometheus text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+
+	//nolint:errcheck // The response is already committed
+	r.WritePrometheus(w)
+}
+
+// family is the description shared by all series of a metric
+type family struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+}
+
+// newFamily creates a new metric description.
+// It panics if the metric or label names are invalid
+func newFamily(name, help, kind string, labelNames []string) family {
+	if !metricNameRegex.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+
+	for _, labelName := range labelNames {
+		if !labelNameRegex.MatchString(labelName) || strings.HasPrefix(labelName, "__") {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %q", labelName, name))
+		}
+	}
+
+	return family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: append([]string(nil), labelNames...),
+	}
+}
+
+// key returns the series key for the label values.
+// It panics if the number of label values doesn't match the label names
+func (f *family) key(labelValues []string) string {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf(
+			"metrics: %q expects %d label values, got %d",
+			f.name,
+			len(f.labelNames),
+			len(labelValues),
+		))
+	}
+
+	return strings.Join(labelValues, labelSeparator)
+}
+
+// writeHeader writes the HELP and TYPE lines of the metric
+func (f *family) writeHeader(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(buf, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// writeSample writes a single sample line. The extra label,
+// if set, is appended after the metric labels
+func (f *family) writeSample(
+	buf *bytes.Buffer,
+	suffix string,
+	labelValues []string,
+	extraLabel []string,
+	value float64,
+) {
+	buf.WriteString(f.name)
+	buf.WriteString(suffix)
+
+	if len(labelValues) > 0 || len(extraLabel) > 0 {
+		buf.WriteByte('{')
+
+		for index, labelName := range f.labelNames {
+			if index > 0 {
+				buf.WriteByte(',')
+			}
+
+			writeLabel(buf, labelName, labelValues[index])
+		}
+
+		if len(extraLabel) == 2 {
+			if len(labelValues) > 0 {
+				buf.WriteByte(',')
+			}
+
+			writeLabel(buf, extraLabel[0], extraLabel[1])
+		}
+
+		buf.WriteByte('}')
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(value))
+	buf.WriteByte('\n')
+}
+
+// writeLabel writes a single label pair
+func writeLabel(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(`="`)
+	buf.WriteString(escapeLabelValue(value))
+	buf.WriteByte('"')
+}
+
+// sortedKeys returns the series keys in a stable order
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp escapes the help text
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+// escapeLabelValue escapes the label value
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+// formatFloat formats the sample value
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
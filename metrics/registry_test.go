@@ -0,0 +1,216 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// render renders the registry in the text exposition format
+func render(t *testing.T, registry *Registry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, registry.WritePrometheus(&buf))
+
+	return buf.String()
+}
+
+// TestRegistry_Counter makes sure counters
+// are tracked and rendered correctly
+func TestRegistry_Counter(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+
+	counter := registry.NewCounter("requests_total", "Number of requests", "method", "code")
+
+	counter.Inc("GET", "200")
+	counter.Add(2, "GET", "200")
+	counter.Inc("POST", "500")
+
+	assert.Equal(t, 3.0, counter.Value("GET", "200"))
+	assert.Equal(t, 0.0, counter.Value("GET", "404"))
+
+	assert.Equal(
+		t,
+		`# HELP requests_total Number of requests
+# TYPE requests_total counter
+requests_total{method="GET",code="200"} 3
+requests_total{method="POST",code="500"} 1
+`,
+		render(t, registry),
+	)
+
+	// Make sure counters can't decrease
+	assert.Panics(t, func() {
+		counter.Add(-1, "GET", "200")
+	})
+}
+
+// TestRegistry_Gauge makes sure gauges
+// are tracked and rendered correctly
+func TestRegistry_Gauge(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+
+	gauge := registry.NewGauge("temperature", "Current temperature")
+
+	gauge.Set(10)
+	gauge.Add(-12.5)
+
+	assert.Equal(t, -2.5, gauge.Value())
+
+	assert.Equal(
+		t,
+		`# HELP temperature Current temperature
+# TYPE temperature gauge
+temperature -2.5
+`,
+		render(t, registry),
+	)
+}
+
+// TestRegistry_Order makes sure metrics and
+// series are rendered in a stable order
+func TestRegistry_Order(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+
+	second := registry.NewGauge("b", "Second", "label")
+	first := registry.NewGauge("a", "First")
+
+	second.Set(2, "y")
+	second.Set(1, "x")
+	first.Set(0)
+
+	assert.Equal(
+		t,
+		`# HELP a First
+# TYPE a gauge
+a 0
+# HELP b Second
+# TYPE b gauge
+b{label="x"} 1
+b{label="y"} 2
+`,
+		render(t, registry),
+	)
+}
+
+// TestRegistry_Escaping makes sure help texts and
+// label values are escaped correctly
+func TestRegistry_Escaping(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+
+	counter := registry.NewCounter("escaped", "Back\\slash and\nnewline", "label")
+	counter.Inc("quote \" back\\slash \n newline")
+
+	assert.Equal(
+		t,
+		`# HELP escaped Back\\slash and\nnewline
+# TYPE escaped counter
+escaped{label="quote \" back\\slash \n newline"} 1
+`,
+		render(t, registry),
+	)
+}
+
+// TestRegistry_InvalidUsage makes sure programming
+// errors are caught early
+func TestRegistry_InvalidUsage(t *testing.T) {
+	t.Parallel()
+
+	testTable := []struct {
+		name string
+		fn   func(registry *Registry)
+	}{
+		{
+			"invalid metric name",
+			func(registry *Registry) {
+				registry.NewCounter("invalid-name", "")
+			},
+		},
+		{
+			"invalid label name",
+			func(registry *Registry) {
+				registry.NewCounter("name", "", "invalid-label")
+			},
+		},
+		{
+			"reserved label name",
+			func(registry *Registry) {
+				registry.NewCounter("name", "", "__reserved")
+			},
+		},
+		{
+			"duplicate metric name",
+			func(registry *Registry) {
+				registry.NewCounter("name", "")
+				registry.NewGauge("name", "")
+			},
+		},
+		{
+			"label value count mismatch",
+			func(registry *Registry) {
+				registry.NewCounter("name", "", "label").Inc()
+			},
+		},
+	}
+
+	for _, testCase := range testTable {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Panics(t, func() {
+				testCase.fn(NewRegistry())
+			})
+		})
+	}
+}
+
+// errWriter is the writer that always fails
+type errWriter struct{}
+
+func (errWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+// TestRegistry_WriteError makes sure
+// write errors are propagated
+func TestRegistry_WriteError(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.NewCounter("name", "").Inc()
+
+	assert.Error(t, registry.WritePrometheus(errWriter{}))
+}
+
+// TestRegistry_ServeHTTP makes sure the metrics
+// are served over HTTP
+func TestRegistry_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.NewCounter("name", "Help").Inc()
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+	assert.Equal(t, render(t, registry), recorder.Body.String())
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+)
+
+// scalarValue is the value of a single scalar series
+type scalarValue struct {
+	labelValues []string
+	value       float64
+}
+
+// scalarSet holds the series of a scalar metric (counter or gauge)
+type scalarSet struct {
+	family
+
+	series map[string]*scalarValue
+
+	mux sync.Mutex
+}
+
+// newScalarSet creates a new empty scalar metric
+func newScalarSet(name, help, kind string, labelNames []string) *scalarSet {
+	return &scalarSet{
+		family: newFamily(name, help, kind, labelNames),
+		series: make(map[string]*scalarValue),
+	}
+}
+
+// getSeries returns the series for the label values, creating it if needed.
+// The caller needs to hold the lock
+func (s *scalarSet) getSeries(labelValues []string) *scalarValue {
+	key := s.key(labelValues)
+
+	series, ok := s.series[key]
+	if !ok {
+		series = &scalarValue{
+			labelValues: append([]string(nil), labelValues...),
+		}
+
+		s.series[key] = series
+	}
+
+	return series
+}
+
+// add adds the delta to the series value
+func (s *scalarSet) add(delta float64, labelValues []string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.getSeries(labelValues).value += delta
+}
+
+// set sets the series value
+func (s *scalarSet) set(value float64, labelValues []string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.getSeries(labelValues).value = value
+}
+
+// get returns the series value
+func (s *scalarSet) get(labelValues []string) float64 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	series, ok := s.series[s.key(labelValues)]
+	if !ok {
+		return 0
+	}
+
+	return series.value
+}
+
+// write renders the metric in the text exposition format
+func (s *scalarSet) write(buf *bytes.Buffer) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.writeHeader(buf)
+
+	for _, key := range sortedKeys(s.series) {
+		series := s.series[key]
+
+		s.writeSample(buf, "", series.labelValues, nil, series.value)
+	}
+}
+
+// Counter is a metric that can only increase
+type Counter struct {
+	*scalarSet
+}
+
+// NewCounter creates and registers a new counter.
+// It panics if the name is invalid, or already registered
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	counter := &Counter{newScalarSet(name, help, "counter", labelNames)}
+
+	r.register(name, counter)
+
+	return counter
+}
+
+// Inc increments the counter for the label values by 1
+func (c *Counter) Inc(labelValues ...string) {
+	c.add(1, labelValues)
+}
+
+// Add increases the counter for the label values by the delta.
+// It panics if the delta is negative
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %q can't decrease", c.name))
+	}
+
+	c.add(delta, labelValues)
+}
+
+// Value returns the counter value for the label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.get(labelValues)
+}
+
+// Gauge is a metric that can arbitrarily go up and down
+type Gauge struct {
+	*scalarSet
+}
+
+// NewGauge creates and registers a new gauge.
+// It panics if the name is invalid, or already registered
+func (r *Registry) NewGauge(name, help string, labelNames ...string) *Gauge {
+	gauge := &Gauge{newScalarSet(name, help, "gauge", labelNames)}
+
+	r.register(name, gauge)
+
+	return gauge
+}
+
+// Set sets the gauge for the label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.set(value, labelValues)
+}
+
+// Add adds the delta to the gauge for the label values
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.add(delta, labelValues)
+}
+
+// Value returns the gauge value for the label values
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.get(labelValues)
+}